@@ -2,53 +2,301 @@ package configs
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"reflect"
+	"time"
 
-	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+const redacted = "******"
+
+// AppConfig is the whole application configuration. Every setting can come
+// from the YAML file (yaml tag), the environment (env tag) or a -set flag
+// using its dotted YAML path. Fields tagged secret are redacted when printed.
 type AppConfig struct {
-	ServerPort            string
-	Dsn                   string
-	AppSecret             string
-	TwilioAccountSid      string
-	TwilioAuthToken       string
-	TwilioFromPhoneNumber string
-	StripeSecret          string
-	SuccessUrl            string
-	CancelUrl             string
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Sms         SmsConfig         `yaml:"sms"`
+	Payment     PaymentConfig     `yaml:"payment"`
+	Cart        CartConfig        `yaml:"cart"`
+	Tax         TaxConfig         `yaml:"tax"`
+	Payout      PayoutConfig      `yaml:"payout"`
+	Review      ReviewConfig      `yaml:"review"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+}
+
+// ServerConfig sets where the server listens and how long it waits for
+// in-flight requests and background jobs when shutting down.
+type ServerConfig struct {
+	Port            string        `yaml:"port" env:"HTTP_PORT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+// DatabaseConfig holds the connection string and the connection pool limits.
+type DatabaseConfig struct {
+	Dsn             string        `yaml:"dsn" env:"DSN" secret:"true"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+}
+
+type AuthConfig struct {
+	AppSecret string `yaml:"app_secret" env:"APP_SECRET" secret:"true"`
+}
+
+// SmsConfig holds the Twilio account used to text verification codes. With
+// SMS disabled the messages are only logged.
+type SmsConfig struct {
+	Enabled               bool   `yaml:"enabled" env:"SMS_ENABLED"`
+	TwilioAccountSid      string `yaml:"twilio_account_sid" env:"TWILIO_ACCOUNT_SID"`
+	TwilioAuthToken       string `yaml:"twilio_auth_token" env:"TWILIO_AUTH_TOKEN" secret:"true"`
+	TwilioFromPhoneNumber string `yaml:"twilio_from_phone_number" env:"TWILIO_FROM_PHONE_NUMBER"`
+}
+
+type PaymentConfig struct {
+	Provider     string        `yaml:"provider" env:"PAYMENT_PROVIDER"`
+	StripeSecret string        `yaml:"stripe_secret" env:"STRIPE_SECRET" secret:"true"`
+	SuccessUrl   string        `yaml:"success_url" env:"SUCCESS_URL"`
+	CancelUrl    string        `yaml:"cancel_url" env:"CANCEL_URL"`
+	SyncInterval time.Duration `yaml:"sync_interval" env:"PAYMENT_SYNC_INTERVAL"`
+	SyncAfter    time.Duration `yaml:"sync_after" env:"PAYMENT_SYNC_AFTER"`
+}
+
+type CartConfig struct {
+	GuestTTL time.Duration `yaml:"guest_ttl" env:"GUEST_CART_TTL"`
+}
+
+type TaxConfig struct {
+	RatesFile string `yaml:"rates_file" env:"TAX_RATES_FILE"`
+}
+
+// PayoutConfig sets the commission, how often payouts run and the secret the
+// bank's settlement callback must present. Without a secret payouts cannot be
+// settled over HTTP.
+type PayoutConfig struct {
+	CommissionRate float64       `yaml:"commission_rate" env:"COMMISSION_RATE"`
+	Interval       time.Duration `yaml:"interval" env:"PAYOUT_INTERVAL"`
+	WebhookSecret  string        `yaml:"webhook_secret" env:"PAYOUT_WEBHOOK_SECRET" secret:"true"`
+}
+
+// ReviewConfig sets how many flags hide a review until it is moderated. Zero
+// never hides flagged reviews.
+type ReviewConfig struct {
+	FlagThreshold int `yaml:"flag_threshold" env:"REVIEW_FLAG_THRESHOLD"`
+}
+
+type IdempotencyConfig struct {
+	KeyTTL time.Duration `yaml:"key_ttl" env:"IDEMPOTENCY_KEY_TTL"`
+}
+
+// RateLimitConfig sets the per IP and per account limits and the lockout
+// applied after repeated failed logins. Zero turns a limit off.
+type RateLimitConfig struct {
+	Store            string        `yaml:"store" env:"RATE_LIMIT_STORE"`
+	PerIP            int           `yaml:"per_ip" env:"RATE_LIMIT_PER_IP"`
+	PerAccount       int           `yaml:"per_account" env:"RATE_LIMIT_PER_ACCOUNT"`
+	Window           time.Duration `yaml:"window" env:"RATE_LIMIT_WINDOW"`
+	LockoutThreshold int           `yaml:"lockout_threshold" env:"LOCKOUT_THRESHOLD"`
+	LockoutDuration  time.Duration `yaml:"lockout_duration" env:"LOCKOUT_DURATION"`
+	MaxLockout       time.Duration `yaml:"max_lockout" env:"MAX_LOCKOUT_DURATION"`
+}
+
+// LogConfig sets the minimum level (debug, info, warn or error) and the
+// format (json or text) of the application logs.
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// TracingConfig selects where OpenTelemetry spans are exported: none, stdout
+// or otlp, which sends them over HTTP to Endpoint.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// Defaults returns the configuration used for every setting that is not
+// overridden.
+func Defaults() AppConfig {
+	return AppConfig{
+		Server: ServerConfig{ShutdownTimeout: 15 * time.Second},
+		Database: DatabaseConfig{
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Sms: SmsConfig{Enabled: true},
+		Payment: PaymentConfig{
+			Provider:     "stripe",
+			SyncInterval: 5 * time.Minute,
+			SyncAfter:    15 * time.Minute,
+		},
+		Cart: CartConfig{GuestTTL: 7 * 24 * time.Hour},
+		Payout: PayoutConfig{
+			CommissionRate: 10,
+			Interval:       24 * time.Hour,
+		},
+		Review:      ReviewConfig{FlagThreshold: 3},
+		Idempotency: IdempotencyConfig{KeyTTL: 24 * time.Hour},
+		RateLimit: RateLimitConfig{
+			Store:            "memory",
+			PerIP:            20,
+			PerAccount:       5,
+			Window:           time.Minute,
+			LockoutThreshold: 5,
+			LockoutDuration:  time.Minute,
+			MaxLockout:       time.Hour,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "go-ecommerce-app",
+			SampleRatio: 1,
+		},
+	}
 }
 
-func SetupEnv() (cfg AppConfig, err error) {
+// Validate checks every setting the enabled features depend on and reports
+// all problems at once.
+func (c AppConfig) Validate() error {
+	var errs []error
+	required := func(name, value string) {
+		if len(value) < 1 {
+			errs = append(errs, fmt.Errorf("%s is required", name))
+		}
+	}
+	positive := func(name string, d time.Duration) {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be a positive duration", name))
+		}
+	}
+	nonNegative := func(name string, n int) {
+		if n < 0 {
+			errs = append(errs, fmt.Errorf("%s must be a non negative integer", name))
+		}
+	}
+
+	required("server.port", c.Server.Port)
+	positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
 
-	if os.Getenv("APP_ENV") == "dev" {
-		godotenv.Load()
+	required("database.dsn", c.Database.Dsn)
+	nonNegative("database.max_open_conns", c.Database.MaxOpenConns)
+	nonNegative("database.max_idle_conns", c.Database.MaxIdleConns)
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, errors.New("database.max_idle_conns must not exceed database.max_open_conns"))
 	}
+	positive("database.conn_max_lifetime", c.Database.ConnMaxLifetime)
+	positive("database.conn_max_idle_time", c.Database.ConnMaxIdleTime)
+	required("auth.app_secret", c.Auth.AppSecret)
 
-	httpPort := os.Getenv("HTTP_PORT")
-	if len(httpPort) < 1 {
-		return AppConfig{}, errors.New("HTTP_PORT is not set")
+	if c.Sms.Enabled {
+		required("sms.twilio_account_sid", c.Sms.TwilioAccountSid)
+		required("sms.twilio_auth_token", c.Sms.TwilioAuthToken)
+		required("sms.twilio_from_phone_number", c.Sms.TwilioFromPhoneNumber)
 	}
 
-	Dsn := os.Getenv("DSN")
-	if len(Dsn) < 1 {
-		return AppConfig{}, errors.New("DSN is not set")
+	switch c.Payment.Provider {
+	case "stripe":
+		required("payment.stripe_secret", c.Payment.StripeSecret)
+		required("payment.success_url", c.Payment.SuccessUrl)
+		required("payment.cancel_url", c.Payment.CancelUrl)
+	case "fake":
+		required("payment.success_url", c.Payment.SuccessUrl)
+	case "cod":
+	default:
+		errs = append(errs, errors.New("payment.provider must be stripe, cod or fake"))
 	}
+	positive("payment.sync_interval", c.Payment.SyncInterval)
+	positive("payment.sync_after", c.Payment.SyncAfter)
 
-	appSecret := os.Getenv("APP_SECRET")
-	if len(appSecret) < 1 {
-		return AppConfig{}, errors.New("APP_SECRET is not set")
+	positive("cart.guest_ttl", c.Cart.GuestTTL)
+
+	if len(c.Tax.RatesFile) > 0 {
+		if _, err := os.Stat(c.Tax.RatesFile); err != nil {
+			errs = append(errs, fmt.Errorf("tax.rates_file cannot be read: %v", err))
+		}
 	}
 
-	return AppConfig{
-		ServerPort:            httpPort,
-		Dsn:                   Dsn,
-		AppSecret:             appSecret,
-		TwilioAccountSid:      os.Getenv("TWILIO_ACCOUNT_SID"),
-		TwilioAuthToken:       os.Getenv("TWILIO_AUTH_TOKEN"),
-		TwilioFromPhoneNumber: os.Getenv("TWILIO_FROM_PHONE_NUMBER"),
-		StripeSecret:          os.Getenv("STRIPE_SECRET"),
-		SuccessUrl:            os.Getenv("SUCCESS_URL"),
-		CancelUrl:             os.Getenv("CANCEL_URL"),
-	}, nil
+	if c.Payout.CommissionRate < 0 || c.Payout.CommissionRate > 100 {
+		errs = append(errs, errors.New("payout.commission_rate must be a percentage between 0 and 100"))
+	}
+	positive("payout.interval", c.Payout.Interval)
+
+	nonNegative("review.flag_threshold", c.Review.FlagThreshold)
+
+	positive("idempotency.key_ttl", c.Idempotency.KeyTTL)
+
+	if c.RateLimit.Store != "memory" && c.RateLimit.Store != "postgres" {
+		errs = append(errs, errors.New("rate_limit.store must be memory or postgres"))
+	}
+	nonNegative("rate_limit.per_ip", c.RateLimit.PerIP)
+	nonNegative("rate_limit.per_account", c.RateLimit.PerAccount)
+	positive("rate_limit.window", c.RateLimit.Window)
+	nonNegative("rate_limit.lockout_threshold", c.RateLimit.LockoutThreshold)
+	if c.RateLimit.LockoutThreshold > 0 {
+		positive("rate_limit.lockout_duration", c.RateLimit.LockoutDuration)
+		if c.RateLimit.MaxLockout < c.RateLimit.LockoutDuration {
+			errs = append(errs, errors.New("rate_limit.max_lockout must not be shorter than rate_limit.lockout_duration"))
+		}
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, errors.New("log.level must be debug, info, warn or error"))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, errors.New("log.format must be json or text"))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		required("tracing.endpoint", c.Tracing.Endpoint)
+	default:
+		errs = append(errs, errors.New("tracing.exporter must be none, stdout or otlp"))
+	}
+	if c.Tracing.Exporter != "none" {
+		required("tracing.service_name", c.Tracing.ServiceName)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the configuration with every secret that is set
+// masked, safe to print or log.
+func (c AppConfig) Redacted() AppConfig {
+	walkFields(reflect.ValueOf(&c).Elem(), "", func(field reflect.Value, sf reflect.StructField, _ string) error {
+		if sf.Tag.Get("secret") == "true" && field.Kind() == reflect.String && field.Len() > 0 {
+			field.SetString(redacted)
+		}
+		return nil
+	})
+
+	return c
+}
+
+// String prints the redacted configuration as YAML, so a config that ends up
+// in a log line never leaks its secrets.
+func (c AppConfig) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(out)
 }
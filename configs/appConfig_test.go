@@ -0,0 +1,17 @@
+package configs
+
+import "testing"
+
+// The defaults must leave only the deployment specific settings to fill in.
+func TestDefaultsValidateWithRequiredSettings(t *testing.T) {
+	config := Defaults()
+	config.Server.Port = ":9000"
+	config.Database.Dsn = "host=localhost"
+	config.Auth.AppSecret = "secret"
+	config.Sms.Enabled = false
+	config.Payment.Provider = "cod"
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() = %v; want the defaults to be valid", err)
+	}
+}
@@ -0,0 +1,204 @@
+package configs
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the configuration in layers, each overriding the one before:
+// defaults, the YAML file named by -config or CONFIG_FILE, the environment
+// and finally -set section.key=value flags. The result is not validated.
+func Load(args []string) (AppConfig, error) {
+	if os.Getenv("APP_ENV") == "dev" {
+		godotenv.Load()
+	}
+
+	var file string
+	var overrides setFlags
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.StringVar(&file, "config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	fs.Var(&overrides, "set", "override a setting, e.g. -set payment.provider=cod (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return AppConfig{}, err
+	}
+
+	cfg := Defaults()
+
+	if len(file) > 0 {
+		if err := loadFile(&cfg, file); err != nil {
+			return AppConfig{}, err
+		}
+	}
+
+	if err := loadEnv(&cfg); err != nil {
+		return AppConfig{}, err
+	}
+
+	for _, o := range overrides {
+		path, value, ok := strings.Cut(o, "=")
+		if !ok {
+			return AppConfig{}, fmt.Errorf("-set %q must be formatted as key=value", o)
+		}
+		if err := set(&cfg, path, value); err != nil {
+			return AppConfig{}, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// LoadAndValidate loads the configuration and fails when it is not valid.
+func LoadAndValidate(args []string) (AppConfig, error) {
+	cfg, err := Load(args)
+	if err != nil {
+		return AppConfig{}, err
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return AppConfig{}, err
+	}
+
+	return cfg, nil
+}
+
+type setFlags []string
+
+func (s *setFlags) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *setFlags) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// loadFile overlays the YAML file on cfg. Unknown keys are rejected so a typo
+// does not silently leave a default in place.
+func loadFile(cfg *AppConfig, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("reading config file failed: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err = dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s failed: %w", file, err)
+	}
+
+	return nil
+}
+
+func loadEnv(cfg *AppConfig) error {
+	return walkFields(reflect.ValueOf(cfg).Elem(), "", func(field reflect.Value, sf reflect.StructField, path string) error {
+		name := sf.Tag.Get("env")
+		if len(name) < 1 {
+			return nil
+		}
+
+		value := os.Getenv(name)
+		if len(value) < 1 {
+			return nil
+		}
+
+		if err := setValue(field, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+func set(cfg *AppConfig, path string, value string) error {
+	found := false
+	err := walkFields(reflect.ValueOf(cfg).Elem(), "", func(field reflect.Value, _ reflect.StructField, p string) error {
+		if p != path {
+			return nil
+		}
+
+		found = true
+		if err := setValue(field, value); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("unknown setting %q", path)
+	}
+
+	return nil
+}
+
+// walkFields calls fn for every leaf field of v with its dotted YAML path.
+func walkFields(v reflect.Value, prefix string, fn func(field reflect.Value, sf reflect.StructField, path string) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		path := prefix + name
+
+		if sf.Type.Kind() == reflect.Struct {
+			if err := walkFields(v.Field(i), path+".", fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(v.Field(i), sf, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("must be a duration such as 15m")
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+
+	return nil
+}
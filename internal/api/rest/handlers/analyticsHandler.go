@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bytes"
+	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AnalyticsHandler struct {
+	svc services.AnalyticsService
+}
+
+func SetupAnalyticsRoutes(rh *rest.RestHandler) {
+	app := rh.App
+
+	handler := &AnalyticsHandler{
+		svc: rh.Services.Analytics,
+	}
+
+	sellerRoutes := app.Group("/seller", rh.Auth.AuthorizeSeller)
+	sellerRoutes.Get("/analytics/sales", handler.GetSalesReport)
+	sellerRoutes.Get("/analytics/sales/export", handler.ExportSales)
+}
+
+// GetSalesReport takes ?from= and ?to= dates, a ?bucket= of day, week or
+// month and the ?limit= of top products.
+func (h *AnalyticsHandler) GetSalesReport(ctx *fiber.Ctx) error {
+	q, msg := salesReportQuery(ctx)
+	if len(msg) > 0 {
+		return rest.BadRequestError(ctx, msg)
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	report, err := h.svc.GetSalesReport(ctx.UserContext(), user, q)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "sales report", report)
+}
+
+// ExportSales downloads the sales report as CSV, by period or, with
+// ?report=products, by top product.
+func (h *AnalyticsHandler) ExportSales(ctx *fiber.Ctx) error {
+	q, msg := salesReportQuery(ctx)
+	if len(msg) > 0 {
+		return rest.BadRequestError(ctx, msg)
+	}
+	report := ctx.Query("report", services.SalesExportSeries)
+
+	var out bytes.Buffer
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	if err := h.svc.ExportSales(ctx.UserContext(), user, q, report, &out); err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	ctx.Attachment("sales-" + report + ".csv")
+	ctx.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	return ctx.Status(fiber.StatusOK).Send(out.Bytes())
+}
+
+func salesReportQuery(ctx *fiber.Ctx) (dto.SalesReportQuery, string) {
+	from, to, msg := reportPeriod(ctx)
+	return dto.SalesReportQuery{
+		From:   from,
+		To:     to,
+		Bucket: ctx.Query("bucket"),
+		Limit:  ctx.QueryInt("limit"),
+	}, msg
+}
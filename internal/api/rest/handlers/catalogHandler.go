@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"go-ecommerce-app/internal/api/rest"
-	"go-ecommerce-app/internal/domain"
 	"go-ecommerce-app/internal/dto"
 	"go-ecommerce-app/internal/repository"
 	"go-ecommerce-app/internal/services"
@@ -18,14 +17,8 @@ type CatalogHandler struct {
 func SetupCatalogRoutes(rh *rest.RestHandler) {
 	app := rh.App
 
-	svc := services.CatalogService{
-		Repo:   repository.NewCatalogRepository(rh.DB),
-		Auth:   rh.Auth,
-		Config: rh.Config,
-	}
-
 	handler := &CatalogHandler{
-		svc: svc,
+		svc: rh.Services.Catalog,
 	}
 
 	// Public routes
@@ -44,7 +37,7 @@ func SetupCatalogRoutes(rh *rest.RestHandler) {
 
 	// Product routes
 	sellerRoutes.Post("/products", handler.CreateProducts)
-	sellerRoutes.Get("/products", handler.GetProducts)
+	sellerRoutes.Get("/products", handler.GetSellerProducts)
 	sellerRoutes.Get("/products/:id", handler.GetProduct)
 	sellerRoutes.Put("/products/:id", handler.EditProduct)
 	sellerRoutes.Patch("/products/:id", handler.UpdateStock)
@@ -53,20 +46,32 @@ func SetupCatalogRoutes(rh *rest.RestHandler) {
 
 // /////////////////////////// Categories /////////////////////////////////////
 func (h *CatalogHandler) GetCategories(ctx *fiber.Ctx) error {
-	cats, err := h.svc.GetCategories()
+	filter := repository.CategoryFilter{
+		Search: ctx.Query("q"),
+	}
+	if parentId := ctx.Query("parent_id"); len(parentId) > 0 {
+		id, err := strconv.Atoi(parentId)
+		if err != nil || id < 0 {
+			return rest.BadRequestError(ctx, "parent_id must be a number")
+		}
+		pid := uint(id)
+		filter.ParentId = &pid
+	}
+
+	cats, meta, err := h.svc.GetCategories(ctx.UserContext(), rest.PaginationQuery(ctx), filter)
 	if err != nil {
-		return rest.ErrorMessage(ctx, 404, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
-	return rest.SuccessMessage(ctx, "GetCategories", cats)
+	return rest.PaginatedMessage(ctx, "GetCategories", cats, meta)
 }
 
 func (h *CatalogHandler) GetCategoryById(ctx *fiber.Ctx) error {
 	id, _ := strconv.Atoi(ctx.Params("id"))
 
-	cat, err := h.svc.GetCategory(id)
+	cat, err := h.svc.GetCategory(ctx.UserContext(), id)
 	if err != nil {
-		return rest.ErrorMessage(ctx, 404, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return rest.SuccessMessage(ctx, "GetCategoryById", cat)
@@ -80,9 +85,9 @@ func (h *CatalogHandler) CreateCategories(ctx *fiber.Ctx) error {
 		return rest.BadRequestError(ctx, "create category request body is not valid")
 	}
 
-	err = h.svc.CreateCategory(req)
+	err = h.svc.CreateCategory(ctx.UserContext(), req)
 	if err != nil {
-		return rest.InternalError(ctx, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return rest.SuccessMessage(ctx, "category created succesfully", nil)
@@ -97,9 +102,9 @@ func (h *CatalogHandler) EditCategory(ctx *fiber.Ctx) error {
 		return rest.BadRequestError(ctx, "update category request body is not valid")
 	}
 
-	updatedCat, err := h.svc.EditCategory(id, req)
+	updatedCat, err := h.svc.EditCategory(ctx.UserContext(), id, req)
 	if err != nil {
-		return rest.InternalError(ctx, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return rest.SuccessMessage(ctx, "category updated succesfully", updatedCat)
@@ -107,9 +112,9 @@ func (h *CatalogHandler) EditCategory(ctx *fiber.Ctx) error {
 
 func (h *CatalogHandler) DeleteCategory(ctx *fiber.Ctx) error {
 	id, _ := strconv.Atoi(ctx.Params("id"))
-	err := h.svc.DeleteCategory(id)
+	err := h.svc.DeleteCategory(ctx.UserContext(), id)
 	if err != nil {
-		return rest.InternalError(ctx, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return rest.SuccessMessage(ctx, "category deleted successfully", nil)
@@ -125,9 +130,9 @@ func (h *CatalogHandler) CreateProducts(ctx *fiber.Ctx) error {
 	}
 
 	user := h.svc.Auth.GetCurrentUser(ctx)
-	err = h.svc.CreateProduct(req, user)
+	err = h.svc.CreateProduct(ctx.UserContext(), req, user)
 	if err != nil {
-		return rest.InternalError(ctx, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return rest.SuccessMessage(ctx, "product created successfully", nil)
@@ -142,29 +147,54 @@ func (h *CatalogHandler) EditProduct(ctx *fiber.Ctx) error {
 	}
 
 	user := h.svc.Auth.GetCurrentUser(ctx)
-	product, err := h.svc.EditProduct(id, req, user)
+	product, err := h.svc.EditProduct(ctx.UserContext(), id, req, user)
 	if err != nil {
-		return rest.InternalError(ctx, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return rest.SuccessMessage(ctx, "CreateProducts", product)
 }
 
 func (h *CatalogHandler) GetProducts(ctx *fiber.Ctx) error {
-	products, err := h.svc.GetProducts()
+	filter := productFilterQuery(ctx)
+	filter.SellerId = uint(ctx.QueryInt("seller_id"))
+
+	products, meta, err := h.svc.GetProducts(ctx.UserContext(), rest.PaginationQuery(ctx), filter)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.PaginatedMessage(ctx, "products", products, meta)
+}
+
+func (h *CatalogHandler) GetSellerProducts(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+
+	products, meta, err := h.svc.GetSellerProducts(ctx.UserContext(), int(user.ID), rest.PaginationQuery(ctx), productFilterQuery(ctx))
 	if err != nil {
-		return rest.ErrorMessage(ctx, 404, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
-	return rest.SuccessMessage(ctx, "products", products)
+	return rest.PaginatedMessage(ctx, "seller products", products, meta)
+}
+
+func productFilterQuery(ctx *fiber.Ctx) repository.ProductFilter {
+	return repository.ProductFilter{
+		CategoryId: uint(ctx.QueryInt("category_id")),
+		MinPrice:   ctx.QueryFloat("min_price"),
+		MaxPrice:   ctx.QueryFloat("max_price"),
+		Search:     ctx.Query("q"),
+		InStock:    ctx.QueryBool("in_stock"),
+		MinRating:  ctx.QueryFloat("min_rating"),
+	}
 }
 
 func (h *CatalogHandler) GetProduct(ctx *fiber.Ctx) error {
 	id, _ := strconv.Atoi(ctx.Params("id"))
 
-	product, err := h.svc.GetProductById(id)
+	product, err := h.svc.GetProductById(ctx.UserContext(), id)
 	if err != nil {
-		return rest.ErrorMessage(ctx, 404, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return rest.SuccessMessage(ctx, "GetProduct", product)
@@ -179,13 +209,10 @@ func (h *CatalogHandler) UpdateStock(ctx *fiber.Ctx) error {
 	}
 
 	user := h.svc.Auth.GetCurrentUser(ctx)
-
-	product := domain.Product{
-		ID:     uint(id),
-		Stock:  uint(req.Stock),
-		UserId: int(user.ID),
+	updateProduct, err := h.svc.UpdateProductStock(ctx.UserContext(), id, req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
 	}
-	updateProduct, _ := h.svc.UpdateProductStock(product)
 
 	return rest.SuccessMessage(ctx, "update stock successfully", updateProduct)
 }
@@ -194,7 +221,10 @@ func (h *CatalogHandler) DeleteProduct(ctx *fiber.Ctx) error {
 	id, _ := strconv.Atoi(ctx.Params("id"))
 
 	user := h.svc.Auth.GetCurrentUser(ctx)
-	err := h.svc.DeleteProduct(id, user)
+	err := h.svc.DeleteProduct(ctx.UserContext(), id, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
 
-	return rest.SuccessMessage(ctx, "delete product", err)
+	return rest.SuccessMessage(ctx, "delete product", nil)
 }
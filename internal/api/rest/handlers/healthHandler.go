@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	repo  repository.HealthRepository
+	ready func() bool
+}
+
+func SetupHealthRoutes(rh *rest.RestHandler) {
+	app := rh.App
+
+	handler := &HealthHandler{
+		repo:  rh.Services.Repos.Health,
+		ready: rh.Ready.Load,
+	}
+
+	app.Get("/healthz", handler.Health)
+	app.Get("/readyz", handler.Ready)
+}
+
+// Health reports whether the process is up and can reach its database.
+func (h *HealthHandler) Health(ctx *fiber.Ctx) error {
+	c, cancel := context.WithTimeout(ctx.Context(), healthCheckTimeout)
+	defer cancel()
+
+	if err := h.repo.Ping(c); err != nil {
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":   "unavailable",
+			"database": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":   "ok",
+		"database": "ok",
+	})
+}
+
+// Ready reports whether the server should receive traffic: the database is
+// reachable, every table is migrated and the server is not shutting down.
+func (h *HealthHandler) Ready(ctx *fiber.Ctx) error {
+	checks := fiber.Map{"database": "ok", "migrations": "ok", "server": "ok"}
+	ready := true
+
+	c, cancel := context.WithTimeout(ctx.Context(), healthCheckTimeout)
+	defer cancel()
+
+	if err := h.repo.Ping(c); err != nil {
+		checks["database"] = err.Error()
+		checks["migrations"] = "unknown"
+		ready = false
+	} else if missing, err := h.repo.MissingTables(ctx.UserContext(), domain.Models()); err != nil {
+		checks["migrations"] = err.Error()
+		ready = false
+	} else if len(missing) > 0 {
+		checks["migrations"] = fiber.Map{"missing_tables": missing}
+		ready = false
+	}
+
+	if !h.ready() {
+		checks["server"] = "starting or shutting down"
+		ready = false
+	}
+
+	status := fiber.StatusOK
+	checks["status"] = "ready"
+	if !ready {
+		status = fiber.StatusServiceUnavailable
+		checks["status"] = "not ready"
+	}
+
+	return ctx.Status(status).JSON(checks)
+}
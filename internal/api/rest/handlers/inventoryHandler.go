@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/repository"
+	"go-ecommerce-app/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type InventoryHandler struct {
+	svc        services.InventoryService
+	catalogSvc services.CatalogService
+}
+
+func SetupInventoryRoutes(rh *rest.RestHandler) {
+	app := rh.App
+
+	handler := &InventoryHandler{
+		svc:        rh.Services.Inventory,
+		catalogSvc: rh.Services.Catalog,
+	}
+
+	sellerRoutes := app.Group("/seller", rh.Auth.AuthorizeSeller)
+	sellerRoutes.Get("/inventory/movements", handler.GetMovements)
+	sellerRoutes.Get("/inventory/drift", handler.GetStockDrift)
+	sellerRoutes.Post("/inventory/reconcile", handler.Reconcile)
+	sellerRoutes.Post("/inventory/import", handler.ImportStock)
+}
+
+// GetMovements lists the seller's stock history, optionally narrowed with
+// ?product_id= and ?reason=.
+func (h *InventoryHandler) GetMovements(ctx *fiber.Ctx) error {
+	filter := repository.MovementFilter{
+		ProductId: uint(ctx.QueryInt("product_id")),
+		Reason:    ctx.Query("reason"),
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	movements, meta, err := h.svc.GetMovements(ctx.UserContext(), user, rest.PaginationQuery(ctx), filter)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.PaginatedMessage(ctx, "inventory movements", movements, meta)
+}
+
+func (h *InventoryHandler) GetStockDrift(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	drift, err := h.svc.GetStockDrift(ctx.UserContext(), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "stock drift", drift)
+}
+
+func (h *InventoryHandler) Reconcile(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	drift, err := h.svc.Reconcile(ctx.UserContext(), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "stock reconciled with inventory movements", drift)
+}
+
+// ImportStock sets the stock of several products from one request, such as a
+// warehouse count.
+func (h *InventoryHandler) ImportStock(ctx *fiber.Ctx) error {
+	req := dto.ImportStockRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "stock import request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	products, err := h.catalogSvc.ImportStock(ctx.UserContext(), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "stock imported", products)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// payoutSecretHeader carries the shared secret on payout settlement callbacks.
+const payoutSecretHeader = "X-Payout-Secret"
+
+type LedgerHandler struct {
+	svc services.LedgerService
+	// secret authenticates the bank's settlement callbacks. The callback is
+	// disabled while it is empty.
+	secret string
+}
+
+func SetupLedgerRoutes(rh *rest.RestHandler) {
+	app := rh.App
+
+	handler := &LedgerHandler{
+		svc:    rh.Services.Ledger,
+		secret: rh.Config.Payout.WebhookSecret,
+	}
+
+	app.Post("/payouts/:id/settlement", handler.SettlePayout)
+
+	sellerRoutes := app.Group("/seller", rh.Auth.AuthorizeSeller)
+	sellerRoutes.Get("/balance", handler.GetBalance)
+	sellerRoutes.Get("/statement", handler.GetStatement)
+	sellerRoutes.Get("/payouts", handler.GetPayouts)
+}
+
+func (h *LedgerHandler) GetBalance(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	balance, err := h.svc.GetBalance(ctx.UserContext(), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "seller balance", balance)
+}
+
+func (h *LedgerHandler) GetStatement(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	entries, meta, err := h.svc.GetStatement(ctx.UserContext(), user, rest.PaginationQuery(ctx))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.PaginatedMessage(ctx, "seller statement", entries, meta)
+}
+
+func (h *LedgerHandler) GetPayouts(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	payouts, meta, err := h.svc.GetPayouts(ctx.UserContext(), user, rest.PaginationQuery(ctx))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.PaginatedMessage(ctx, "seller payouts", payouts, meta)
+}
+
+// SettlePayout is called back once the bank has paid or rejected a payout.
+func (h *LedgerHandler) SettlePayout(ctx *fiber.Ctx) error {
+	if len(h.secret) < 1 {
+		return rest.ErrorMessage(ctx, fiber.StatusNotFound, errors.New("payout settlement is not enabled"))
+	}
+	if subtle.ConstantTimeCompare([]byte(ctx.Get(payoutSecretHeader)), []byte(h.secret)) != 1 {
+		return rest.ErrorMessage(ctx, fiber.StatusUnauthorized, errors.New("payout secret is not valid"))
+	}
+
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	req := dto.PayoutSettlementRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "payout settlement request body is not valid")
+	}
+
+	payout, err := h.svc.SettlePayout(ctx.UserContext(), uint(id), req.Status)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "payout settled", payout)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PromotionHandler struct {
+	svc services.PromotionService
+}
+
+func SetupPromotionRoutes(rh *rest.RestHandler) {
+	app := rh.App
+
+	handler := &PromotionHandler{
+		svc: rh.Services.Promotions,
+	}
+
+	sellerRoutes := app.Group("/seller", rh.Auth.AuthorizeSeller)
+	sellerRoutes.Post("/coupons", handler.CreateCoupon)
+	sellerRoutes.Get("/coupons", handler.GetCoupons)
+	sellerRoutes.Delete("/coupons/:id", handler.DeactivateCoupon)
+
+	// platform wide coupons, applying to every seller's products
+	adminRoutes := app.Group("/admin", rh.Auth.AuthorizeAdmin)
+	adminRoutes.Post("/coupons", handler.CreateCoupon)
+	adminRoutes.Get("/coupons", handler.GetCoupons)
+	adminRoutes.Delete("/coupons/:id", handler.DeactivateCoupon)
+}
+
+func (h *PromotionHandler) CreateCoupon(ctx *fiber.Ctx) error {
+	req := dto.CreateCouponRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "create coupon request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	coupon, err := h.svc.CreateCoupon(ctx.UserContext(), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "coupon created successfully", coupon)
+}
+
+func (h *PromotionHandler) GetCoupons(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	coupons, meta, err := h.svc.GetSellerCoupons(ctx.UserContext(), user, rest.PaginationQuery(ctx))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.PaginatedMessage(ctx, "coupons", coupons, meta)
+}
+
+func (h *PromotionHandler) DeactivateCoupon(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	err := h.svc.DeactivateCoupon(ctx.UserContext(), uint(id), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "coupon deactivated successfully", nil)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReviewHandler struct {
+	svc services.ReviewService
+}
+
+func SetupReviewRoutes(rh *rest.RestHandler) {
+	app := rh.App
+
+	handler := &ReviewHandler{
+		svc: rh.Services.Reviews,
+	}
+
+	app.Get("/products/:id/reviews", handler.GetProductReviews)
+	app.Post("/products/:id/reviews", rh.Auth.Authorize, handler.CreateReview)
+
+	reviewRoutes := app.Group("/reviews", rh.Auth.Authorize)
+	reviewRoutes.Put("/:id", handler.UpdateReview)
+	reviewRoutes.Delete("/:id", handler.DeleteReview)
+	reviewRoutes.Post("/:id/flag", handler.FlagReview)
+
+	sellerRoutes := app.Group("/seller", rh.Auth.AuthorizeSeller)
+	sellerRoutes.Get("/reviews", handler.GetSellerReviews)
+	sellerRoutes.Post("/reviews/:id/reply", handler.ReplyToReview)
+
+	adminRoutes := app.Group("/admin", rh.Auth.AuthorizeAdmin)
+	adminRoutes.Get("/reviews/flagged", handler.GetFlaggedReviews)
+	adminRoutes.Post("/reviews/:id/restore", handler.RestoreReview)
+}
+
+func (h *ReviewHandler) GetProductReviews(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	reviews, meta, err := h.svc.GetProductReviews(ctx.UserContext(), uint(id), rest.PaginationQuery(ctx))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.PaginatedMessage(ctx, "reviews", reviews, meta)
+}
+
+func (h *ReviewHandler) CreateReview(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	req := dto.ReviewRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "review request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	review, err := h.svc.CreateReview(ctx.UserContext(), uint(id), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "review created successfully", review)
+}
+
+func (h *ReviewHandler) UpdateReview(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	req := dto.ReviewRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "review request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	review, err := h.svc.UpdateReview(ctx.UserContext(), uint(id), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "review updated successfully", review)
+}
+
+func (h *ReviewHandler) DeleteReview(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	if err := h.svc.DeleteReview(ctx.UserContext(), uint(id), user); err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "review deleted successfully", nil)
+}
+
+func (h *ReviewHandler) FlagReview(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	req := dto.ReviewFlagRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "flag review request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	if err := h.svc.FlagReview(ctx.UserContext(), uint(id), req, user); err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "review flagged for moderation", nil)
+}
+
+func (h *ReviewHandler) GetSellerReviews(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	reviews, meta, err := h.svc.GetSellerReviews(ctx.UserContext(), user, rest.PaginationQuery(ctx))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.PaginatedMessage(ctx, "seller reviews", reviews, meta)
+}
+
+func (h *ReviewHandler) ReplyToReview(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	req := dto.ReviewReplyRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "reply request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	review, err := h.svc.ReplyToReview(ctx.UserContext(), uint(id), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "reply saved successfully", review)
+}
+
+func (h *ReviewHandler) GetFlaggedReviews(ctx *fiber.Ctx) error {
+	reviews, meta, err := h.svc.GetFlaggedReviews(ctx.UserContext(), rest.PaginationQuery(ctx))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.PaginatedMessage(ctx, "flagged reviews", reviews, meta)
+}
+
+func (h *ReviewHandler) RestoreReview(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	review, err := h.svc.RestoreReview(ctx.UserContext(), uint(id))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "review restored", review)
+}
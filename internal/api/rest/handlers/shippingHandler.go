@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ShippingHandler struct {
+	svc services.ShippingService
+}
+
+func SetupShippingRoutes(rh *rest.RestHandler) {
+	app := rh.App
+
+	handler := &ShippingHandler{
+		svc: rh.Services.Shipping,
+	}
+
+	sellerRoutes := app.Group("/seller", rh.Auth.AuthorizeSeller)
+	sellerRoutes.Post("/shipping-profiles", handler.CreateProfile)
+	sellerRoutes.Get("/shipping-profiles", handler.GetProfiles)
+	sellerRoutes.Put("/shipping-profiles/:id", handler.UpdateProfile)
+	sellerRoutes.Delete("/shipping-profiles/:id", handler.DeleteProfile)
+	sellerRoutes.Patch("/orders/:id/ship", handler.ShipOrder)
+	sellerRoutes.Patch("/orders/:id/deliver", handler.DeliverOrder)
+}
+
+func (h *ShippingHandler) CreateProfile(ctx *fiber.Ctx) error {
+	req := dto.ShippingProfileRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "create shipping profile request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	profile, err := h.svc.CreateProfile(ctx.UserContext(), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "shipping profile created successfully", profile)
+}
+
+func (h *ShippingHandler) GetProfiles(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	profiles, err := h.svc.GetSellerProfiles(ctx.UserContext(), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "shipping profiles", profiles)
+}
+
+func (h *ShippingHandler) UpdateProfile(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	req := dto.ShippingProfileRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "update shipping profile request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	profile, err := h.svc.UpdateProfile(ctx.UserContext(), uint(id), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "shipping profile updated successfully", profile)
+}
+
+func (h *ShippingHandler) DeleteProfile(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	err := h.svc.DeleteProfile(ctx.UserContext(), uint(id), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "shipping profile deleted successfully", nil)
+}
+
+func (h *ShippingHandler) ShipOrder(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	req := dto.ShipOrderRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "ship order request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	shipment, err := h.svc.MarkShipped(ctx.UserContext(), uint(id), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "order marked as shipped", shipment)
+}
+
+func (h *ShippingHandler) DeliverOrder(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	shipment, err := h.svc.MarkDelivered(ctx.UserContext(), uint(id), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "order marked as delivered", shipment)
+}
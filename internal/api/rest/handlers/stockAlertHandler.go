@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StockAlertHandler struct {
+	svc services.StockAlertService
+}
+
+func SetupStockAlertRoutes(rh *rest.RestHandler) {
+	app := rh.App
+
+	handler := &StockAlertHandler{
+		svc: rh.Services.StockAlerts,
+	}
+
+	app.Post("/products/:id/stock-alert", rh.Auth.Authorize, handler.Subscribe)
+	app.Delete("/products/:id/stock-alert", rh.Auth.Authorize, handler.Unsubscribe)
+	app.Get("/users/stock-alerts", rh.Auth.Authorize, handler.GetSubscriptions)
+}
+
+func (h *StockAlertHandler) Subscribe(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	alert, err := h.svc.Subscribe(ctx.UserContext(), uint(id), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "you will be notified when the product is back in stock", alert)
+}
+
+func (h *StockAlertHandler) Unsubscribe(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	if err := h.svc.Unsubscribe(ctx.UserContext(), uint(id), user); err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "stock alert removed", nil)
+}
+
+func (h *StockAlertHandler) GetSubscriptions(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	alerts, err := h.svc.GetSubscriptions(ctx.UserContext(), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "stock alerts", alerts)
+}
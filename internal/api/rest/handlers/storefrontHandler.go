@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StorefrontHandler struct {
+	svc services.StorefrontService
+}
+
+func SetupStorefrontRoutes(rh *rest.RestHandler) {
+	app := rh.App
+
+	handler := &StorefrontHandler{
+		svc: rh.Services.Storefronts,
+	}
+
+	app.Get("/sellers/:slug", handler.GetStorefront)
+
+	sellerRoutes := app.Group("/seller", rh.Auth.AuthorizeSeller)
+	sellerRoutes.Get("/storefront", handler.GetOwnStorefront)
+	sellerRoutes.Put("/storefront", handler.SaveStorefront)
+}
+
+// GetStorefront serves the public seller page. The slug may also be the
+// seller's id, as found on products and order items.
+func (h *StorefrontHandler) GetStorefront(ctx *fiber.Ctx) error {
+	store, products, meta, err := h.svc.GetStorefront(ctx.UserContext(), ctx.Params("slug"), rest.PaginationQuery(ctx), productFilterQuery(ctx))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.PaginatedMessage(ctx, "storefront", fiber.Map{
+		"store":    store,
+		"products": products,
+	}, meta)
+}
+
+func (h *StorefrontHandler) GetOwnStorefront(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	store, err := h.svc.GetOwnStorefront(ctx.UserContext(), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "storefront", store)
+}
+
+func (h *StorefrontHandler) SaveStorefront(ctx *fiber.Ctx) error {
+	req := dto.StorefrontRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "storefront request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	store, err := h.svc.SaveStorefront(ctx.UserContext(), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "storefront saved successfully", store)
+}
@@ -3,13 +3,14 @@ package handlers
 import (
 	"errors"
 	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/domain"
 	"go-ecommerce-app/internal/helper"
-	"go-ecommerce-app/internal/repository"
 	"go-ecommerce-app/internal/services"
 	"go-ecommerce-app/pkg/payment"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 )
 
 type TransactionHandler struct {
@@ -18,66 +19,93 @@ type TransactionHandler struct {
 	paymentClient payment.PaymentClient
 }
 
-func initializeTransactionService(db *gorm.DB, auth helper.Auth) services.TransactionService {
-	return services.TransactionService{
-		Repo: repository.NewTransactionRepository(db),
-		Auth: auth,
-	}
-}
-
 func SetupTransactionRoutes(as *rest.RestHandler) {
 	app := as.App
-	svc := initializeTransactionService(as.DB, as.Auth)
-	userSvc := services.UserService{
-		Repo:   repository.NewUserRepository(as.DB),
-		CRepo:  repository.NewCatalogRepository(as.DB),
-		Auth:   as.Auth,
-		Config: as.Config,
-	}
 
 	handler := &TransactionHandler{
-		svc:           svc,
-		paymentClient: as.Pc,
-		userSvc:       userSvc,
+		svc:           as.Services.Transactions,
+		paymentClient: as.Services.Payments,
+		userSvc:       as.Services.Users,
 	}
 
-	secRoute := app.Group("/", as.Auth.Authorize)
-	secRoute.Get("/payment", handler.MakePayment)
+	app.Get("/payment", as.Auth.Authorize, as.Idempotency.Handle, handler.MakePayment)
 
 	sellerRoute := app.Group("/seller", as.Auth.AuthorizeSeller)
 	sellerRoute.Get("/orders", handler.GetOrders)
 	sellerRoute.Get("/orders/:id", handler.GetOrderDetails)
+	sellerRoute.Get("/reports/tax", handler.GetTaxReport)
+
+	adminRoutes := app.Group("/admin", as.Auth.AuthorizeAdmin)
+	adminRoutes.Post("/orders/:id/collect", handler.CollectPayment)
 }
 
 func (h *TransactionHandler) MakePayment(ctx *fiber.Ctx) error {
 	// gram authorize user
 	user := h.svc.Auth.GetCurrentUser(ctx)
 
-	activePayment, err := h.svc.GetActivePayment(user.ID)
-	if activePayment.ID > 0 {
+	activePayment, err := h.svc.GetActivePayment(ctx.UserContext(), user.ID)
+	if errors.Is(err, domain.ErrConflict) {
+		return rest.ErrorResponse(ctx, err)
+	}
+	if activePayment != nil && activePayment.ID > 0 {
 		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
-			"error":       "You have an ongoing payment. Please complete it before initiating a new one.",
+			"message":     "You have an ongoing payment. Please complete it before initiating a new one.",
 			"payment_url": activePayment.PaymentUrl,
 		})
 	}
 
-	_, amount, err := h.userSvc.FindCart(user.ID)
+	summary, err := h.userSvc.PrepareCheckout(ctx.UserContext(), user.ID)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
 
 	orderId, err := helper.RandomNumbers(8)
 	if err != nil {
 		return rest.InternalError(ctx, errors.New("failed to generate order id"))
 	}
 
-	sessionResult, err := h.paymentClient.CreatePayment(amount, user.ID, orderId)
+	// a coupon covering the whole order leaves nothing to charge, so the
+	// order is placed without a payment page
+	if summary.Total <= 0 {
+		err = h.svc.StoreCreatedPayment(ctx.UserContext(), user.ID, payment.NothingToPay(orderId), summary, orderId)
+		if err != nil {
+			return rest.InternalError(ctx, err)
+		}
 
-	err = h.svc.StoreCreatedPayment(user.ID, sessionResult, amount, orderId)
+		orderRef, err := h.userSvc.CreateOrder(ctx.UserContext(), user)
+		if err != nil {
+			return rest.ErrorResponse(ctx, err)
+		}
 
-	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": "order created successfully",
+			"order":   orderRef,
 		})
 	}
 
+	var charges []payment.Charge
+	if summary.ShippingTotal > 0 {
+		charges = append(charges, payment.Charge{Name: "Shipping", Amount: summary.ShippingTotal})
+	}
+	if summary.TaxTotal > 0 {
+		charges = append(charges, payment.Charge{Name: "Tax", Amount: summary.TaxTotal})
+	}
+
+	var discounts []payment.Discount
+	for _, d := range summary.Discounts {
+		discounts = append(discounts, payment.Discount{Name: d.Code, Amount: d.Amount})
+	}
+
+	sessionResult, err := h.paymentClient.CreatePayment(ctx.UserContext(), summary.SubTotal, user.ID, orderId, charges, discounts)
+	if err != nil {
+		return rest.InternalError(ctx, err)
+	}
+
+	err = h.svc.StoreCreatedPayment(ctx.UserContext(), user.ID, sessionResult, summary, orderId)
+	if err != nil {
+		return rest.InternalError(ctx, err)
+	}
+
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message":     "Payment initiated successfully",
 		"result":      sessionResult,
@@ -86,9 +114,79 @@ func (h *TransactionHandler) MakePayment(ctx *fiber.Ctx) error {
 }
 
 func (h *TransactionHandler) GetOrders(ctx *fiber.Ctx) error {
-	return ctx.Status(fiber.StatusOK).JSON("Get all orders")
+	user := h.svc.Auth.GetCurrentUser(ctx)
+
+	orders, meta, err := h.svc.GetOrders(ctx.UserContext(), user, rest.PaginationQuery(ctx))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.PaginatedMessage(ctx, "seller orders", orders, meta)
+}
+
+func (h *TransactionHandler) GetTaxReport(ctx *fiber.Ctx) error {
+	from, to, msg := reportPeriod(ctx)
+	if len(msg) > 0 {
+		return rest.BadRequestError(ctx, msg)
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	report, err := h.svc.GetTaxReport(ctx.UserContext(), user, from, to)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "tax report",
+		"from":    from,
+		"to":      to,
+		"report":  report,
+	})
 }
 
 func (h *TransactionHandler) GetOrderDetails(ctx *fiber.Ctx) error {
-	return ctx.Status(fiber.StatusOK).JSON("GetOrderDetails")
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	order, err := h.svc.GetOrderDetails(ctx.UserContext(), user, uint(id))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "seller order details", order)
+}
+
+// CollectPayment records the cash of an order paid on delivery as collected.
+func (h *TransactionHandler) CollectPayment(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	if err := h.userSvc.CollectPayment(ctx.UserContext(), uint(id)); err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "payment collected", nil)
+}
+
+// reportPeriod reads a report's ?from= and ?to= dates, defaulting to the
+// current month so far. Both dates are included, so the period returned ends
+// at the start of the day after to. The message explains a malformed date.
+func reportPeriod(ctx *fiber.Ctx) (time.Time, time.Time, string) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now
+
+	var err error
+	if q := ctx.Query("from"); len(q) > 0 {
+		if from, err = time.Parse(time.DateOnly, q); err != nil {
+			return from, to, "from must be a date formatted as YYYY-MM-DD"
+		}
+	}
+	if q := ctx.Query("to"); len(q) > 0 {
+		if to, err = time.Parse(time.DateOnly, q); err != nil {
+			return from, to, "to must be a date formatted as YYYY-MM-DD"
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	return from, to, ""
 }
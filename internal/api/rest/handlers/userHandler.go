@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"errors"
 	"go-ecommerce-app/internal/api/rest"
 	"go-ecommerce-app/internal/dto"
 	"go-ecommerce-app/internal/repository"
@@ -11,6 +10,9 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// CartTokenHeader carries the opaque token identifying a guest cart.
+const CartTokenHeader = "X-Cart-Token"
+
 type UserHandler struct {
 	svc services.UserService
 }
@@ -18,26 +20,31 @@ type UserHandler struct {
 func SetupUserRoutes(rh *rest.RestHandler) {
 	app := rh.App
 
-	svc := services.UserService{
-		Repo:   repository.NewUserRepository(rh.DB),
-		CRepo:  repository.NewCatalogRepository(rh.DB),
-		Auth:   rh.Auth,
-		Config: rh.Config,
-	}
 	handler := &UserHandler{
-		svc: svc,
+		svc: rh.Services.Users,
+	}
+
+	limiter := rest.RateLimiter{
+		Store:  rh.Services.Repos.RateLimits,
+		Limit:  rh.Config.RateLimit.PerIP,
+		Window: rh.Config.RateLimit.Window,
 	}
 
 	pubRoutes := app.Group("/users")
 	// Public routes
-	pubRoutes.Post("/register", handler.Register)
-	pubRoutes.Post("/login", handler.Login)
+	pubRoutes.Post("/register", limiter.PerIP("register"), handler.Register)
+	pubRoutes.Post("/login", limiter.PerIP("login"), handler.Login)
+
+	// Guest cart routes
+	guestRoutes := app.Group("/cart")
+	guestRoutes.Post("/", handler.AddToGuestCart)
+	guestRoutes.Get("/", handler.GetGuestCart)
 
 	pvtRoutes := pubRoutes.Group("/", rh.Auth.Authorize)
 
 	// Protected routes
-	pvtRoutes.Get("/verify", handler.GetVerificationCode)
-	pvtRoutes.Post("/verify", handler.Verify)
+	pvtRoutes.Get("/verify", limiter.PerIP("verify-code"), handler.GetVerificationCode)
+	pvtRoutes.Post("/verify", limiter.PerIP("verify"), handler.Verify)
 
 	pvtRoutes.Post("/profile", handler.CreateProfile)
 	pvtRoutes.Get("/profile", handler.GetProfile)
@@ -45,10 +52,15 @@ func SetupUserRoutes(rh *rest.RestHandler) {
 
 	pvtRoutes.Post("/cart", handler.AddToCart)
 	pvtRoutes.Get("/cart", handler.GetCart)
+	pvtRoutes.Post("/cart/acknowledge", handler.AcknowledgeCart)
+	pvtRoutes.Post("/cart/coupon", handler.ApplyCoupon)
+	pvtRoutes.Delete("/cart/coupon", handler.RemoveCoupon)
+	pvtRoutes.Post("/cart/shipping", handler.SelectShipping)
 
 	pvtRoutes.Post("/order", handler.CreateOrder)
 	pvtRoutes.Get("/order", handler.GetOrders)
 	pvtRoutes.Get("/order/:id", handler.GetOrder)
+	pvtRoutes.Post("/order/:id/cancel", handler.CancelOrder)
 
 	pvtRoutes.Post("/become-seller", handler.BecomeSeller)
 }
@@ -58,16 +70,16 @@ func (h *UserHandler) Register(ctx *fiber.Ctx) error {
 	err := ctx.BodyParser(&user)
 
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
-			"message": "Please provide valid details",
-		})
+		return rest.BadRequestError(ctx, "Please provide valid details")
 	}
 
-	token, err := h.svc.SignUp(user)
+	if len(user.CartToken) < 1 {
+		user.CartToken = ctx.Get(CartTokenHeader)
+	}
+
+	token, err := h.svc.SignUp(ctx.UserContext(), user)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
-			"message": "Error on creating account",
-		})
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
@@ -81,16 +93,16 @@ func (h *UserHandler) Login(ctx *fiber.Ctx) error {
 	err := ctx.BodyParser(&loginInput)
 
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
-			"message": "Please provide valid details",
-		})
+		return rest.BadRequestError(ctx, "Please provide valid details")
+	}
+
+	if len(loginInput.CartToken) < 1 {
+		loginInput.CartToken = ctx.Get(CartTokenHeader)
 	}
 
-	token, err := h.svc.Login(loginInput.Email, loginInput.Password)
+	token, err := h.svc.Login(ctx.UserContext(), loginInput)
 	if err != nil {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
-			"message": "Invalid credentials",
-		})
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
@@ -103,11 +115,9 @@ func (h *UserHandler) GetVerificationCode(ctx *fiber.Ctx) error {
 	user := h.svc.Auth.GetCurrentUser(ctx)
 
 	// Create verification code and update to user profile in DB
-	err := h.svc.GetVerificationCode(user)
+	err := h.svc.GetVerificationCode(ctx.UserContext(), user)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
-			"message": "Unable to generate verification code",
-		})
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
@@ -121,17 +131,12 @@ func (h *UserHandler) Verify(ctx *fiber.Ctx) error {
 	var req dto.VerificationCodeInput
 
 	if err := ctx.BodyParser(&req); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
-			"message": "Please provide valid details",
-		})
+		return rest.BadRequestError(ctx, "Please provide valid details")
 	}
 
-	err := h.svc.VerifyCode(user.ID, req.Code)
-
+	err := h.svc.VerifyCode(ctx.UserContext(), user.ID, req.Code)
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
-			"message": err.Error(),
-		})
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
@@ -144,12 +149,13 @@ func (h *UserHandler) CreateProfile(ctx *fiber.Ctx) error {
 	req := dto.ProfileInput{}
 	err := ctx.BodyParser(&req)
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
-			"message": "request parameters are not valid",
-		})
+		return rest.BadRequestError(ctx, "request parameters are not valid")
 	}
 
-	err = h.svc.CreateProfile(user.ID, req)
+	err = h.svc.CreateProfile(ctx.UserContext(), user.ID, req)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
 		"message": "CreateProfile",
@@ -158,9 +164,9 @@ func (h *UserHandler) CreateProfile(ctx *fiber.Ctx) error {
 func (h *UserHandler) GetProfile(ctx *fiber.Ctx) error {
 	user := h.svc.Auth.GetCurrentUser(ctx)
 
-	profile, err := h.svc.GetProfile(user.ID)
+	profile, err := h.svc.GetProfile(ctx.UserContext(), user.ID)
 	if err != nil {
-		return rest.InternalError(ctx, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
@@ -173,14 +179,12 @@ func (h *UserHandler) UpdateProfile(ctx *fiber.Ctx) error {
 	req := dto.ProfileInput{}
 	err := ctx.BodyParser(&req)
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
-			"message": "request parameters are not valid",
-		})
+		return rest.BadRequestError(ctx, "request parameters are not valid")
 	}
 
-	err = h.svc.UpdateProfile(user.ID, req)
+	err = h.svc.UpdateProfile(ctx.UserContext(), user.ID, req)
 	if err != nil {
-		return rest.InternalError(ctx, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
@@ -191,17 +195,15 @@ func (h *UserHandler) UpdateProfile(ctx *fiber.Ctx) error {
 func (h *UserHandler) AddToCart(ctx *fiber.Ctx) error {
 	req := dto.CreateCartRequest{}
 	if err := ctx.BodyParser(&req); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
-			"message": "request parameters are not valid",
-		})
+		return rest.BadRequestError(ctx, "request parameters are not valid")
 	}
 
 	user := h.svc.Auth.GetCurrentUser(ctx)
 
 	// Call service to add to cart
-	cartItems, err := h.svc.CreateCart(req, user)
+	cartItems, err := h.svc.CreateCart(ctx.UserContext(), req, user)
 	if err != nil {
-		return rest.InternalError(ctx, err)
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return rest.SuccessMessage(ctx, "Product added to cart successfully", cartItems)
@@ -209,23 +211,109 @@ func (h *UserHandler) AddToCart(ctx *fiber.Ctx) error {
 func (h *UserHandler) GetCart(ctx *fiber.Ctx) error {
 
 	user := h.svc.Auth.GetCurrentUser(ctx)
-	cart, _, err := h.svc.FindCart(user.ID)
+	summary, err := h.svc.CartSummary(ctx.UserContext(), user.ID)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message":        "GetCart",
+		"cart":           summary.Items,
+		"sub_total":      summary.SubTotal,
+		"discounts":      summary.Discounts,
+		"discount_total": summary.DiscountTotal,
+		"total":          summary.Total,
+		"warnings":       summary.Warnings,
+	})
+}
+
+func (h *UserHandler) AcknowledgeCart(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	summary, err := h.svc.AcknowledgeCart(ctx.UserContext(), user.ID)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "cart changes acknowledged", summary)
+}
+
+func (h *UserHandler) ApplyCoupon(ctx *fiber.Ctx) error {
+	req := dto.ApplyCouponRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "request parameters are not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	summary, err := h.svc.ApplyCoupon(ctx.UserContext(), user.ID, req.Code)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "coupon applied successfully", summary)
+}
+
+func (h *UserHandler) RemoveCoupon(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	summary, err := h.svc.RemoveCoupon(ctx.UserContext(), user.ID)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "coupon removed successfully", summary)
+}
+
+func (h *UserHandler) SelectShipping(ctx *fiber.Ctx) error {
+	req := dto.SelectShippingRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "request parameters are not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	summary, err := h.svc.SelectShipping(ctx.UserContext(), user.ID, req)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "shipping method selected", summary)
+}
+
+func (h *UserHandler) AddToGuestCart(ctx *fiber.Ctx) error {
+	req := dto.CreateCartRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "request parameters are not valid")
+	}
 
+	cartItems, token, err := h.svc.CreateGuestCart(ctx.UserContext(), req, ctx.Get(CartTokenHeader))
 	if err != nil {
-		return rest.InternalError(ctx, errors.New("cart does not exist"))
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	ctx.Set(CartTokenHeader, token)
+	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message":    "Product added to cart successfully",
+		"cart_token": token,
+		"data":       cartItems,
+	})
+}
+
+func (h *UserHandler) GetGuestCart(ctx *fiber.Ctx) error {
+	cart, amount, err := h.svc.FindGuestCart(ctx.UserContext(), ctx.Get(CartTokenHeader))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
 		"message": "GetCart",
 		"cart":    cart,
+		"amount":  amount,
 	})
 }
 
 func (h *UserHandler) CreateOrder(ctx *fiber.Ctx) error {
 	user := h.svc.Auth.GetCurrentUser(ctx)
-	orderRef, err := h.svc.CreateOrder(user)
+	orderRef, err := h.svc.CreateOrder(ctx.UserContext(), user)
 	if err != nil {
-		return rest.InternalError(ctx, errors.New("failed to create order"))
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
@@ -233,25 +321,42 @@ func (h *UserHandler) CreateOrder(ctx *fiber.Ctx) error {
 		"order":   orderRef,
 	})
 }
+
+func (h *UserHandler) CancelOrder(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	if err := h.svc.CancelOrder(ctx.UserContext(), uint(id), user); err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "order cancelled", nil)
+}
+
 func (h *UserHandler) GetOrders(ctx *fiber.Ctx) error {
 	user := h.svc.Auth.GetCurrentUser(ctx)
-	orders, err := h.svc.GetOrders(user)
+	filter := repository.OrderFilter{
+		Status: ctx.Query("status"),
+	}
+
+	orders, meta, err := h.svc.GetOrders(ctx.UserContext(), user, rest.PaginationQuery(ctx), filter)
 	if err != nil {
-		return rest.InternalError(ctx, errors.New("failed to fetch orders"))
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
 		"message": "get orders",
 		"orders":  orders,
+		"meta":    meta,
 	})
 }
 func (h *UserHandler) GetOrder(ctx *fiber.Ctx) error {
 	orderId, _ := strconv.Atoi(ctx.Params("id"))
 	user := h.svc.Auth.GetCurrentUser(ctx)
 
-	order, err := h.svc.GetOrderById(uint(orderId), user.ID)
+	order, err := h.svc.GetOrderById(ctx.UserContext(), uint(orderId), user.ID)
 	if err != nil {
-		return rest.InternalError(ctx, errors.New("failed to fetch order"))
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
@@ -266,16 +371,12 @@ func (h *UserHandler) BecomeSeller(ctx *fiber.Ctx) error {
 	req := dto.SellerInput{}
 	err := ctx.BodyParser(&req)
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
-			"message": "request parameters are not valid",
-		})
+		return rest.BadRequestError(ctx, "request parameters are not valid")
 	}
 
-	token, err := h.svc.BecomeSeller(user.ID, req)
+	token, err := h.svc.BecomeSeller(ctx.UserContext(), user.ID, req)
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
-			"message": "failed to become a seller",
-		})
+		return rest.ErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
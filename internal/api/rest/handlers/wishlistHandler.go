@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"go-ecommerce-app/internal/api/rest"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WishlistHandler struct {
+	svc   services.WishlistService
+	users services.UserService
+}
+
+func SetupWishlistRoutes(rh *rest.RestHandler) {
+	app := rh.App
+
+	handler := &WishlistHandler{
+		svc:   rh.Services.Wishlists,
+		users: rh.Services.Users,
+	}
+
+	// registered ahead of the authorized group so share links work signed out
+	app.Get("/wishlists/shared/:token", handler.GetSharedWishlist)
+
+	app.Post("/users/cart/:id/save-for-later", rh.Auth.Authorize, handler.SaveForLater)
+
+	pvtRoutes := app.Group("/wishlists", rh.Auth.Authorize)
+	pvtRoutes.Post("/", handler.CreateWishlist)
+	pvtRoutes.Get("/", handler.GetWishlists)
+	pvtRoutes.Get("/:id", handler.GetWishlist)
+	pvtRoutes.Put("/:id", handler.UpdateWishlist)
+	pvtRoutes.Delete("/:id", handler.DeleteWishlist)
+	pvtRoutes.Post("/:id/items", handler.AddItem)
+	pvtRoutes.Delete("/:id/items/:productId", handler.RemoveItem)
+	pvtRoutes.Post("/:id/items/:productId/move-to-cart", handler.MoveToCart)
+}
+
+func (h *WishlistHandler) CreateWishlist(ctx *fiber.Ctx) error {
+	req := dto.WishlistRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "wishlist request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	list, err := h.svc.CreateWishlist(ctx.UserContext(), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "wishlist created successfully", list)
+}
+
+func (h *WishlistHandler) GetWishlists(ctx *fiber.Ctx) error {
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	lists, err := h.svc.GetWishlists(ctx.UserContext(), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "wishlists", lists)
+}
+
+func (h *WishlistHandler) GetWishlist(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	list, err := h.svc.GetWishlist(ctx.UserContext(), uint(id), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "wishlist", list)
+}
+
+func (h *WishlistHandler) GetSharedWishlist(ctx *fiber.Ctx) error {
+	list, err := h.svc.GetSharedWishlist(ctx.UserContext(), ctx.Params("token"))
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "wishlist", list)
+}
+
+func (h *WishlistHandler) UpdateWishlist(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	req := dto.WishlistRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "wishlist request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	list, err := h.svc.UpdateWishlist(ctx.UserContext(), uint(id), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "wishlist updated successfully", list)
+}
+
+func (h *WishlistHandler) DeleteWishlist(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	if err := h.svc.DeleteWishlist(ctx.UserContext(), uint(id), user); err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "wishlist deleted successfully", nil)
+}
+
+func (h *WishlistHandler) AddItem(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+
+	req := dto.WishlistItemRequest{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return rest.BadRequestError(ctx, "wishlist item request body is not valid")
+	}
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	list, err := h.svc.AddItem(ctx.UserContext(), uint(id), req, user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "product added to wishlist", list)
+}
+
+func (h *WishlistHandler) RemoveItem(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+	productId, _ := strconv.Atoi(ctx.Params("productId"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	list, err := h.svc.RemoveItem(ctx.UserContext(), uint(id), uint(productId), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "product removed from wishlist", list)
+}
+
+// MoveToCart takes the product off the wishlist and adds ?qty=, one by
+// default, to the cart.
+func (h *WishlistHandler) MoveToCart(ctx *fiber.Ctx) error {
+	id, _ := strconv.Atoi(ctx.Params("id"))
+	productId, _ := strconv.Atoi(ctx.Params("productId"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	cartItems, err := h.users.MoveToCart(ctx.UserContext(), uint(id), uint(productId), ctx.QueryInt("qty", 1), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "product moved to cart", cartItems)
+}
+
+// SaveForLater moves a cart line to the wishlist given by ?wishlist_id=, or
+// to "Saved for later" without one.
+func (h *WishlistHandler) SaveForLater(ctx *fiber.Ctx) error {
+	productId, _ := strconv.Atoi(ctx.Params("id"))
+
+	user := h.svc.Auth.GetCurrentUser(ctx)
+	list, err := h.users.SaveForLater(ctx.UserContext(), uint(productId), uint(ctx.QueryInt("wishlist_id")), user)
+	if err != nil {
+		return rest.ErrorResponse(ctx, err)
+	}
+
+	return rest.SuccessMessage(ctx, "product saved for later", list)
+}
@@ -2,17 +2,23 @@ package rest
 
 import (
 	"go-ecommerce-app/configs"
+	"go-ecommerce-app/internal/container"
 	"go-ecommerce-app/internal/helper"
-	"go-ecommerce-app/pkg/payment"
+	"sync/atomic"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 )
 
 type RestHandler struct {
 	App    *fiber.App
-	DB     *gorm.DB
 	Auth   helper.Auth
 	Config configs.AppConfig
-	Pc     payment.PaymentClient
+
+	// Services holds the wired services the handlers delegate to.
+	Services *container.Container
+
+	Idempotency Idempotency
+
+	// Ready is set once the server accepts traffic and cleared on shutdown.
+	Ready *atomic.Bool
 }
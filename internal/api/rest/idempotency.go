@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/repository"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	cartTokenHeader          = "X-Cart-Token"
+)
+
+// Idempotency replays the stored response when a request is retried with
+// the same Idempotency-Key header. Keys are scoped to the caller's
+// credentials, so two users can never see each other's responses.
+type Idempotency struct {
+	Repo repository.IdempotencyRepository
+	TTL  time.Duration
+}
+
+// Mutations honours the header on POST, PUT, PATCH and DELETE requests and
+// lets every other request through untouched.
+func (i Idempotency) Mutations(ctx *fiber.Ctx) error {
+	switch ctx.Method() {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return i.Handle(ctx)
+	}
+
+	return ctx.Next()
+}
+
+// Handle honours the header whatever the method, for routes such as
+// GET /payment that have side effects.
+func (i Idempotency) Handle(ctx *fiber.Ctx) error {
+	key := ctx.Get(IdempotencyKeyHeader)
+	if len(key) < 1 || ctx.Locals(IdempotencyKeyHeader) != nil {
+		return ctx.Next()
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return BadRequestError(ctx, "Idempotency-Key must be at most 255 characters")
+	}
+	ctx.Locals(IdempotencyKeyHeader, key)
+
+	record := &domain.IdempotencyKey{
+		Scope:       digest(ctx.Get(fiber.HeaderAuthorization), ctx.Get(cartTokenHeader)),
+		Key:         key,
+		Fingerprint: digest(ctx.Method(), ctx.OriginalURL(), string(ctx.Body())),
+		ExpiresAt:   time.Now().Add(i.TTL),
+	}
+
+	reserved, existing, err := i.Repo.Reserve(ctx.UserContext(), record)
+	if err != nil {
+		return InternalError(ctx, err)
+	}
+
+	if !reserved {
+		if existing.Fingerprint != record.Fingerprint {
+			return ErrorResponse(ctx, domain.ConflictError("Idempotency-Key was already used with a different request"))
+		}
+		if !existing.Completed {
+			return ErrorResponse(ctx, domain.ConflictError("a request with this Idempotency-Key is still being processed"))
+		}
+
+		ctx.Set(IdempotentReplayedHeader, "true")
+		ctx.Set(fiber.HeaderContentType, existing.ContentType)
+		return ctx.Status(existing.StatusCode).Send(existing.Body)
+	}
+
+	if err = ctx.Next(); err != nil {
+		if err = ctx.App().ErrorHandler(ctx, err); err != nil {
+			i.release(ctx.UserContext(), record)
+			return err
+		}
+	}
+
+	// server errors are not cached so the client can retry them
+	status := ctx.Response().StatusCode()
+	if status >= fiber.StatusInternalServerError {
+		i.release(ctx.UserContext(), record)
+		return nil
+	}
+
+	record.Completed = true
+	record.StatusCode = status
+	record.ContentType = string(ctx.Response().Header.ContentType())
+	record.Body = append([]byte(nil), ctx.Response().Body()...)
+	if err = i.Repo.Complete(ctx.UserContext(), record); err != nil {
+		slog.ErrorContext(ctx.UserContext(), "storing idempotent response failed", "error", err)
+	}
+
+	return nil
+}
+
+func (i Idempotency) release(ctx context.Context, record *domain.IdempotencyKey) {
+	if err := i.Repo.Release(ctx, record.ID); err != nil {
+		slog.ErrorContext(ctx, "releasing idempotency key failed", "error", err)
+	}
+}
+
+func digest(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
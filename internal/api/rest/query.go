@@ -0,0 +1,23 @@
+package rest
+
+import (
+	"go-ecommerce-app/internal/repository"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PaginationQuery reads the shared page, cursor, limit and sort query
+// parameters. A leading "-" on sort requests descending order.
+func PaginationQuery(ctx *fiber.Ctx) repository.Pagination {
+	sort := ctx.Query("sort")
+	desc := strings.HasPrefix(sort, "-")
+
+	return repository.Pagination{
+		Page:   ctx.QueryInt("page", 1),
+		Limit:  ctx.QueryInt("limit", repository.DefaultPageLimit),
+		Cursor: ctx.Query("cursor"),
+		Sort:   strings.TrimPrefix(sort, "-"),
+		Desc:   desc,
+	}
+}
@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimiter allows Limit requests per client IP in every Window.
+type RateLimiter struct {
+	Store  repository.RateLimitStore
+	Limit  int
+	Window time.Duration
+}
+
+// PerIP returns a middleware counting requests per IP under name, so each
+// protected endpoint has its own budget.
+func (l RateLimiter) PerIP(name string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if l.Store == nil || l.Limit < 1 {
+			return ctx.Next()
+		}
+
+		hits, err := l.Store.Hit(ctx.UserContext(), name+":ip:"+ctx.IP(), l.Window)
+		if err != nil {
+			return InternalError(ctx, err)
+		}
+		if hits.Hits > l.Limit {
+			return ErrorResponse(ctx, domain.RateLimitedError("too many requests, please try again later", time.Until(hits.WindowEnd)))
+		}
+
+		return ctx.Next()
+	}
+}
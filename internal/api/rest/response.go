@@ -1,23 +1,82 @@
 package rest
 
 import (
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/repository"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+const (
+	CodeBadRequest = "bad_request"
+	CodeInternal   = "internal_error"
+)
+
+var errorStatuses = []struct {
+	kind   error
+	status int
+}{
+	{domain.ErrNotFound, http.StatusNotFound},
+	{domain.ErrForbidden, http.StatusForbidden},
+	{domain.ErrConflict, http.StatusConflict},
+	{domain.ErrValidation, http.StatusUnprocessableEntity},
+	{domain.ErrUnauthorized, http.StatusUnauthorized},
+	{domain.ErrRateLimited, http.StatusTooManyRequests},
+}
+
+type ErrorBody struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestId string      `json:"request_id,omitempty"`
+}
+
+// ErrorHandler is installed as the fiber error handler so errors returned
+// from handlers and middleware are rendered with the same envelope.
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return writeError(ctx, fe.Code, codeForStatus(fe.Code), fe.Message)
+	}
+
+	return ErrorResponse(ctx, err)
+}
+
+// ErrorResponse maps domain errors to their HTTP status and code. Unknown
+// errors are logged and reported as internal errors without their details.
+func ErrorResponse(ctx *fiber.Ctx, err error) error {
+	var appErr *domain.AppError
+	if errors.As(err, &appErr) {
+		if appErr.RetryAfter > 0 {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+		}
+
+		for _, e := range errorStatuses {
+			if errors.Is(appErr, e.kind) {
+				return writeError(ctx, e.status, e.kind.Error(), appErr.Message, appErr.Details)
+			}
+		}
+	}
+
+	return InternalError(ctx, err)
+}
+
 func ErrorMessage(ctx *fiber.Ctx, status int, err error) error {
-	return ctx.Status(status).JSON(err.Error())
+	return writeError(ctx, status, codeForStatus(status), err.Error())
 }
 
 func InternalError(ctx *fiber.Ctx, err error) error {
-	return ctx.Status(fiber.StatusInternalServerError).JSON(err.Error())
+	slog.ErrorContext(ctx.UserContext(), "internal error", "error", err)
+	return writeError(ctx, fiber.StatusInternalServerError, CodeInternal, "internal server error")
 }
 
 func BadRequestError(ctx *fiber.Ctx, msg string) error {
-	return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-		"message": msg,
-	})
+	return writeError(ctx, http.StatusBadRequest, CodeBadRequest, msg)
 }
 
 func SuccessMessage(ctx *fiber.Ctx, message string, data interface{}) error {
@@ -26,3 +85,44 @@ func SuccessMessage(ctx *fiber.Ctx, message string, data interface{}) error {
 		"data":    data,
 	})
 }
+
+func PaginatedMessage(ctx *fiber.Ctx, message string, data interface{}, meta repository.PageInfo) error {
+	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": message,
+		"data":    data,
+		"meta":    meta,
+	})
+}
+
+func RequestId(ctx *fiber.Ctx) string {
+	id, _ := ctx.Locals("requestid").(string)
+	return id
+}
+
+func writeError(ctx *fiber.Ctx, status int, code string, msg string, details ...interface{}) error {
+	body := ErrorBody{
+		Code:      code,
+		Message:   msg,
+		RequestId: RequestId(ctx),
+	}
+	if len(details) > 0 {
+		body.Details = details[0]
+	}
+
+	return ctx.Status(status).JSON(&fiber.Map{
+		"error": body,
+	})
+}
+
+func codeForStatus(status int) string {
+	for _, e := range errorStatuses {
+		if e.status == status {
+			return e.kind.Error()
+		}
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+
+	return CodeBadRequest
+}
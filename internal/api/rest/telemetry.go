@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"go-ecommerce-app/internal/telemetry"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("go-ecommerce-app/http")
+
+// Telemetry starts a span for every request, continuing the caller's trace
+// when one is propagated, and hands services a context carrying the span
+// and the request id. When the request is done it records the request
+// metrics and writes an access log line.
+// It must run after the request id middleware.
+func Telemetry(ctx *fiber.Ctx) error {
+	start := time.Now()
+
+	headers := propagation.HeaderCarrier(http.Header{})
+	ctx.Request().Header.VisitAll(func(key, value []byte) {
+		headers.Set(string(key), string(value))
+	})
+
+	c := otel.GetTextMapPropagator().Extract(ctx.UserContext(), headers)
+	c = telemetry.WithRequestId(c, RequestId(ctx))
+	c, span := tracer.Start(c, ctx.Method(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(ctx.Method()),
+			semconv.URLPath(ctx.Path()),
+		),
+	)
+	defer span.End()
+	ctx.SetUserContext(c)
+
+	if err := ctx.Next(); err != nil {
+		if err = ctx.App().ErrorHandler(ctx, err); err != nil {
+			ctx.Status(fiber.StatusInternalServerError)
+		}
+	}
+
+	status := ctx.Response().StatusCode()
+	route := ctx.Route().Path
+	span.SetName(ctx.Method() + " " + route)
+	span.SetAttributes(
+		semconv.HTTPRoute(route),
+		semconv.HTTPResponseStatusCode(status),
+		attribute.String("request.id", RequestId(ctx)),
+	)
+	if status >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+
+	elapsed := time.Since(start)
+	telemetry.ObserveRequest(ctx.Method(), route, strconv.Itoa(status), elapsed)
+
+	level := slog.LevelInfo
+	switch {
+	case status >= fiber.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= fiber.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+
+	slog.LogAttrs(c, level, "request",
+		slog.String("method", ctx.Method()),
+		slog.String("path", ctx.Path()),
+		slog.String("route", route),
+		slog.Int("status", status),
+		slog.Float64("latency_ms", float64(elapsed.Microseconds())/1000),
+		slog.String("ip", ctx.IP()),
+		slog.Int("bytes", len(ctx.Response().Body())),
+	)
+
+	return nil
+}
@@ -1,64 +1,273 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"go-ecommerce-app/configs"
 	"go-ecommerce-app/internal/api/rest"
 	"go-ecommerce-app/internal/api/rest/handlers"
+	"go-ecommerce-app/internal/container"
 	"go-ecommerce-app/internal/domain"
-	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"go-ecommerce-app/internal/services"
+	"go-ecommerce-app/internal/telemetry"
+	"go-ecommerce-app/pkg/notification"
 	"go-ecommerce-app/pkg/payment"
-	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func StartServer(config configs.AppConfig) {
-	app := fiber.New()
+func StartServer(config configs.AppConfig) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	db, err := gorm.Open(postgres.Open(config.Dsn), &gorm.Config{})
+	shutdownTracing, err := telemetry.SetupTracing(ctx, config.Tracing)
 	if err != nil {
-		panic("failed to connect database")
+		return err
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("flushing traces failed", "error", err)
+		}
+	}()
+
+	db, err := gorm.Open(postgres.Open(config.Database.Dsn), &gorm.Config{
+		Logger: telemetry.NewGormLogger(slog.Default()),
+	})
+	if err != nil {
+		return fmt.Errorf("connecting to database failed: %w", err)
+	}
+
+	if err = db.Use(telemetry.GormTelemetry{}); err != nil {
+		return fmt.Errorf("installing query telemetry failed: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("accessing database connection failed: %w", err)
+	}
+	defer sqlDB.Close()
+
+	sqlDB.SetMaxOpenConns(config.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.Database.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.Database.ConnMaxIdleTime)
+
+	if err = telemetry.RegisterDBStats(sqlDB, "postgres"); err != nil {
+		return fmt.Errorf("registering database metrics failed: %w", err)
 	}
 
 	// run migration
-	err = db.
-		AutoMigrate(
-			&domain.User{},
-			&domain.Address{},
-			&domain.BankAccount{},
-			&domain.Category{},
-			&domain.Product{},
-			&domain.Cart{},
-			&domain.Order{},
-			&domain.OrderItem{},
-			&domain.Payment{},
-		)
+	if err = db.AutoMigrate(domain.Models()...); err != nil {
+		return fmt.Errorf("auto migration failed: %w", err)
+	}
+
+	paymentClient, err := payment.NewProvider(config.Payment.Provider, config.Payment.StripeSecret, config.Payment.SuccessUrl, config.Payment.CancelUrl)
 	if err != nil {
-		log.Fatalf("Auto migration failed: %v", err)
+		return fmt.Errorf("payment provider setup failed: %w", err)
+	}
+
+	deps := container.New(config, container.NewRepositories(db, config), paymentClient, notification.NewNotificationClient(config))
+
+	if len(config.Tax.RatesFile) > 0 {
+		if err = deps.Tax.LoadRates(ctx, config.Tax.RatesFile); err != nil {
+			return fmt.Errorf("loading tax rates failed: %w", err)
+		}
 	}
 
+	rh := NewApp(deps)
+	app := rh.App
+
+	var workers sync.WaitGroup
+
+	every(ctx, &workers, time.Hour, func(ctx context.Context) { expireGuestCarts(ctx, deps.Users) })
+
+	every(ctx, &workers, time.Hour, func(ctx context.Context) { expireIdempotencyKeys(ctx, deps.Repos.Idempotency) })
+
+	every(ctx, &workers, 10*time.Minute, func(ctx context.Context) { expireRateLimits(ctx, deps.Repos.RateLimits) })
+
+	every(ctx, &workers, config.Payment.SyncInterval, func(ctx context.Context) { reconcilePayments(ctx, deps.Transactions, config) })
+
+	every(ctx, &workers, config.Payout.Interval, func(ctx context.Context) { runPayouts(ctx, deps.Ledger) })
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(config.Server.Port)
+	}()
+	rh.Ready.Store(true)
+	slog.InfoContext(ctx, "server listening", "port", config.Server.Port)
+
+	select {
+	case err = <-listenErr:
+		stop()
+		workers.Wait()
+		deps.Alerts.Wait()
+		return fmt.Errorf("server stopped: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.InfoContext(ctx, "shutting down", "timeout", config.Server.ShutdownTimeout.String())
+	rh.Ready.Store(false)
+
+	deadline := time.Now().Add(config.Server.ShutdownTimeout)
+	if err = app.ShutdownWithTimeout(config.Server.ShutdownTimeout); err != nil {
+		slog.ErrorContext(ctx, "draining requests failed", "error", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		deps.Alerts.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.InfoContext(ctx, "shutdown complete")
+	case <-time.After(time.Until(deadline)):
+		slog.WarnContext(ctx, "shutdown timed out waiting for background jobs")
+	}
+
+	return nil
+}
+
+// NewApp builds the HTTP application on top of the wired services, with the
+// middleware and every route installed. The server is not started.
+func NewApp(deps *container.Container) *rest.RestHandler {
+	app := fiber.New(fiber.Config{
+		ErrorHandler:          rest.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+
 	c := cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
+		AllowOrigins:  "*",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, X-Request-ID, X-Cart-Token, Idempotency-Key",
+		AllowMethods:  "GET, POST, PUT, DELETE, OPTIONS",
+		ExposeHeaders: "X-Request-ID, X-Cart-Token, Idempotent-Replayed, Retry-After",
 	})
 	app.Use(c)
+	app.Use(requestid.New())
+	app.Use(rest.Telemetry)
 
-	auth := helper.SetupAuth(config.AppSecret)
-
-	paymentClient := payment.NewPaymentClient(config.StripeSecret, config.SuccessUrl, config.CancelUrl)
+	idempotency := rest.Idempotency{
+		Repo: deps.Repos.Idempotency,
+		TTL:  deps.Config.Idempotency.KeyTTL,
+	}
+	app.Use(idempotency.Mutations)
 
-	rh := &rest.RestHandler{App: app, DB: db, Auth: auth, Config: config, Pc: paymentClient}
+	rh := &rest.RestHandler{
+		App:         app,
+		Auth:        deps.Auth,
+		Config:      deps.Config,
+		Services:    deps,
+		Idempotency: idempotency,
+		Ready:       &atomic.Bool{},
+	}
 	setupRoutes(rh)
 
-	app.Listen(config.ServerPort)
+	return rh
+}
+
+// every runs job on each tick of interval until ctx is cancelled. A job that
+// is running when ctx is cancelled is allowed to finish, so it is handed a
+// context that outlives the cancellation.
+func every(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, job func(ctx context.Context)) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				job(context.WithoutCancel(ctx))
+			}
+		}
+	}()
+}
+
+func expireGuestCarts(ctx context.Context, svc services.UserService) {
+	removed, err := svc.ExpireGuestCarts(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "guest cart expiry failed", "error", err)
+		return
+	}
+	if removed > 0 {
+		slog.InfoContext(ctx, "removed expired guest cart items", "count", removed)
+	}
+}
+
+func expireIdempotencyKeys(ctx context.Context, repo repository.IdempotencyRepository) {
+	removed, err := repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "idempotency key expiry failed", "error", err)
+		return
+	}
+	if removed > 0 {
+		slog.InfoContext(ctx, "removed expired idempotency keys", "count", removed)
+	}
+}
+
+func expireRateLimits(ctx context.Context, store repository.RateLimitStore) {
+	if _, err := store.DeleteExpired(ctx, time.Now()); err != nil {
+		slog.ErrorContext(ctx, "rate limit expiry failed", "error", err)
+	}
+}
+
+func reconcilePayments(ctx context.Context, svc services.TransactionService, config configs.AppConfig) {
+	checked, err := svc.ReconcilePayments(ctx, config.Payment.SyncAfter)
+	if err != nil {
+		slog.ErrorContext(ctx, "payment reconciliation failed", "error", err)
+		return
+	}
+	if checked > 0 {
+		slog.InfoContext(ctx, "reconciled open payments", "count", checked)
+	}
+}
+
+func runPayouts(ctx context.Context, svc services.LedgerService) {
+	batch, err := svc.RunPayouts(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "seller payout run failed", "error", err)
+		return
+	}
+	if batch != nil {
+		slog.InfoContext(ctx, "created payout batch", "batch_id", batch.ID, "payouts", batch.Count, "total", batch.Total)
+	}
 }
 
 func setupRoutes(rh *rest.RestHandler) {
+	handlers.SetupHealthRoutes(rh)
+	handlers.SetupMetricsRoutes(rh)
+	// ahead of every /seller group, whose middleware fiber matches by plain
+	// prefix and would otherwise guard the public /sellers pages too
+	handlers.SetupStorefrontRoutes(rh)
 	handlers.SetupUserRoutes(rh)
 	handlers.SetupTransactionRoutes(rh)
 	handlers.SetupCatalogRoutes(rh)
+	handlers.SetupReviewRoutes(rh)
+	handlers.SetupWishlistRoutes(rh)
+	handlers.SetupStockAlertRoutes(rh)
+	handlers.SetupInventoryRoutes(rh)
+	handlers.SetupAnalyticsRoutes(rh)
+	handlers.SetupPromotionRoutes(rh)
+	handlers.SetupShippingRoutes(rh)
+	handlers.SetupLedgerRoutes(rh)
 }
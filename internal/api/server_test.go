@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"go-ecommerce-app/configs"
+	"go-ecommerce-app/internal/container"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/repository"
+	"go-ecommerce-app/pkg/notification"
+	"go-ecommerce-app/pkg/payment"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeUsers keeps the accounts the HTTP tests create in memory.
+type fakeUsers struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users map[uint]domain.User
+}
+
+func (r *fakeUsers) CreateUser(ctx context.Context, u domain.User) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u.ID = uint(len(r.users) + 1)
+	u.UserType = domain.BUYER
+	r.users[u.ID] = u
+	return u, nil
+}
+
+func (r *fakeUsers) FindUser(ctx context.Context, email string) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return domain.User{}, errors.New("user does not exist")
+}
+
+func (r *fakeUsers) FindUserByID(ctx context.Context, id uint) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return domain.User{}, errors.New("user does not exist")
+	}
+	return u, nil
+}
+
+func (r *fakeUsers) UpdateUser(ctx context.Context, id uint, u domain.User) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user := r.users[id]
+	if len(u.Code) > 0 {
+		user.Code, user.Expiry = u.Code, u.Expiry
+	}
+	if u.Verified {
+		user.Verified = true
+	}
+	r.users[id] = user
+	return user, nil
+}
+
+type fakeHealth struct{ err error }
+
+func (h fakeHealth) Ping(ctx context.Context) error { return h.err }
+
+func (h fakeHealth) MissingTables(ctx context.Context, models []interface{}) ([]string, error) {
+	return nil, nil
+}
+
+type testApp struct {
+	app      *fiber.App
+	notifier *notification.FakeClient
+}
+
+func newTestApp(t *testing.T) testApp {
+	t.Helper()
+
+	config := configs.Defaults()
+	config.Auth.AppSecret = "test-secret"
+
+	notifier := notification.NewFakeClient()
+	deps := container.New(config, container.Repositories{
+		User:       &fakeUsers{users: map[uint]domain.User{}},
+		Health:     fakeHealth{},
+		RateLimits: repository.NewMemoryRateLimitStore(),
+	}, payment.NewFakeClient("http://localhost/success"), notifier)
+
+	rh := NewApp(deps)
+	rh.Ready.Store(true)
+
+	return testApp{app: rh.App, notifier: notifier}
+}
+
+// do sends a request and decodes the JSON response body into a map.
+func (a testApp) do(t *testing.T, method string, path string, token string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := a.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	out := map[string]interface{}{}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+
+	return resp.StatusCode, out
+}
+
+func TestSignUpVerifyAndReadProfile(t *testing.T) {
+	a := newTestApp(t)
+	account := map[string]string{"email": "buyer@example.com", "password": "secret123", "phone": "+15550100"}
+
+	status, body := a.do(t, http.MethodPost, "/users/register", "", account)
+	if status != http.StatusOK {
+		t.Fatalf("register status = %d, body = %v", status, body)
+	}
+
+	status, _ = a.do(t, http.MethodPost, "/users/register", "", account)
+	if status != http.StatusConflict {
+		t.Fatalf("second register status = %d; want %d", status, http.StatusConflict)
+	}
+
+	status, body = a.do(t, http.MethodPost, "/users/login", "", account)
+	if status != http.StatusOK {
+		t.Fatalf("login status = %d, body = %v", status, body)
+	}
+	token, _ := body["token"].(string)
+
+	status, body = a.do(t, http.MethodGet, "/users/verify", token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("verification code status = %d, body = %v", status, body)
+	}
+	messages := a.notifier.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("sent %d texts; want 1", len(messages))
+	}
+	code := messages[0].Body[strings.LastIndex(messages[0].Body, " ")+1:]
+
+	status, body = a.do(t, http.MethodPost, "/users/verify", token, map[string]string{"code": code})
+	if status != http.StatusOK {
+		t.Fatalf("verify status = %d, body = %v", status, body)
+	}
+
+	status, body = a.do(t, http.MethodGet, "/users/profile", token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("profile status = %d, body = %v", status, body)
+	}
+	profile, _ := body["profile"].(map[string]interface{})
+	if profile["email"] != "buyer@example.com" || profile["verified"] != true {
+		t.Fatalf("profile = %v; want the verified account", profile)
+	}
+}
+
+func TestRoutesRequireAuthorization(t *testing.T) {
+	a := newTestApp(t)
+
+	status, _ := a.do(t, http.MethodGet, "/users/profile", "", nil)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("profile without a token status = %d; want %d", status, http.StatusUnauthorized)
+	}
+
+	_, body := a.do(t, http.MethodPost, "/users/register", "", map[string]string{"email": "buyer@example.com", "password": "secret123"})
+	token, _ := body["token"].(string)
+
+	status, _ = a.do(t, http.MethodGet, "/seller/orders", token, nil)
+	if status != http.StatusForbidden {
+		t.Fatalf("seller route as a buyer status = %d; want %d", status, http.StatusForbidden)
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	a := newTestApp(t)
+
+	status, body := a.do(t, http.MethodGet, "/readyz", "", nil)
+	if status != http.StatusOK || body["status"] != "ready" {
+		t.Fatalf("readyz = %d %v; want ready", status, body)
+	}
+}
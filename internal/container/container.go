@@ -0,0 +1,191 @@
+package container
+
+import (
+	"go-ecommerce-app/configs"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"go-ecommerce-app/internal/services"
+	"go-ecommerce-app/pkg/notification"
+	"go-ecommerce-app/pkg/payment"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Repositories groups the data access used by the services. Tests fill it
+// with fakes for the repositories they exercise.
+type Repositories struct {
+	User        repository.UserRepository
+	Catalog     repository.CatalogRepository
+	Transaction repository.TransactionRepository
+	Promotion   repository.PromotionRepository
+	Tax         repository.TaxRepository
+	Shipping    repository.ShippingRepository
+	Ledger      repository.LedgerRepository
+	Review      repository.ReviewRepository
+	Wishlist    repository.WishlistRepository
+	StockAlert  repository.StockAlertRepository
+	Inventory   repository.InventoryRepository
+	Storefront  repository.StorefrontRepository
+	Analytics   repository.AnalyticsRepository
+	Idempotency repository.IdempotencyRepository
+	Health      repository.HealthRepository
+	RateLimits  repository.RateLimitStore
+	UnitOfWork  repository.UnitOfWork
+}
+
+// NewRepositories builds the database backed repositories.
+func NewRepositories(db *gorm.DB, config configs.AppConfig) Repositories {
+	rateLimits := repository.NewMemoryRateLimitStore()
+	if config.RateLimit.Store == repository.RateLimitStorePostgres {
+		rateLimits = repository.NewRateLimitRepository(db)
+	}
+
+	return Repositories{
+		User:        repository.NewUserRepository(db),
+		Catalog:     repository.NewCatalogRepository(db),
+		Transaction: repository.NewTransactionRepository(db),
+		Promotion:   repository.NewPromotionRepository(db),
+		Tax:         repository.NewTaxRepository(db),
+		Shipping:    repository.NewShippingRepository(db),
+		Ledger:      repository.NewLedgerRepository(db),
+		Review:      repository.NewReviewRepository(db),
+		Wishlist:    repository.NewWishlistRepository(db),
+		StockAlert:  repository.NewStockAlertRepository(db),
+		Inventory:   repository.NewInventoryRepository(db),
+		Storefront:  repository.NewStorefrontRepository(db),
+		Analytics:   repository.NewAnalyticsRepository(db),
+		Idempotency: repository.NewIdempotencyRepository(db),
+		Health:      repository.NewHealthRepository(db),
+		RateLimits:  rateLimits,
+		UnitOfWork:  repository.NewUnitOfWork(db),
+	}
+}
+
+// Container holds the application's services, wired once at startup and
+// shared by the HTTP handlers and the background jobs.
+type Container struct {
+	Config        configs.AppConfig
+	Auth          helper.Auth
+	Repos         Repositories
+	Payments      payment.PaymentClient
+	Notifications notification.NotificationClient
+	// Alerts texts users about events they follow, in the background.
+	Alerts services.NotificationService
+
+	Users        services.UserService
+	Catalog      services.CatalogService
+	Transactions services.TransactionService
+	Promotions   services.PromotionService
+	Tax          services.TaxService
+	Shipping     services.ShippingService
+	Ledger       services.LedgerService
+	Reviews      services.ReviewService
+	Wishlists    services.WishlistService
+	StockAlerts  services.StockAlertService
+	Inventory    services.InventoryService
+	Storefronts  services.StorefrontService
+	Analytics    services.AnalyticsService
+}
+
+func New(config configs.AppConfig, repos Repositories, payments payment.PaymentClient, notifications notification.NotificationClient) *Container {
+	auth := helper.SetupAuth(config.Auth.AppSecret)
+
+	c := &Container{
+		Config:        config,
+		Auth:          auth,
+		Repos:         repos,
+		Payments:      payments,
+		Notifications: notifications,
+	}
+
+	alerts := services.NotificationService{Client: notifications, Background: &sync.WaitGroup{}}
+	c.Alerts = alerts
+	c.Wishlists = services.WishlistService{
+		Repo:   repos.Wishlist,
+		CRepo:  repos.Catalog,
+		Alerts: alerts,
+		Auth:   auth,
+	}
+	c.StockAlerts = services.StockAlertService{
+		Repo:   repos.StockAlert,
+		CRepo:  repos.Catalog,
+		URepo:  repos.User,
+		Alerts: alerts,
+		Auth:   auth,
+	}
+	c.Inventory = services.InventoryService{
+		Repo: repos.Inventory,
+		Auth: auth,
+	}
+	c.Catalog = services.CatalogService{
+		Repo:        repos.Catalog,
+		Inventory:   c.Inventory,
+		Wishlists:   c.Wishlists,
+		StockAlerts: c.StockAlerts,
+		UnitOfWork:  repos.UnitOfWork,
+		Auth:        auth,
+		Config:      config,
+	}
+	c.Transactions = services.NewTransactionService(repos.Transaction, auth, payments)
+	c.Promotions = services.PromotionService{
+		Repo:  repos.Promotion,
+		CRepo: repos.Catalog,
+		Auth:  auth,
+	}
+	c.Tax = services.TaxService{
+		Repo:  repos.Tax,
+		CRepo: repos.Catalog,
+	}
+	c.Shipping = services.ShippingService{
+		Repo:  repos.Shipping,
+		CRepo: repos.Catalog,
+		Auth:  auth,
+	}
+	c.Ledger = services.LedgerService{
+		Repo:   repos.Ledger,
+		Auth:   auth,
+		Config: config,
+	}
+	c.Reviews = services.ReviewService{
+		Repo:   repos.Review,
+		CRepo:  repos.Catalog,
+		Auth:   auth,
+		Config: config,
+	}
+	c.Storefronts = services.StorefrontService{
+		Repo:  repos.Storefront,
+		CRepo: repos.Catalog,
+		Auth:  auth,
+	}
+	c.Analytics = services.AnalyticsService{
+		Repo: repos.Analytics,
+		Auth: auth,
+	}
+	c.Users = services.UserService{
+		Repo:        repos.User,
+		CRepo:       repos.Catalog,
+		Promotions:  c.Promotions,
+		Tax:         c.Tax,
+		Shipping:    c.Shipping,
+		Ledger:      c.Ledger,
+		Payments:    c.Transactions,
+		Inventory:   c.Inventory,
+		Wishlists:   c.Wishlists,
+		StockAlerts: c.StockAlerts,
+		Guard: services.AuthGuard{
+			Store:        repos.RateLimits,
+			AccountLimit: config.RateLimit.PerAccount,
+			Window:       config.RateLimit.Window,
+			Threshold:    config.RateLimit.LockoutThreshold,
+			Lockout:      config.RateLimit.LockoutDuration,
+			MaxLockout:   config.RateLimit.MaxLockout,
+		},
+		Notifier:   notifications,
+		UnitOfWork: repos.UnitOfWork,
+		Auth:       auth,
+		Config:     config,
+	}
+
+	return c
+}
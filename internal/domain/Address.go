@@ -7,6 +7,7 @@ type Address struct {
 	AddressLine1 string    `json:"address_line1"`
 	AddressLine2 string    `json:"address_line2"`
 	City         string    `json:"city"`
+	Region       string    `json:"region"`
 	PostCode     string    `json:"postCode"`
 	Country      string    `json:"country"`
 	UserID       uint      `json:"user_id"`
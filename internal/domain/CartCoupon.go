@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+type CartCoupon struct {
+	ID        uint      `json:"id" gorm:"PrimaryKey"`
+	UserId    uint      `json:"user_id" gorm:"index;unique"`
+	CouponId  uint      `json:"coupon_id"`
+	Code      string    `json:"code"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"default:current_timestamp"`
+}
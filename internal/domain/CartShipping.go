@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// CartShipping is the shipping method a buyer picked for one seller's items.
+type CartShipping struct {
+	ID                uint      `json:"id" gorm:"PrimaryKey"`
+	UserId            uint      `json:"user_id" gorm:"uniqueIndex:idx_cart_shipping_seller"`
+	SellerId          uint      `json:"seller_id" gorm:"uniqueIndex:idx_cart_shipping_seller"`
+	ShippingProfileId uint      `json:"shipping_profile_id"`
+	CreatedAt         time.Time `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt         time.Time `json:"updated_at" gorm:"default:current_timestamp"`
+}
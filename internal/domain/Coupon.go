@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+type CouponType string
+
+const (
+	CouponTypePercentage   CouponType = "percentage"
+	CouponTypeFixed        CouponType = "fixed"
+	CouponTypeFreeShipping CouponType = "free_shipping"
+)
+
+type Coupon struct {
+	ID           uint       `json:"id" gorm:"PrimaryKey"`
+	Code         string     `json:"code" gorm:"index;unique;not null"`
+	Description  string     `json:"description"`
+	Type         CouponType `json:"type"`
+	Value        float64    `json:"value"`
+	SellerId     uint       `json:"seller_id" gorm:"index"`
+	CategoryId   uint       `json:"category_id"`
+	MinSpend     float64    `json:"min_spend"`
+	UsageLimit   int        `json:"usage_limit"`
+	PerUserLimit int        `json:"per_user_limit"`
+	UsedCount    int        `json:"used_count" gorm:"default:0"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	Active       bool       `json:"active" gorm:"default:true"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"default:current_timestamp"`
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+type CouponRedemption struct {
+	ID        uint      `json:"id" gorm:"PrimaryKey"`
+	CouponId  uint      `json:"coupon_id" gorm:"index"`
+	UserId    uint      `json:"user_id" gorm:"index"`
+	OrderId   uint      `json:"order_id"`
+	Discount  float64   `json:"discount"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:current_timestamp"`
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Error kinds shared by services and mapped to HTTP statuses by the rest layer.
+var (
+	ErrNotFound     = errors.New("not_found")
+	ErrForbidden    = errors.New("forbidden")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation_failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate_limited")
+)
+
+type AppError struct {
+	Kind       error
+	Message    string
+	Details    interface{}
+	RetryAfter time.Duration
+}
+
+func (e *AppError) Error() string {
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Kind
+}
+
+func NewError(kind error, message string) error {
+	return &AppError{Kind: kind, Message: message}
+}
+
+// NewErrorWithDetails attaches machine-readable details that are returned to
+// the client alongside the error code.
+func NewErrorWithDetails(kind error, message string, details interface{}) error {
+	return &AppError{Kind: kind, Message: message, Details: details}
+}
+
+func NotFoundError(message string) error {
+	return NewError(ErrNotFound, message)
+}
+
+func ForbiddenError(message string) error {
+	return NewError(ErrForbidden, message)
+}
+
+func ConflictError(message string) error {
+	return NewError(ErrConflict, message)
+}
+
+func ValidationError(message string) error {
+	return NewError(ErrValidation, message)
+}
+
+func UnauthorizedError(message string) error {
+	return NewError(ErrUnauthorized, message)
+}
+
+// RateLimitedError tells the client to wait retryAfter before trying again.
+func RateLimitedError(message string, retryAfter time.Duration) error {
+	return &AppError{Kind: ErrRateLimited, Message: message, RetryAfter: retryAfter}
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// IdempotencyKey remembers the outcome of a request sent with an
+// Idempotency-Key header so a retry gets the same response instead of
+// repeating the side effects.
+type IdempotencyKey struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Scope       string    `json:"scope" gorm:"uniqueIndex:idx_idempotency_scope_key"`
+	Key         string    `json:"key" gorm:"uniqueIndex:idx_idempotency_scope_key"`
+	Fingerprint string    `json:"fingerprint"`
+	Completed   bool      `json:"completed"`
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"-"`
+	ExpiresAt   time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"default:current_timestamp"`
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+type InventoryReason string
+
+const (
+	InventoryReasonInitial        InventoryReason = "initial"
+	InventoryReasonManual         InventoryReason = "manual"
+	InventoryReasonOrder          InventoryReason = "order"
+	InventoryReasonCancellation   InventoryReason = "cancellation"
+	InventoryReasonRefund         InventoryReason = "refund"
+	InventoryReasonImport         InventoryReason = "import"
+	InventoryReasonReconciliation InventoryReason = "reconciliation"
+)
+
+// InventoryMovement records one change to a product's stock. The quantities
+// of a product's movements add up to its stock; Quantity is negative when
+// stock was taken out. ActorId is zero for changes made by the system.
+type InventoryMovement struct {
+	ID         uint            `json:"id" gorm:"PrimaryKey"`
+	ProductId  uint            `json:"product_id" gorm:"index;not null"`
+	SellerId   uint            `json:"seller_id" gorm:"index;not null"`
+	Quantity   int             `json:"quantity"`
+	StockAfter uint            `json:"stock_after"`
+	Reason     InventoryReason `json:"reason" gorm:"index"`
+	ActorId    uint            `json:"actor_id"`
+	OrderId    uint            `json:"order_id"`
+	Note       string          `json:"note"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"default:current_timestamp"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+type LedgerAccount string
+
+const (
+	LedgerAccountPlatformCash  LedgerAccount = "platform_cash"
+	LedgerAccountCommission    LedgerAccount = "platform_commission"
+	LedgerAccountSellerPayable LedgerAccount = "seller_payable"
+	// LedgerAccountPayoutsInTransit holds payouts sent to the bank until the
+	// transfer is settled as paid or failed.
+	LedgerAccountPayoutsInTransit LedgerAccount = "payouts_in_transit"
+)
+
+// LedgerEntry is one side of a double-entry posting. Entries sharing a
+// Reference always balance: their debits equal their credits.
+type LedgerEntry struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	Reference   string        `json:"reference" gorm:"index"`
+	Account     LedgerAccount `json:"account" gorm:"index:idx_ledger_account_seller"`
+	SellerId    uint          `json:"seller_id" gorm:"index:idx_ledger_account_seller"`
+	Debit       float64       `json:"debit"`
+	Credit      float64       `json:"credit"`
+	Description string        `json:"description"`
+	OrderId     uint          `json:"order_id"`
+	SubOrderId  uint          `json:"sub_order_id"`
+	PayoutId    uint          `json:"payout_id"`
+	CreatedAt   time.Time     `json:"created_at" gorm:"default:current_timestamp"`
+}
@@ -0,0 +1,38 @@
+package domain
+
+// Models lists every entity the database schema is migrated for.
+func Models() []interface{} {
+	return []interface{}{
+		&User{},
+		&Address{},
+		&BankAccount{},
+		&Category{},
+		&Product{},
+		&Cart{},
+		&Order{},
+		&OrderItem{},
+		&Payment{},
+		&Coupon{},
+		&CouponRedemption{},
+		&CartCoupon{},
+		&OrderDiscount{},
+		&TaxRate{},
+		&ShippingProfile{},
+		&CartShipping{},
+		&OrderShipment{},
+		&SubOrder{},
+		&LedgerEntry{},
+		&PayoutBatch{},
+		&Payout{},
+		&IdempotencyKey{},
+		&RateLimit{},
+		&Review{},
+		&ReviewImage{},
+		&ReviewFlag{},
+		&Wishlist{},
+		&WishlistItem{},
+		&StockAlert{},
+		&InventoryMovement{},
+		&Storefront{},
+	}
+}
@@ -2,15 +2,25 @@ package domain
 
 import "time"
 
+// OrderStatusCancelled marks an order the buyer called off before it shipped.
+const OrderStatusCancelled = "cancelled"
+
 type Order struct {
-	ID             uint        `gorm:"primaryKey" json:"id"`
-	UserId         uint        `json:"user_id"`
-	Status         string      `json:"status"`
-	Amount         float64     `json:"amount"`
-	TransactionId  string      `json:"transaction_id"`
-	OrderRefNumber string      `json:"order_ref_number"`
-	PaymentId      string      `json:"payment_id"`
-	Items          []OrderItem `json:"items"`
-	CreatedAt      time.Time   `gorm:"default:current_timestamp"`
-	UpdatedAt      time.Time   `gorm:"default:current_timestamp"`
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	UserId         uint            `json:"user_id"`
+	Status         string          `json:"status"`
+	SubTotal       float64         `json:"sub_total"`
+	DiscountAmount float64         `json:"discount_amount"`
+	ShippingAmount float64         `json:"shipping_amount"`
+	TaxAmount      float64         `json:"tax_amount"`
+	Amount         float64         `json:"amount"`
+	TransactionId  string          `json:"transaction_id"`
+	OrderRefNumber string          `json:"order_ref_number"`
+	PaymentId      string          `json:"payment_id"`
+	Items          []OrderItem     `json:"items"`
+	Discounts      []OrderDiscount `json:"discounts"`
+	Shipments      []OrderShipment `json:"shipments"`
+	SubOrders      []SubOrder      `json:"sub_orders"`
+	CreatedAt      time.Time       `gorm:"default:current_timestamp"`
+	UpdatedAt      time.Time       `gorm:"default:current_timestamp"`
 }
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+type OrderDiscount struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	OrderId     uint      `json:"order_id"`
+	CouponId    uint      `json:"coupon_id"`
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	CreatedAt   time.Time `gorm:"default:current_timestamp"`
+	UpdatedAt   time.Time `gorm:"default:current_timestamp"`
+}
@@ -3,14 +3,20 @@ package domain
 import "time"
 
 type OrderItem struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	OrderId   uint      `json:"order_id"`
-	ProductId uint      `json:"product_id"`
-	Name      string    `json:"name"`
-	ImageUrl  string    `json:"image_url"`
-	SellerId  uint      `json:"seller_id"`
-	Price     float64   `json:"price"`
-	Qty       int       `json:"qty"`
-	CreatedAt time.Time `gorm:"default:current_timestamp"`
-	UpdatedAt time.Time `gorm:"default:current_timestamp"`
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	OrderId       uint      `json:"order_id"`
+	SubOrderId    uint      `json:"sub_order_id" gorm:"index"`
+	ProductId     uint      `json:"product_id"`
+	Name          string    `json:"name"`
+	ImageUrl      string    `json:"image_url"`
+	SellerId      uint      `json:"seller_id"`
+	Price         float64   `json:"price"`
+	Qty           int       `json:"qty"`
+	TaxCountry    string    `json:"tax_country"`
+	TaxRegion     string    `json:"tax_region"`
+	TaxRate       float64   `json:"tax_rate"`
+	TaxableAmount float64   `json:"taxable_amount"`
+	TaxAmount     float64   `json:"tax_amount"`
+	CreatedAt     time.Time `gorm:"default:current_timestamp"`
+	UpdatedAt     time.Time `gorm:"default:current_timestamp"`
 }
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+const (
+	ShipmentStatusPending   = "pending"
+	ShipmentStatusShipped   = "shipped"
+	ShipmentStatusDelivered = "delivered"
+	ShipmentStatusCancelled = "cancelled"
+)
+
+type OrderShipment struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	OrderId           uint       `json:"order_id" gorm:"index"`
+	SellerId          uint       `json:"seller_id" gorm:"index"`
+	ShippingProfileId uint       `json:"shipping_profile_id"`
+	Method            string     `json:"method"`
+	Cost              float64    `json:"cost"`
+	Status            string     `json:"status" gorm:"default:pending"`
+	Carrier           string     `json:"carrier"`
+	TrackingNumber    string     `json:"tracking_number"`
+	ShippedAt         *time.Time `json:"shipped_at"`
+	DeliveredAt       *time.Time `json:"delivered_at"`
+	CreatedAt         time.Time  `gorm:"default:current_timestamp"`
+	UpdatedAt         time.Time  `gorm:"default:current_timestamp"`
+}
@@ -5,6 +5,7 @@ import "time"
 type Payment struct {
 	ID            uint          `gorm:"primaryKey" json:"id"`
 	UserId        uint          `json:"user_id"`
+	Provider      string        `json:"provider"`
 	CaptureMethod string        `json:"capture_method"`
 	Amount        float64       `json:"amount"`
 	OrderId       string        `json:"order_id"`
@@ -14,8 +15,12 @@ type Payment struct {
 	Status        PaymentStatus `json:"status" gorm:"default:'initial'"`
 	Response      string        `json:"response"`
 	PaymentUrl    string        `json:"payment_url"`
-	CreatedAt     time.Time     `gorm:"default:current_timestamp"`
-	UpdatedAt     time.Time     `gorm:"default:current_timestamp"`
+	ExpiresAt     *time.Time    `json:"expires_at"`
+	OrderedAt     *time.Time    `json:"ordered_at"`
+	// Checkout is the cart summary, as JSON, that the payment was taken for.
+	Checkout  string    `json:"-" gorm:"type:text"`
+	CreatedAt time.Time `gorm:"default:current_timestamp"`
+	UpdatedAt time.Time `gorm:"default:current_timestamp"`
 }
 
 type PaymentStatus string
@@ -25,4 +30,7 @@ const (
 	PaymentStatusSuccess PaymentStatus = "success"
 	PaymentStatusFailed  PaymentStatus = "failed"
 	PaymentStatusPending PaymentStatus = "pending"
+	PaymentStatusExpired PaymentStatus = "expired"
+	// PaymentStatusRefunded marks a payment returned to the buyer.
+	PaymentStatusRefunded PaymentStatus = "refunded"
 )
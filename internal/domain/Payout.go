@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+type PayoutStatus string
+
+const (
+	PayoutStatusPending PayoutStatus = "pending"
+	PayoutStatusPaid    PayoutStatus = "paid"
+	PayoutStatusFailed  PayoutStatus = "failed"
+)
+
+// PayoutBatch groups the payouts created by one payout run.
+type PayoutBatch struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Total     float64   `json:"total"`
+	Count     int       `json:"count"`
+	Payouts   []Payout  `json:"payouts"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"default:current_timestamp"`
+}
+
+type Payout struct {
+	ID            uint         `gorm:"primaryKey" json:"id"`
+	PayoutBatchId uint         `json:"payout_batch_id" gorm:"index"`
+	SellerId      uint         `json:"seller_id" gorm:"index"`
+	BankAccountId uint         `json:"bank_account_id"`
+	BankAccount   uint         `json:"bank_account"`
+	SwiftCode     string       `json:"swift_code"`
+	Amount        float64      `json:"amount"`
+	Status        PayoutStatus `json:"status" gorm:"default:pending"`
+	PaidAt        *time.Time   `json:"paid_at"`
+	CreatedAt     time.Time    `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt     time.Time    `json:"updated_at" gorm:"default:current_timestamp"`
+}
@@ -2,15 +2,22 @@ package domain
 
 import "time"
 
+// Product is a seller's listing. The seller is alerted once Stock falls below
+// a non-zero LowStockThreshold.
 type Product struct {
-	ID          uint      `json:"id" gorm:"PrimaryKey"`
-	Name        string    `json:"name" gorm:"index;"`
-	Description string    `json:"description"`
-	CategoryId  uint      `json:"category_id"`
-	ImageUrl    string    `json:"image_url"`
-	Price       float64   `json:"price"`
-	UserId      int       `json:"user_id"`
-	Stock       uint      `json:"stock"`
-	CreatedAt   time.Time `json:"created_at" gorm:"default:current_timestamp"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"default:current_timestamp"`
+	ID                uint      `json:"id" gorm:"PrimaryKey"`
+	Name              string    `json:"name" gorm:"index;"`
+	Description       string    `json:"description"`
+	CategoryId        uint      `json:"category_id"`
+	ImageUrl          string    `json:"image_url"`
+	Price             float64   `json:"price"`
+	UserId            int       `json:"user_id"`
+	Stock             uint      `json:"stock"`
+	LowStockThreshold uint      `json:"low_stock_threshold" gorm:"default:0"`
+	Weight            float64   `json:"weight"`
+	TaxCategory       string    `json:"tax_category" gorm:"default:standard"`
+	RatingAverage     float64   `json:"rating_average" gorm:"default:0"`
+	RatingCount       int       `json:"rating_count" gorm:"default:0"`
+	CreatedAt         time.Time `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt         time.Time `json:"updated_at" gorm:"default:current_timestamp"`
 }
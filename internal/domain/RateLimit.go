@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// RateLimit counts hits against a key in a fixed window and can lock the key
+// out until a given time.
+type RateLimit struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Key         string    `json:"key" gorm:"uniqueIndex"`
+	Hits        int       `json:"hits"`
+	WindowEnd   time.Time `json:"window_end"`
+	LockedUntil time.Time `json:"locked_until"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"default:current_timestamp"`
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPublished ReviewStatus = "published"
+	ReviewStatusHidden    ReviewStatus = "hidden"
+)
+
+// Review is a buyer's rating of a product they received. Hidden reviews are
+// left out of listings and of the product's rating.
+type Review struct {
+	ID          uint          `json:"id" gorm:"PrimaryKey"`
+	ProductId   uint          `json:"product_id" gorm:"uniqueIndex:idx_review_product_user;not null"`
+	UserId      uint          `json:"user_id" gorm:"uniqueIndex:idx_review_product_user;not null"`
+	OrderItemId uint          `json:"order_item_id"`
+	Rating      int           `json:"rating"`
+	Body        string        `json:"body"`
+	Images      []ReviewImage `json:"images"`
+	Reply       string        `json:"reply"`
+	RepliedAt   *time.Time    `json:"replied_at"`
+	Status      ReviewStatus  `json:"status" gorm:"index;default:published"`
+	FlagCount   int           `json:"flag_count" gorm:"default:0"`
+	CreatedAt   time.Time     `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt   time.Time     `json:"updated_at" gorm:"default:current_timestamp"`
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// ReviewFlag reports a review for moderation. A user flags a review once.
+type ReviewFlag struct {
+	ID        uint      `json:"id" gorm:"PrimaryKey"`
+	ReviewId  uint      `json:"review_id" gorm:"uniqueIndex:idx_review_flag_user;not null"`
+	UserId    uint      `json:"user_id" gorm:"uniqueIndex:idx_review_flag_user;not null"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:current_timestamp"`
+}
@@ -0,0 +1,7 @@
+package domain
+
+type ReviewImage struct {
+	ID       uint   `json:"id" gorm:"PrimaryKey"`
+	ReviewId uint   `json:"review_id" gorm:"index"`
+	Url      string `json:"url"`
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+type ShippingRateType string
+
+const (
+	ShippingRateFlat   ShippingRateType = "flat"
+	ShippingRateWeight ShippingRateType = "weight"
+)
+
+// ShippingProfile is a shipping method offered by a seller. Weight based
+// profiles charge Rate plus PerKg for every kilogram shipped, FreeOver waives
+// the charge once the seller's items reach that amount and Countries limits
+// the profile to a comma separated list of country codes.
+type ShippingProfile struct {
+	ID            uint             `json:"id" gorm:"PrimaryKey"`
+	SellerId      uint             `json:"seller_id" gorm:"index"`
+	Name          string           `json:"name"`
+	Type          ShippingRateType `json:"type"`
+	Rate          float64          `json:"rate"`
+	PerKg         float64          `json:"per_kg"`
+	FreeOver      float64          `json:"free_over"`
+	Countries     string           `json:"countries"`
+	EstimatedDays int              `json:"estimated_days"`
+	Active        bool             `json:"active" gorm:"default:true"`
+	CreatedAt     time.Time        `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt     time.Time        `json:"updated_at" gorm:"default:current_timestamp"`
+}
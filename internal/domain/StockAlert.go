@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// StockAlert is a buyer's request to be told when an out of stock product
+// can be bought again. It is removed once the alert is sent.
+type StockAlert struct {
+	ID        uint      `json:"id" gorm:"PrimaryKey"`
+	ProductId uint      `json:"product_id" gorm:"uniqueIndex:idx_stock_alert_user;not null"`
+	UserId    uint      `json:"user_id" gorm:"uniqueIndex:idx_stock_alert_user;index;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:current_timestamp"`
+}
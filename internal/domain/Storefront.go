@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Storefront is the public face of a seller, served at /sellers/:slug. The
+// rating is aggregated from the published reviews of the seller's products
+// when the storefront is read.
+type Storefront struct {
+	ID            uint      `json:"id" gorm:"PrimaryKey"`
+	UserId        uint      `json:"user_id" gorm:"uniqueIndex;not null"`
+	StoreName     string    `json:"store_name"`
+	Slug          string    `json:"slug" gorm:"uniqueIndex;not null"`
+	LogoUrl       string    `json:"logo_url"`
+	Description   string    `json:"description"`
+	ReturnPolicy  string    `json:"return_policy"`
+	ContactEmail  string    `json:"contact_email"`
+	ContactPhone  string    `json:"contact_phone"`
+	RatingAverage float64   `json:"rating_average" gorm:"-"`
+	RatingCount   int       `json:"rating_count" gorm:"-"`
+	CreatedAt     time.Time `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"default:current_timestamp"`
+}
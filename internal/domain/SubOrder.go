@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// SubOrder is the part of an order fulfilled by one seller, together with the
+// amount the seller is owed for it after the platform commission.
+type SubOrder struct {
+	ID             uint        `gorm:"primaryKey" json:"id"`
+	OrderId        uint        `json:"order_id" gorm:"index"`
+	SellerId       uint        `json:"seller_id" gorm:"index"`
+	SubTotal       float64     `json:"sub_total"`
+	DiscountAmount float64     `json:"discount_amount"`
+	ShippingAmount float64     `json:"shipping_amount"`
+	TaxAmount      float64     `json:"tax_amount"`
+	Amount         float64     `json:"amount"`
+	CommissionRate float64     `json:"commission_rate"`
+	Commission     float64     `json:"commission"`
+	SellerAmount   float64     `json:"seller_amount"`
+	Items          []OrderItem `json:"items"`
+	CreatedAt      time.Time   `gorm:"default:current_timestamp"`
+	UpdatedAt      time.Time   `gorm:"default:current_timestamp"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// DefaultTaxCategory is used for products without a specific tax category.
+const DefaultTaxCategory = "standard"
+
+// TaxRate applies Rate percent to products of TaxCategory shipped to Country
+// and, when set, Region. Empty Region or TaxCategory match any value.
+type TaxRate struct {
+	ID          uint      `json:"id" gorm:"PrimaryKey"`
+	Name        string    `json:"name"`
+	Country     string    `json:"country" gorm:"uniqueIndex:idx_tax_rate_scope;not null"`
+	Region      string    `json:"region" gorm:"uniqueIndex:idx_tax_rate_scope"`
+	TaxCategory string    `json:"tax_category" gorm:"uniqueIndex:idx_tax_rate_scope"`
+	Rate        float64   `json:"rate"`
+	CreatedAt   time.Time `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"default:current_timestamp"`
+}
@@ -5,6 +5,9 @@ import "time"
 const (
 	SELLER = "seller"
 	BUYER  = "buyer"
+	// ADMIN runs the platform, such as moderating reviews. Admins are
+	// appointed in the database; there is no way to sign up as one.
+	ADMIN = "admin"
 )
 
 type User struct {
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// DefaultWishlistName names the list cart items are saved for later to when
+// the user does not pick one.
+const DefaultWishlistName = "Saved for later"
+
+// Wishlist is a named list of products a user is keeping an eye on. Anyone
+// holding the share token can view a public list.
+type Wishlist struct {
+	ID         uint           `json:"id" gorm:"PrimaryKey"`
+	UserId     uint           `json:"user_id" gorm:"index;not null"`
+	Name       string         `json:"name"`
+	Public     bool           `json:"public" gorm:"default:false"`
+	ShareToken string         `json:"share_token" gorm:"uniqueIndex"`
+	Items      []WishlistItem `json:"items"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt  time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// WishlistItem keeps the product as it was when added. CurrentPrice and
+// InStock are filled from the catalog when the list is read.
+type WishlistItem struct {
+	ID           uint      `json:"id" gorm:"PrimaryKey"`
+	WishlistId   uint      `json:"wishlist_id" gorm:"uniqueIndex:idx_wishlist_product;not null"`
+	ProductId    uint      `json:"product_id" gorm:"uniqueIndex:idx_wishlist_product;index;not null"`
+	Name         string    `json:"name"`
+	ImageUrl     string    `json:"image_url"`
+	SellerId     uint      `json:"seller_id"`
+	Price        float64   `json:"price"`
+	CurrentPrice float64   `json:"current_price" gorm:"-"`
+	InStock      bool      `json:"in_stock" gorm:"-"`
+	CreatedAt    time.Time `json:"created_at" gorm:"default:current_timestamp"`
+}
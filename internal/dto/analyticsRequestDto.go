@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+// SalesReportQuery selects the sales in [From, To), grouped into Bucket
+// periods, with the Limit best selling products.
+type SalesReportQuery struct {
+	From   time.Time
+	To     time.Time
+	Bucket string
+	Limit  int
+}
@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+// SalesSummary totals a seller's sales over a report period. Shoppers are
+// the buyers plus the shoppers still holding the seller's products in
+// their cart.
+type SalesSummary struct {
+	Orders            int64   `json:"orders"`
+	Units             int64   `json:"units"`
+	Revenue           float64 `json:"revenue"`
+	AverageOrderValue float64 `json:"average_order_value"`
+	Buyers            int64   `json:"buyers"`
+	Shoppers          int64   `json:"shoppers"`
+	ConversionRate    float64 `json:"conversion_rate"`
+	RefundedOrders    int64   `json:"refunded_orders"`
+	RefundedRevenue   float64 `json:"refunded_revenue"`
+	RefundRate        float64 `json:"refund_rate"`
+}
+
+// SalesBucket is a seller's sales in one day, week or month of a report.
+type SalesBucket struct {
+	Period  time.Time `json:"period"`
+	Orders  int64     `json:"orders"`
+	Units   int64     `json:"units"`
+	Revenue float64   `json:"revenue"`
+}
+
+type TopProduct struct {
+	ProductId uint    `json:"product_id"`
+	Name      string  `json:"name"`
+	Orders    int64   `json:"orders"`
+	Units     int64   `json:"units"`
+	Revenue   float64 `json:"revenue"`
+}
+
+type SalesReport struct {
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Bucket      string        `json:"bucket"`
+	Summary     SalesSummary  `json:"summary"`
+	Series      []SalesBucket `json:"series"`
+	TopProducts []TopProduct  `json:"top_products"`
+}
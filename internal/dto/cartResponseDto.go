@@ -0,0 +1,70 @@
+package dto
+
+import "go-ecommerce-app/internal/domain"
+
+const (
+	CartWarningPriceChanged      = "price_changed"
+	CartWarningOutOfStock        = "out_of_stock"
+	CartWarningInsufficientStock = "insufficient_stock"
+	CartWarningProductRemoved    = "product_removed"
+	CartWarningCouponInvalid     = "coupon_invalid"
+)
+
+type CartWarning struct {
+	CartItemId uint    `json:"cart_item_id"`
+	ProductId  uint    `json:"product_id"`
+	Code       string  `json:"code"`
+	Message    string  `json:"message"`
+	OldPrice   float64 `json:"old_price,omitempty"`
+	NewPrice   float64 `json:"new_price,omitempty"`
+	Available  uint    `json:"available,omitempty"`
+}
+
+type DiscountLine struct {
+	CouponId    uint    `json:"coupon_id"`
+	Type        string  `json:"type"`
+	SellerId    uint    `json:"seller_id"`
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	// CartItemIds are the cart lines the coupon applies to.
+	CartItemIds []uint `json:"cart_item_ids"`
+}
+
+type TaxLine struct {
+	CartItemId uint    `json:"cart_item_id"`
+	ProductId  uint    `json:"product_id"`
+	Country    string  `json:"country"`
+	Region     string  `json:"region,omitempty"`
+	Rate       float64 `json:"rate"`
+	Taxable    float64 `json:"taxable"`
+	Amount     float64 `json:"amount"`
+}
+
+type ShippingOption struct {
+	ShippingProfileId uint    `json:"shipping_profile_id"`
+	Name              string  `json:"name"`
+	Cost              float64 `json:"cost"`
+	EstimatedDays     int     `json:"estimated_days"`
+}
+
+type SellerShipping struct {
+	SellerId uint             `json:"seller_id"`
+	SubTotal float64          `json:"sub_total"`
+	Options  []ShippingOption `json:"options"`
+	Selected *ShippingOption  `json:"selected"`
+}
+
+type CartSummary struct {
+	Items           []domain.Cart    `json:"items"`
+	SubTotal        float64          `json:"sub_total"`
+	Discounts       []DiscountLine   `json:"discounts"`
+	DiscountTotal   float64          `json:"discount_total"`
+	Shipping        []SellerShipping `json:"shipping"`
+	ShippingTotal   float64          `json:"shipping_total"`
+	Taxes           []TaxLine        `json:"taxes"`
+	TaxTotal        float64          `json:"tax_total"`
+	Total           float64          `json:"total"`
+	ShippingAddress *domain.Address  `json:"shipping_address"`
+	Warnings        []CartWarning    `json:"warnings"`
+}
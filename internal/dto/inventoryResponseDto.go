@@ -0,0 +1,11 @@
+package dto
+
+// StockDrift is a product whose stock does not match its inventory
+// movements.
+type StockDrift struct {
+	ProductId   uint   `json:"product_id"`
+	Name        string `json:"name"`
+	Stock       uint   `json:"stock"`
+	LedgerStock int    `json:"ledger_stock"`
+	Movements   int64  `json:"movements"`
+}
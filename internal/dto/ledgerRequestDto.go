@@ -0,0 +1,8 @@
+package dto
+
+import "go-ecommerce-app/internal/domain"
+
+// PayoutSettlementRequest is the bank's outcome for a payout transfer.
+type PayoutSettlementRequest struct {
+	Status domain.PayoutStatus `json:"status"`
+}
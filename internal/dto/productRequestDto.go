@@ -1,14 +1,30 @@
 package dto
 
+// CreateProductRequest also edits products. Leaving LowStockThreshold out of
+// an edit keeps the current one.
 type CreateProductRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	CategoryId  uint    `json:"category_id"`
-	ImageUrl    string  `json:"image_url"`
-	Price       float64 `json:"price"`
-	Stock       int     `json:"stock"`
+	Name              string  `json:"name"`
+	Description       string  `json:"description"`
+	CategoryId        uint    `json:"category_id"`
+	ImageUrl          string  `json:"image_url"`
+	Price             float64 `json:"price"`
+	Stock             int     `json:"stock"`
+	Weight            float64 `json:"weight"`
+	TaxCategory       string  `json:"tax_category"`
+	LowStockThreshold *int    `json:"low_stock_threshold"`
 }
 
 type UpdateStockRequest struct {
-	Stock int `json:"stock"`
+	Stock int    `json:"stock"`
+	Note  string `json:"note"`
+}
+
+type ImportStockRequest struct {
+	Items []StockImportLine `json:"items"`
+	Note  string            `json:"note"`
+}
+
+type StockImportLine struct {
+	ProductId uint `json:"product_id"`
+	Stock     int  `json:"stock"`
 }
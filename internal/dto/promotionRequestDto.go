@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+type CreateCouponRequest struct {
+	Code         string     `json:"code"`
+	Description  string     `json:"description"`
+	Type         string     `json:"type"`
+	Value        float64    `json:"value"`
+	CategoryId   uint       `json:"category_id"`
+	MinSpend     float64    `json:"min_spend"`
+	UsageLimit   int        `json:"usage_limit"`
+	PerUserLimit int        `json:"per_user_limit"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+}
+
+type ApplyCouponRequest struct {
+	Code string `json:"code"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+type ReviewRequest struct {
+	Rating int      `json:"rating"`
+	Body   string   `json:"body"`
+	Images []string `json:"images"`
+}
+
+type ReviewReplyRequest struct {
+	Reply string `json:"reply"`
+}
+
+type ReviewFlagRequest struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+type ShippingProfileRequest struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	Rate          float64  `json:"rate"`
+	PerKg         float64  `json:"per_kg"`
+	FreeOver      float64  `json:"free_over"`
+	Countries     []string `json:"countries"`
+	EstimatedDays int      `json:"estimated_days"`
+}
+
+type SelectShippingRequest struct {
+	SellerId          uint `json:"seller_id"`
+	ShippingProfileId uint `json:"shipping_profile_id"`
+}
+
+type ShipOrderRequest struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+type StorefrontRequest struct {
+	StoreName    string `json:"store_name"`
+	Slug         string `json:"slug"`
+	LogoUrl      string `json:"logo_url"`
+	Description  string `json:"description"`
+	ReturnPolicy string `json:"return_policy"`
+	ContactEmail string `json:"contact_email"`
+	ContactPhone string `json:"contact_phone"`
+}
@@ -1,17 +1,17 @@
 package dto
 
-type SellerOrderDetails struct {
-	OrderRefNumber  int    `json:"order_ref_number"`
-	OrderStatus     int    `json:"order_status"`
-	CreatedAt       string `json:"created_at"`
-	OrderItemId     uint   `json:"order_item_id"`
-	ProductId       uint   `json:"product_id"`
-	Name            string `json:"name"`
-	ImageUrl        string `json:"image_url"`
-	Price           string `json:"price"`
-	Qty             uint   `json:"qty"`
-	CustomerName    string `json:"customer_name"`
-	CustomerEmail   string `json:"customer_email"`
-	CustomerPhone   string `json:"customer_phone"`
-	CustomerAddress string `json:"customer_address"`
+type SellerTaxReportLine struct {
+	Country       string  `json:"country"`
+	Region        string  `json:"region"`
+	TaxRate       float64 `json:"tax_rate"`
+	Items         int64   `json:"items"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	TaxAmount     float64 `json:"tax_amount"`
+}
+
+type SellerBalance struct {
+	SellerId       uint    `json:"seller_id"`
+	Balance        float64 `json:"balance"`
+	PendingPayouts float64 `json:"pending_payouts"`
+	PaidOut        float64 `json:"paid_out"`
 }
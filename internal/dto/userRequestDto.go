@@ -1,8 +1,9 @@
 package dto
 
 type UserLogin struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	CartToken string `json:"cart_token"`
 }
 
 type UserSignUp struct {
@@ -27,6 +28,7 @@ type AddressInput struct {
 	AddressLine1 string `json:"addressLine1"`
 	AddressLine2 string `json:"addressLine2"`
 	City         string `json:"city"`
+	Region       string `json:"region"`
 	PostCode     string `json:"postCode"`
 	Country      string `json:"country"`
 }
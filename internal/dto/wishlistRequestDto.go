@@ -0,0 +1,10 @@
+package dto
+
+type WishlistRequest struct {
+	Name   string `json:"name"`
+	Public *bool  `json:"public"`
+}
+
+type WishlistItemRequest struct {
+	ProductId uint `json:"product_id"`
+}
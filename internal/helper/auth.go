@@ -110,23 +110,19 @@ func (a *Auth) Authorize(ctx *fiber.Ctx) error {
 	headers := ctx.GetReqHeaders()
 	authHeader, ok := headers["Authorization"]
 	if !ok || len(authHeader) == 0 {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
-			"message": "Authorization failed",
-			"reason":  "Missing Authorization header",
-		})
+		return domain.UnauthorizedError("missing Authorization header")
 	}
-	// Todo
-	user, err := a.VerifyToken(authHeader[0])
 
-	if err == nil && user.ID > 0 {
-		ctx.Locals("user", user)
-		return ctx.Next()
-	} else {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
-			"message": "Authorization failed",
-			"reason":  err.Error(),
-		})
+	user, err := a.VerifyToken(authHeader[0])
+	if err != nil {
+		return domain.UnauthorizedError(err.Error())
 	}
+	if user.ID < 1 {
+		return domain.UnauthorizedError("invalid token")
+	}
+
+	ctx.Locals("user", user)
+	return ctx.Next()
 }
 
 func (a *Auth) GetCurrentUser(ctx *fiber.Ctx) domain.User {
@@ -135,33 +131,54 @@ func (a *Auth) GetCurrentUser(ctx *fiber.Ctx) domain.User {
 	return user.(domain.User)
 }
 
+// VerificationCodeLength is long enough that guessing a code within the
+// attempts the rate limits allow is impractical.
+const VerificationCodeLength = 8
+
 func (a Auth) GenerateCode() (string, error) {
-	return RandomNumbers(6)
+	return RandomNumbers(VerificationCodeLength)
 }
 
 func (a *Auth) AuthorizeSeller(ctx *fiber.Ctx) error {
 	headers := ctx.GetReqHeaders()
 	authHeader, ok := headers["Authorization"]
 	if !ok || len(authHeader) == 0 {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
-			"message": "Authorization failed",
-			"reason":  "Missing Authorization header",
-		})
+		return domain.UnauthorizedError("missing Authorization header")
 	}
+
 	user, err := a.VerifyToken(authHeader[0])
+	if err != nil {
+		return domain.UnauthorizedError(err.Error())
+	}
+	if user.ID < 1 {
+		return domain.UnauthorizedError("invalid token")
+	}
+	if user.UserType != domain.SELLER {
+		return domain.ForbiddenError("please join seller program to manage products")
+	}
 
+	ctx.Locals("user", user)
+	return ctx.Next()
+}
+
+func (a *Auth) AuthorizeAdmin(ctx *fiber.Ctx) error {
+	headers := ctx.GetReqHeaders()
+	authHeader, ok := headers["Authorization"]
+	if !ok || len(authHeader) == 0 {
+		return domain.UnauthorizedError("missing Authorization header")
+	}
+
+	user, err := a.VerifyToken(authHeader[0])
 	if err != nil {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
-			"message": "Authorization failed",
-			"reason":  err.Error(),
-		})
-	} else if user.ID > 0 && user.UserType == domain.SELLER {
-		ctx.Locals("user", user)
-		return ctx.Next()
-	} else {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
-			"message": "Authorization failed",
-			"reason":  errors.New("please join seller program to manage products"),
-		})
+		return domain.UnauthorizedError(err.Error())
+	}
+	if user.ID < 1 {
+		return domain.UnauthorizedError("invalid token")
 	}
+	if user.UserType != domain.ADMIN {
+		return domain.ForbiddenError("only platform admins can do this")
+	}
+
+	ctx.Locals("user", user)
+	return ctx.Next()
 }
@@ -2,6 +2,10 @@ package helper
 
 import (
 	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"strings"
 )
 
 func RandomNumbers(length int) (string, error) {
@@ -21,3 +25,26 @@ func RandomNumbers(length int) (string, error) {
 
 	return string(buffer), nil
 }
+
+func RandomToken(length int) (string, error) {
+	buffer := make([]byte, length)
+	_, err := rand.Read(buffer)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buffer), nil
+}
+
+// IsWebUrl reports whether s is an absolute http or https URL.
+func IsWebUrl(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns s into lowercase words joined by hyphens, for use in URLs.
+func Slugify(s string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSellerSalesAnalytics(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, sellerId := h.newSeller("seller@example.com", "+15550801", 82345678)
+	book := h.listProduct(sellerToken, "Go in Action", 20)
+	bookmark := h.listProduct(sellerToken, "Bookmark", 5)
+	profileId := h.flatShipping(sellerToken, 5)
+
+	first := h.newBuyer("first@example.com", "+15550802")
+	h.buy(first, sellerId, profileId, book, 2)
+	h.buy(first, sellerId, profileId, bookmark, 1)
+
+	second := h.newBuyer("second@example.com", "+15550803")
+	refunded := h.buy(second, sellerId, profileId, book, 1)
+
+	browser := h.newBuyer("browser@example.com", "+15550804")
+	h.ok(fiber.MethodPost, "/users/cart", browser, map[string]interface{}{"product_id": bookmark, "qty": 1})
+
+	// the second buyer called their order off and got their money back
+	h.ok(fiber.MethodPost, fmt.Sprintf("/users/order/%d/cancel", refunded), second, nil)
+
+	// the period ends today, and today's sales are included
+	today := time.Now().UTC()
+	period := fmt.Sprintf("from=%s&to=%s", today.AddDate(0, 0, -1).Format(time.DateOnly), today.Format(time.DateOnly))
+
+	report := h.ok(fiber.MethodGet, "/seller/analytics/sales?bucket=month&"+period, sellerToken, nil)
+	summary := report["data"].(map[string]interface{})["summary"].(map[string]interface{})
+	want := map[string]float64{
+		"orders":              3,
+		"units":               4,
+		"revenue":             65,
+		"average_order_value": 21.67,
+		"buyers":              2,
+		"shoppers":            3,
+		"conversion_rate":     0.6667,
+		"refunded_orders":     1,
+		"refunded_revenue":    20,
+		"refund_rate":         0.3333,
+	}
+	for key, value := range want {
+		if num(summary, key) != value {
+			t.Fatalf("summary %s = %v; want %v in %v", key, num(summary, key), value, summary)
+		}
+	}
+
+	series := list(report, "data", "series")
+	if len(series) != 1 || num(series[0].(map[string]interface{}), "revenue") != 65 {
+		t.Fatalf("monthly series = %v; want one month of 65", series)
+	}
+
+	top := list(report, "data", "top_products")
+	if len(top) != 2 || uint(num(top[0].(map[string]interface{}), "product_id")) != uint(book) ||
+		num(top[0].(map[string]interface{}), "units") != 3 {
+		t.Fatalf("top products = %v; want the book first with 3 sold", top)
+	}
+	if top := list(h.ok(fiber.MethodGet, "/seller/analytics/sales?limit=1&"+period, sellerToken, nil), "data", "top_products"); len(top) != 1 {
+		t.Fatalf("limited top products = %v; want one", top)
+	}
+
+	if resp := h.request(fiber.MethodGet, "/seller/analytics/sales?bucket=hour", sellerToken, nil); resp.Status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("hourly report status = %d; want 422", resp.Status)
+	}
+
+	// The top products download as CSV.
+	req := httptest.NewRequest(fiber.MethodGet, "/seller/analytics/sales/export?report=products&"+period, nil)
+	req.Header.Set("Authorization", "Bearer "+sellerToken)
+	resp, err := h.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if resp.StatusCode != fiber.StatusOK || !strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), "text/csv") {
+		t.Fatalf("export status = %d, type = %q", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType))
+	}
+	if len(lines) != 3 || lines[0] != "product_id,name,orders,units,revenue" ||
+		lines[1] != fmt.Sprintf("%d,Go in Action,2,3,60.00", uint(book)) {
+		t.Fatalf("export = %q; want a header and both products", lines)
+	}
+}
@@ -0,0 +1,34 @@
+package integration
+
+import (
+	"context"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestExpiredGuestCartsGoAsAWhole(t *testing.T) {
+	h := newHarness(t)
+
+	now := time.Now()
+	lines := []domain.Cart{
+		{CartToken: "abandoned", ProductId: 1, Qty: 1, UpdatedAt: now.Add(-10 * 24 * time.Hour)},
+		{CartToken: "active", ProductId: 1, Qty: 1, UpdatedAt: now.Add(-10 * 24 * time.Hour)},
+		{CartToken: "active", ProductId: 2, Qty: 1, UpdatedAt: now.Add(-time.Hour)},
+	}
+	if err := h.db.Create(&lines).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := repository.NewUserRepository(h.db).DeleteExpiredGuestCarts(context.Background(), now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kept []domain.Cart
+	h.db.Order("product_id").Find(&kept)
+	if deleted != 1 || len(kept) != 2 || kept[0].CartToken != "active" || kept[1].CartToken != "active" {
+		t.Fatalf("deleted %d, kept %+v; want only the abandoned cart gone", deleted, kept)
+	}
+}
@@ -0,0 +1,230 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"go-ecommerce-app/pkg/payment"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCheckoutFlow(t *testing.T) {
+	h := newHarness(t)
+
+	// A verified user joins the seller program and lists a product.
+	sellerToken := h.signUp("seller@example.com", "+15550101")
+	sellerToken = str(h.ok(fiber.MethodPost, "/users/become-seller", sellerToken, map[string]interface{}{
+		"first_name":        "Sam",
+		"last_name":         "Seller",
+		"phone_number":      "+15550101",
+		"bankAccountNumber": 12345678,
+		"swiftCode":         "BANKUS33",
+		"paymentType":       "bank",
+	}), "token")
+	sellerId := uint(num(h.ok(fiber.MethodGet, "/users/profile", sellerToken, nil), "profile", "id"))
+
+	h.ok(fiber.MethodPost, "/seller/categories", sellerToken, map[string]interface{}{"name": "Books"})
+	categories := list(h.ok(fiber.MethodGet, "/categories", "", nil), "data")
+	if len(categories) != 1 {
+		t.Fatalf("categories = %v; want the one created", categories)
+	}
+	categoryId := num(categories[0].(map[string]interface{}), "id")
+
+	h.ok(fiber.MethodPost, "/seller/products", sellerToken, map[string]interface{}{
+		"name":        "Go in Action",
+		"description": "A book about Go",
+		"category_id": categoryId,
+		"price":       25,
+		"stock":       10,
+		"weight":      0.5,
+	})
+	products := list(h.ok(fiber.MethodGet, "/seller/products", sellerToken, nil), "data")
+	if len(products) != 1 {
+		t.Fatalf("seller products = %v; want the one created", products)
+	}
+	productId := num(products[0].(map[string]interface{}), "id")
+
+	profileId := num(h.ok(fiber.MethodPost, "/seller/shipping-profiles", sellerToken, map[string]interface{}{
+		"name":           "Standard",
+		"type":           "flat",
+		"rate":           5,
+		"countries":      []string{"US"},
+		"estimated_days": 3,
+	}), "data", "id")
+
+	// A buyer fills a cart, picks shipping and pays.
+	buyerToken := h.signUp("buyer@example.com", "+15550102")
+	h.ok(fiber.MethodPost, "/users/profile", buyerToken, map[string]interface{}{
+		"first_name": "Bea",
+		"last_name":  "Buyer",
+		"address": map[string]string{
+			"addressLine1": "1 Main St",
+			"city":         "Springfield",
+			"postCode":     "12345",
+			"country":      "US",
+		},
+	})
+
+	h.ok(fiber.MethodPost, "/users/cart", buyerToken, map[string]interface{}{"product_id": productId, "qty": 2})
+
+	if resp := h.request(fiber.MethodGet, "/payment", buyerToken, nil); resp.Status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("payment without shipping status = %d, body = %v; want %d", resp.Status, resp.Body, fiber.StatusUnprocessableEntity)
+	}
+
+	summary := h.ok(fiber.MethodPost, "/users/cart/shipping", buyerToken, map[string]interface{}{
+		"seller_id":           sellerId,
+		"shipping_profile_id": profileId,
+	})
+	total := num(summary, "data", "total")
+	if total != 55 {
+		t.Fatalf("cart total = %v; want 2 x 25 + 5 shipping", total)
+	}
+
+	pay := h.ok(fiber.MethodGet, "/payment", buyerToken, nil)
+	sessionId := str(pay, "result", "id")
+	if len(sessionId) < 1 || len(str(pay, "payment_url")) < 1 {
+		t.Fatalf("payment = %v; want a session and a payment url", pay)
+	}
+	if charged := h.payments.Amount(sessionId); math.Abs(charged-total) > 0.001 {
+		t.Fatalf("provider charged %v; want %v", charged, total)
+	}
+
+	// Nothing is ordered, nor owed to the seller, until the payment goes
+	// through.
+	if resp := h.request(fiber.MethodPost, "/users/order", buyerToken, nil); resp.Status != fiber.StatusConflict {
+		t.Fatalf("unpaid order status = %d, body = %v; want %d", resp.Status, resp.Body, fiber.StatusConflict)
+	}
+	if orders := list(h.ok(fiber.MethodGet, "/users/order", buyerToken, nil), "orders"); len(orders) != 0 {
+		t.Fatalf("orders before paying = %v; want none", orders)
+	}
+
+	if err := h.payments.Complete(sessionId); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := h.request(fiber.MethodGet, "/payment", buyerToken, nil); resp.Status != fiber.StatusConflict {
+		t.Fatalf("paying twice status = %d, body = %v; want %d", resp.Status, resp.Body, fiber.StatusConflict)
+	}
+
+	// A price change after paying does not change what the order costs.
+	h.ok(fiber.MethodPut, fmt.Sprintf("/seller/products/%d", uint(productId)), sellerToken, map[string]interface{}{"price": 30})
+
+	// The order is placed and shows up for the buyer and the seller.
+	orderRef := str(h.ok(fiber.MethodPost, "/users/order", buyerToken, nil), "order")
+	if len(orderRef) < 1 {
+		t.Fatal("order was created without a reference")
+	}
+
+	orders := list(h.ok(fiber.MethodGet, "/users/order", buyerToken, nil), "orders")
+	if len(orders) != 1 {
+		t.Fatalf("buyer orders = %v; want one", orders)
+	}
+	order := orders[0].(map[string]interface{})
+	if str(order, "order_ref_number") != orderRef || num(order, "amount") != total || str(order, "payment_id") != sessionId {
+		t.Fatalf("buyer order = %v; want %s for %v paid with %s", order, orderRef, total, sessionId)
+	}
+
+	cart := h.ok(fiber.MethodGet, "/users/cart", buyerToken, nil)
+	if items := list(cart, "cart"); len(items) != 0 {
+		t.Fatalf("cart after checkout = %v; want it empty", items)
+	}
+
+	subOrders := list(h.ok(fiber.MethodGet, "/seller/orders", sellerToken, nil), "data")
+	if len(subOrders) != 1 {
+		t.Fatalf("seller orders = %v; want one", subOrders)
+	}
+	subOrder := subOrders[0].(map[string]interface{})
+	if uint(num(subOrder, "seller_id")) != sellerId || num(subOrder, "amount") != total {
+		t.Fatalf("seller order = %v; want seller %d for %v", subOrder, sellerId, total)
+	}
+
+	balance := num(h.ok(fiber.MethodGet, "/seller/balance", sellerToken, nil), "data", "balance")
+	if balance <= 0 || balance >= total {
+		t.Fatalf("seller balance = %v; want the order total less commission", balance)
+	}
+
+	// Buyers cannot reach seller routes.
+	if resp := h.request(fiber.MethodGet, fmt.Sprintf("/seller/orders/%d", uint(num(subOrder, "id"))), buyerToken, nil); resp.Status != fiber.StatusForbidden {
+		t.Fatalf("seller order as buyer status = %d; want %d", resp.Status, fiber.StatusForbidden)
+	}
+}
+
+func TestCouponCoveringTheOrderNeedsNoPayment(t *testing.T) {
+	h := newHarness(t)
+
+	// The seller has no shipping profile, so shipping is free.
+	sellerToken, _ := h.newSeller("seller@example.com", "+15550103", 12345679)
+	product := h.listProduct(sellerToken, "Go in Action", 20)
+	h.ok(fiber.MethodPost, "/seller/coupons", sellerToken, map[string]interface{}{
+		"code":  "FREEBOOK",
+		"type":  "percentage",
+		"value": 100,
+	})
+
+	buyer := h.newBuyer("buyer@example.com", "+15550104")
+	h.ok(fiber.MethodPost, "/users/cart", buyer, map[string]interface{}{"product_id": product, "qty": 1})
+	summary := h.ok(fiber.MethodPost, "/users/cart/coupon", buyer, map[string]interface{}{"code": "FREEBOOK"})
+	if total := num(summary, "data", "total"); total != 0 {
+		t.Fatalf("cart total = %v; want 0 with the coupon", total)
+	}
+
+	// There is nothing to pay, so the order is placed straight away.
+	orderRef := str(h.ok(fiber.MethodGet, "/payment", buyer, nil), "order")
+	orders := list(h.ok(fiber.MethodGet, "/users/order", buyer, nil), "orders")
+	if len(orders) != 1 {
+		t.Fatalf("orders = %v; want the one placed", orders)
+	}
+	order := orders[0].(map[string]interface{})
+	if str(order, "order_ref_number") != orderRef || num(order, "amount") != 0 || num(order, "discount_amount") != 20 {
+		t.Fatalf("order = %v; want %s for nothing, 20 off", order, orderRef)
+	}
+	if items := list(h.ok(fiber.MethodGet, "/users/cart", buyer, nil), "cart"); len(items) != 0 {
+		t.Fatalf("cart after checkout = %v; want it empty", items)
+	}
+}
+
+func TestCouponUsedUpAfterPaymentIsRefunded(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, _ := h.newSeller("seller@example.com", "+15550105", 12345680)
+	product := h.listProduct(sellerToken, "Go in Action", 20)
+	h.ok(fiber.MethodPost, "/seller/coupons", sellerToken, map[string]interface{}{
+		"code":        "ONCE",
+		"type":        "percentage",
+		"value":       10,
+		"usage_limit": 1,
+	})
+
+	// Both buyers pay with the coupon before either places the order.
+	var buyers, sessions []string
+	for _, phone := range []string{"+15550106", "+15550107"} {
+		buyer := h.newBuyer("buyer"+phone+"@example.com", phone)
+		h.ok(fiber.MethodPost, "/users/cart", buyer, map[string]interface{}{"product_id": product, "qty": 1})
+		h.ok(fiber.MethodPost, "/users/cart/coupon", buyer, map[string]interface{}{"code": "ONCE"})
+
+		sessionId := str(h.ok(fiber.MethodGet, "/payment", buyer, nil), "result", "id")
+		if err := h.payments.Complete(sessionId); err != nil {
+			t.Fatal(err)
+		}
+		buyers = append(buyers, buyer)
+		sessions = append(sessions, sessionId)
+	}
+
+	h.ok(fiber.MethodPost, "/users/order", buyers[0], nil)
+
+	// The coupon ran out for the second buyer, who gets their money back.
+	resp := h.request(fiber.MethodPost, "/users/order", buyers[1], nil)
+	if resp.Status != fiber.StatusConflict || !strings.Contains(str(resp.Body, "error", "message"), "refunded") {
+		t.Fatalf("order with a used up coupon status = %d, body = %v; want a conflict saying the payment was refunded", resp.Status, resp.Body)
+	}
+	session, err := h.payments.GetPaymentStatus(context.Background(), sessions[1])
+	if err != nil || session.Status != payment.StatusRefunded {
+		t.Fatalf("payment session = %+v, %v; want it refunded", session, err)
+	}
+	if orders := list(h.ok(fiber.MethodGet, "/users/order", buyers[1], nil), "orders"); len(orders) != 0 {
+		t.Fatalf("orders = %v; want none", orders)
+	}
+}
@@ -0,0 +1,151 @@
+package integration
+
+import (
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newSeller signs up an account and joins the seller program with it. It
+// returns the seller's token and id.
+func (h *harness) newSeller(email string, phone string, account int) (string, uint) {
+	h.t.Helper()
+
+	token := str(h.ok(fiber.MethodPost, "/users/become-seller", h.signUp(email, phone), map[string]interface{}{
+		"first_name":        "Sam",
+		"last_name":         "Seller",
+		"phone_number":      phone,
+		"bankAccountNumber": account,
+		"swiftCode":         "BANKUS33",
+		"paymentType":       "bank",
+	}), "token")
+
+	return token, uint(num(h.ok(fiber.MethodGet, "/users/profile", token, nil), "profile", "id"))
+}
+
+// newBuyer signs up an account with a US address and returns its token.
+func (h *harness) newBuyer(email string, phone string) string {
+	h.t.Helper()
+
+	token := h.signUp(email, phone)
+	h.ok(fiber.MethodPost, "/users/profile", token, map[string]interface{}{
+		"first_name": "Bea",
+		"last_name":  "Buyer",
+		"address": map[string]string{
+			"addressLine1": "1 Main St",
+			"city":         "Springfield",
+			"postCode":     "12345",
+			"country":      "US",
+		},
+	})
+
+	return token
+}
+
+// newAdmin signs up a user, appoints them admin the only way there is, in the
+// database, and logs them in again to get a token carrying the role.
+func (h *harness) newAdmin(email string, phone string) string {
+	h.t.Helper()
+
+	h.signUp(email, phone)
+	if err := h.db.Model(&domain.User{}).Where("email = ?", email).Update("user_type", domain.ADMIN).Error; err != nil {
+		h.t.Fatal(err)
+	}
+
+	return str(h.ok(fiber.MethodPost, "/users/login", "", map[string]string{"email": email, "password": "secret123"}), "token")
+}
+
+// listProduct creates a product, in a category of the same name, and
+// returns its id.
+func (h *harness) listProduct(token string, name string, price float64) float64 {
+	h.t.Helper()
+
+	h.ok(fiber.MethodPost, "/seller/categories", token, map[string]interface{}{"name": name})
+	categoryId := h.find(list(h.ok(fiber.MethodGet, "/categories", "", nil), "data"), "name", name)
+
+	h.ok(fiber.MethodPost, "/seller/products", token, map[string]interface{}{
+		"name":        name,
+		"description": "About " + name,
+		"category_id": categoryId,
+		"price":       price,
+		"stock":       10,
+		"weight":      0.5,
+	})
+
+	return h.find(list(h.ok(fiber.MethodGet, "/seller/products", token, nil), "data"), "name", name)
+}
+
+// flatShipping creates a flat rate shipping profile for the US and returns
+// its id.
+func (h *harness) flatShipping(token string, rate float64) float64 {
+	h.t.Helper()
+
+	return num(h.ok(fiber.MethodPost, "/seller/shipping-profiles", token, map[string]interface{}{
+		"name":           "Standard",
+		"type":           "flat",
+		"rate":           rate,
+		"countries":      []string{"US"},
+		"estimated_days": 3,
+	}), "data", "id")
+}
+
+// buy checks out qty of the product with the given shipping profile, paying
+// through the fake provider. It returns the new order's id.
+func (h *harness) buy(token string, sellerId uint, profileId float64, productId float64, qty int) uint {
+	h.t.Helper()
+
+	h.ok(fiber.MethodPost, "/users/cart", token, map[string]interface{}{"product_id": productId, "qty": qty})
+	h.ok(fiber.MethodPost, "/users/cart/shipping", token, map[string]interface{}{
+		"seller_id":           sellerId,
+		"shipping_profile_id": profileId,
+	})
+
+	sessionId := str(h.ok(fiber.MethodGet, "/payment", token, nil), "result", "id")
+	if err := h.payments.Complete(sessionId); err != nil {
+		h.t.Fatal(err)
+	}
+
+	ref := str(h.ok(fiber.MethodPost, "/users/order", token, nil), "order")
+	return uint(h.find(list(h.ok(fiber.MethodGet, "/users/order", token, nil), "orders"), "order_ref_number", ref))
+}
+
+// deliver ships the seller's part of the order and marks it delivered.
+func (h *harness) deliver(token string, orderId uint) {
+	h.t.Helper()
+
+	h.ok(fiber.MethodPatch, fmt.Sprintf("/seller/orders/%d/ship", orderId), token, map[string]string{
+		"carrier":         "UPS",
+		"tracking_number": fmt.Sprintf("1Z%06d", orderId),
+	})
+	h.ok(fiber.MethodPatch, fmt.Sprintf("/seller/orders/%d/deliver", orderId), token, nil)
+}
+
+// expectText fails the test unless a text containing want was sent to phone.
+func (h *harness) expectText(t *testing.T, phone string, want string) {
+	t.Helper()
+
+	for _, m := range h.texts() {
+		if m.Phone == phone && strings.Contains(m.Body, want) {
+			return
+		}
+	}
+	t.Fatalf("no text containing %q was sent to %s", want, phone)
+}
+
+// find returns the id of the object in items whose key equals value.
+func (h *harness) find(items []interface{}, key string, value string) float64 {
+	h.t.Helper()
+
+	for _, item := range items {
+		obj, _ := item.(map[string]interface{})
+		if str(obj, key) == value {
+			return num(obj, "id")
+		}
+	}
+	h.t.Fatalf("no item with %s %q in %v", key, value, items)
+
+	return 0
+}
@@ -0,0 +1,254 @@
+// Package integration exercises the application end to end against a real
+// Postgres database. Point TEST_DATABASE_DSN at a database the tests may
+// create schemas in, for example
+//
+//	TEST_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=ecommerce_test sslmode=disable" go test ./internal/integration/
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-ecommerce-app/configs"
+	"go-ecommerce-app/internal/api"
+	"go-ecommerce-app/internal/container"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/services"
+	"go-ecommerce-app/pkg/notification"
+	"go-ecommerce-app/pkg/payment"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DsnEnv names the variable holding the Postgres DSN the integration tests
+// run against. Without it the tests are skipped locally, and fail on CI so a
+// missing database is never mistaken for a green run.
+const DsnEnv = "TEST_DATABASE_DSN"
+
+// harness runs the whole application, with real repositories, against its
+// own Postgres schema and with in-memory payment and SMS providers.
+type harness struct {
+	t        *testing.T
+	app      *fiber.App
+	db       *gorm.DB
+	payments *payment.FakeClient
+	sms      *notification.FakeClient
+	alerts   services.NotificationService
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	dsn := os.Getenv(DsnEnv)
+	if len(dsn) < 1 {
+		if len(os.Getenv("CI")) > 0 {
+			t.Fatalf("%s must be set to run the integration tests on CI", DsnEnv)
+		}
+		t.Skipf("%s is not set", DsnEnv)
+	}
+	if testing.Short() {
+		t.Skip("integration tests do not run in short mode")
+	}
+
+	db := openSchema(t, dsn)
+
+	config := configs.Defaults()
+	config.Auth.AppSecret = "integration-secret"
+	config.Sms.Enabled = false
+	config.Payout.WebhookSecret = "payout-secret"
+
+	h := &harness{
+		t:        t,
+		db:       db,
+		payments: payment.NewFakeClient("http://localhost/payment/success"),
+		sms:      notification.NewFakeClient(),
+	}
+
+	deps := container.New(config, container.NewRepositories(db, config), h.payments, h.sms)
+	h.alerts = deps.Alerts
+	rh := api.NewApp(deps)
+	rh.Ready.Store(true)
+	h.app = rh.App
+
+	return h
+}
+
+// openSchema creates a schema only this test uses, migrates it and drops it
+// when the test ends, so tests never see each other's data.
+func openSchema(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+
+	gormConfig := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+
+	admin, err := gorm.Open(postgres.Open(dsn), gormConfig)
+	if err != nil {
+		t.Fatalf("connecting to test database failed: %v", err)
+	}
+
+	schema := fmt.Sprintf("it_%d", time.Now().UnixNano())
+	if err = admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("creating schema %s failed: %v", schema, err)
+	}
+
+	db, err := gorm.Open(postgres.Open(withSearchPath(dsn, schema)), gormConfig)
+	if err != nil {
+		t.Fatalf("connecting to schema %s failed: %v", schema, err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		if err := admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Errorf("dropping schema %s failed: %v", schema, err)
+		}
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err = db.AutoMigrate(domain.Models()...); err != nil {
+		t.Fatalf("migrating schema %s failed: %v", schema, err)
+	}
+
+	return db
+}
+
+// withSearchPath points dsn, in URL or keyword/value form, at schema.
+func withSearchPath(dsn string, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	return dsn + " search_path=" + schema
+}
+
+type response struct {
+	Status int
+	Body   map[string]interface{}
+}
+
+// request sends a JSON request as the holder of token, if any.
+func (h *harness) request(method string, path string, token string, body interface{}) response {
+	h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := h.app.Test(req, -1)
+	if err != nil {
+		h.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	out := response{Status: resp.StatusCode, Body: map[string]interface{}{}}
+	_ = json.NewDecoder(resp.Body).Decode(&out.Body)
+
+	return out
+}
+
+// ok sends a request and fails the test unless it succeeds.
+func (h *harness) ok(method string, path string, token string, body interface{}) map[string]interface{} {
+	h.t.Helper()
+
+	resp := h.request(method, path, token, body)
+	if resp.Status != fiber.StatusOK {
+		h.t.Fatalf("%s %s status = %d, body = %v", method, path, resp.Status, resp.Body)
+	}
+
+	return resp.Body
+}
+
+// signUp registers an account and verifies it with the code texted to it.
+// It returns the account's token.
+func (h *harness) signUp(email string, phone string) string {
+	h.t.Helper()
+
+	body := h.ok(fiber.MethodPost, "/users/register", "", map[string]string{
+		"email":    email,
+		"password": "secret123",
+		"phone":    phone,
+	})
+	token := str(body, "token")
+
+	h.ok(fiber.MethodGet, "/users/verify", token, nil)
+	h.ok(fiber.MethodPost, "/users/verify", token, map[string]string{"code": h.lastCode(phone)})
+
+	return token
+}
+
+// texts returns every text sent so far, once the ones sent in the background
+// have gone out.
+func (h *harness) texts() []notification.Message {
+	h.alerts.Wait()
+	return h.sms.Messages()
+}
+
+// lastCode returns the verification code last texted to phone.
+func (h *harness) lastCode(phone string) string {
+	h.t.Helper()
+
+	messages := h.texts()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Phone == phone {
+			return messages[i].Body[strings.LastIndex(messages[i].Body, " ")+1:]
+		}
+	}
+	h.t.Fatalf("no text was sent to %s", phone)
+
+	return ""
+}
+
+// str and num read a field from a decoded JSON object, following a path of
+// keys through nested objects.
+func str(m map[string]interface{}, path ...string) string {
+	v, _ := lookup(m, path).(string)
+	return v
+}
+
+func num(m map[string]interface{}, path ...string) float64 {
+	v, _ := lookup(m, path).(float64)
+	return v
+}
+
+func list(m map[string]interface{}, path ...string) []interface{} {
+	v, _ := lookup(m, path).([]interface{})
+	return v
+}
+
+func lookup(m map[string]interface{}, path []string) interface{} {
+	var v interface{} = m
+	for _, key := range path {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = obj[key]
+	}
+	return v
+}
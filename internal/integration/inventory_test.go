@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/pkg/payment"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestInventoryMovements(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, sellerId := h.newSeller("seller@example.com", "+15550501", 52345678)
+	product := h.listProduct(sellerToken, "Go in Action", 20)
+	profileId := h.flatShipping(sellerToken, 5)
+
+	h.ok(fiber.MethodPatch, fmt.Sprintf("/seller/products/%d", uint(product)), sellerToken, map[string]interface{}{"stock": 7, "note": "water damage"})
+
+	buyer := h.newBuyer("buyer@example.com", "+15550502")
+	orderId := h.buy(buyer, sellerId, profileId, product, 2)
+
+	// Every change is on record and the movements add up to the stock.
+	movementsPath := fmt.Sprintf("/seller/inventory/movements?product_id=%d", uint(product))
+	movements := list(h.ok(fiber.MethodGet, movementsPath, sellerToken, nil), "data")
+	want := []struct {
+		reason   string
+		quantity float64
+		after    float64
+	}{{"initial", 10, 10}, {"manual", -3, 7}, {"order", -2, 5}}
+	if len(movements) != len(want) {
+		t.Fatalf("movements = %v; want %d", movements, len(want))
+	}
+	for i, w := range want {
+		m := movements[i].(map[string]interface{})
+		if str(m, "reason") != w.reason || num(m, "quantity") != w.quantity || num(m, "stock_after") != w.after {
+			t.Fatalf("movement %d = %v; want %s of %v leaving %v", i, m, w.reason, w.quantity, w.after)
+		}
+	}
+	sale := movements[2].(map[string]interface{})
+	if uint(num(sale, "order_id")) != orderId || num(sale, "actor_id") == float64(sellerId) {
+		t.Fatalf("order movement = %v; want order %d made by the buyer", sale, orderId)
+	}
+	if str(movements[1].(map[string]interface{}), "note") != "water damage" {
+		t.Fatalf("manual movement = %v; want the seller's note", movements[1])
+	}
+
+	orders := list(h.ok(fiber.MethodGet, movementsPath+"&reason=order", sellerToken, nil), "data")
+	if len(orders) != 1 {
+		t.Fatalf("order movements = %v; want one", orders)
+	}
+
+	// Stock changed behind the ledger's back is found and put right, and
+	// products listed before movements were kept get an opening balance.
+	if drift := list(h.ok(fiber.MethodGet, "/seller/inventory/drift", sellerToken, nil), "data"); len(drift) != 0 {
+		t.Fatalf("drift = %v; want none", drift)
+	}
+
+	if err := h.db.Model(&domain.Product{}).Where("id = ?", uint(product)).UpdateColumn("stock", 9).Error; err != nil {
+		t.Fatal(err)
+	}
+	legacy := domain.Product{Name: "Legacy", Price: 10, UserId: int(sellerId), Stock: 4}
+	if err := h.db.Create(&legacy).Error; err != nil {
+		t.Fatal(err)
+	}
+	oversold := domain.Product{Name: "Oversold", Price: 10, UserId: int(sellerId), Stock: 2}
+	if err := h.db.Create(&oversold).Error; err != nil {
+		t.Fatal(err)
+	}
+	err := h.db.Create(&domain.InventoryMovement{ProductId: oversold.ID, SellerId: sellerId, Quantity: -3, Reason: domain.InventoryReasonOrder}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if drift := list(h.ok(fiber.MethodGet, "/seller/inventory/drift", sellerToken, nil), "data"); len(drift) != 3 {
+		t.Fatalf("drift = %v; want all three products", drift)
+	}
+	h.ok(fiber.MethodPost, "/seller/inventory/reconcile", sellerToken, nil)
+	if drift := list(h.ok(fiber.MethodGet, "/seller/inventory/drift", sellerToken, nil), "data"); len(drift) != 0 {
+		t.Fatalf("drift after reconciling = %v; want none", drift)
+	}
+
+	stock := func(id uint) float64 {
+		return num(h.ok(fiber.MethodGet, fmt.Sprintf("/products/%d", id), "", nil), "data", "stock")
+	}
+	if stock(uint(product)) != 5 || stock(legacy.ID) != 4 || stock(oversold.ID) != 0 {
+		t.Fatalf("stock after reconciling = %v, %v and %v; want 5 from the ledger, 4 kept and 0 written off",
+			stock(uint(product)), stock(legacy.ID), stock(oversold.ID))
+	}
+
+	// Every correction is on record.
+	corrections := map[uint]struct {
+		quantity float64
+		after    float64
+	}{uint(product): {0, 5}, legacy.ID: {4, 4}, oversold.ID: {3, 0}}
+	reconciled := list(h.ok(fiber.MethodGet, "/seller/inventory/movements?reason=reconciliation", sellerToken, nil), "data")
+	if len(reconciled) != len(corrections) {
+		t.Fatalf("reconciliation movements = %v; want one per product", reconciled)
+	}
+	for _, m := range reconciled {
+		m := m.(map[string]interface{})
+		w := corrections[uint(num(m, "product_id"))]
+		if num(m, "quantity") != w.quantity || num(m, "stock_after") != w.after || len(str(m, "note")) == 0 {
+			t.Fatalf("reconciliation movement = %v; want %v leaving %v with a note", m, w.quantity, w.after)
+		}
+	}
+}
+
+func TestStockImport(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, _ := h.newSeller("seller@example.com", "+15550503", 52345679)
+	book := h.listProduct(sellerToken, "Go in Action", 20)
+	mug := h.listProduct(sellerToken, "Gopher Mug", 8)
+	otherToken, _ := h.newSeller("other@example.com", "+15550504", 52345680)
+	foreign := h.listProduct(otherToken, "Rust in Action", 30)
+
+	h.ok(fiber.MethodPost, "/seller/inventory/import", sellerToken, map[string]interface{}{
+		"note":  "warehouse count",
+		"items": []map[string]interface{}{{"product_id": book, "stock": 3}, {"product_id": mug, "stock": 12}},
+	})
+
+	imports := list(h.ok(fiber.MethodGet, "/seller/inventory/movements?reason=import", sellerToken, nil), "data")
+	want := map[uint]float64{uint(book): -7, uint(mug): 2}
+	if len(imports) != len(want) {
+		t.Fatalf("import movements = %v; want one per product", imports)
+	}
+	for _, m := range imports {
+		m := m.(map[string]interface{})
+		if num(m, "quantity") != want[uint(num(m, "product_id"))] || str(m, "note") != "warehouse count" {
+			t.Fatalf("import movement = %v; want %v with the note", m, want[uint(num(m, "product_id"))])
+		}
+	}
+
+	// An import touching another seller's product changes nothing.
+	resp := h.request(fiber.MethodPost, "/seller/inventory/import", sellerToken, map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": book, "stock": 1}, {"product_id": foreign, "stock": 0}},
+	})
+	if resp.Status != fiber.StatusForbidden {
+		t.Fatalf("importing another seller's stock status = %d; want %d", resp.Status, fiber.StatusForbidden)
+	}
+	if stock := num(h.ok(fiber.MethodGet, fmt.Sprintf("/products/%d", uint(book)), "", nil), "data", "stock"); stock != 3 {
+		t.Fatalf("stock after a rejected import = %v; want 3", stock)
+	}
+}
+
+func TestCancelledOrdersGoBackInStock(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, sellerId := h.newSeller("seller@example.com", "+15550505", 52345681)
+	book := h.listProduct(sellerToken, "Go in Action", 20)
+	profileId := h.flatShipping(sellerToken, 5)
+	buyer := h.newBuyer("buyer@example.com", "+15550506")
+
+	// A shipped order can no longer be cancelled.
+	shipped := h.buy(buyer, sellerId, profileId, book, 1)
+	h.ok(fiber.MethodPatch, fmt.Sprintf("/seller/orders/%d/ship", shipped), sellerToken, map[string]string{
+		"carrier":         "UPS",
+		"tracking_number": "1Z000001",
+	})
+	if resp := h.request(fiber.MethodPost, fmt.Sprintf("/users/order/%d/cancel", shipped), buyer, nil); resp.Status != fiber.StatusConflict {
+		t.Fatalf("cancelling a shipped order status = %d; want %d", resp.Status, fiber.StatusConflict)
+	}
+
+	orderId := h.buy(buyer, sellerId, profileId, book, 2)
+	balance := num(h.ok(fiber.MethodGet, "/seller/balance", sellerToken, nil), "data", "balance")
+	cancel := fmt.Sprintf("/users/order/%d/cancel", orderId)
+	h.ok(fiber.MethodPost, cancel, buyer, nil)
+	if resp := h.request(fiber.MethodPost, cancel, buyer, nil); resp.Status != fiber.StatusConflict {
+		t.Fatalf("cancelling twice status = %d; want %d", resp.Status, fiber.StatusConflict)
+	}
+
+	// The paid items come back as a refund and the seller is no longer owed
+	// for them.
+	refunds := list(h.ok(fiber.MethodGet, "/seller/inventory/movements?reason=refund", sellerToken, nil), "data")
+	if len(refunds) != 1 {
+		t.Fatalf("refund movements = %v; want one", refunds)
+	}
+	if m := refunds[0].(map[string]interface{}); num(m, "quantity") != 2 || num(m, "stock_after") != 9 || uint(num(m, "order_id")) != orderId {
+		t.Fatalf("refund movement = %v; want 2 of order %d back, leaving 9", m, orderId)
+	}
+	after := num(h.ok(fiber.MethodGet, "/seller/balance", sellerToken, nil), "data", "balance")
+	if after <= 0 || after >= balance {
+		t.Fatalf("balance after cancelling = %v; want less than %v, with the shipped order still owed", after, balance)
+	}
+
+	order := h.ok(fiber.MethodGet, fmt.Sprintf("/users/order/%d", orderId), buyer, nil)
+	if str(order, "order", "status") != domain.OrderStatusCancelled {
+		t.Fatalf("order = %v; want it cancelled", order["order"])
+	}
+	session, err := h.payments.GetPaymentStatus(context.Background(), str(order, "order", "payment_id"))
+	if err != nil || session.Status != payment.StatusRefunded {
+		t.Fatalf("payment session = %+v, %v; want it refunded", session, err)
+	}
+}
@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/repository"
+	"go-ecommerce-app/internal/services"
+	"go-ecommerce-app/pkg/payment"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPayoutSettlement(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, sellerId := h.newSeller("seller@example.com", "+15550701", 72345678)
+	book := h.listProduct(sellerToken, "Go in Action", 20)
+	profileId := h.flatShipping(sellerToken, 5)
+	buyer := h.newBuyer("buyer@example.com", "+15550702")
+	h.buy(buyer, sellerId, profileId, book, 1)
+
+	ledger := services.LedgerService{Repo: repository.NewLedgerRepository(h.db)}
+	payout := func() uint {
+		batch, err := ledger.RunPayouts(context.Background())
+		if err != nil || batch == nil || batch.Count != 1 {
+			t.Fatalf("RunPayouts() = %+v, %v; want one payout", batch, err)
+		}
+		return batch.Payouts[0].ID
+	}
+	balance := func() map[string]interface{} {
+		return h.ok(fiber.MethodGet, "/seller/balance", sellerToken, nil)["data"].(map[string]interface{})
+	}
+	settle := func(id uint, status string, secret string) int {
+		body := strings.NewReader(fmt.Sprintf(`{"status":%q}`, status))
+		req := httptest.NewRequest(fiber.MethodPost, fmt.Sprintf("/payouts/%d/settlement", id), body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Payout-Secret", secret)
+		resp, err := h.app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	earned := num(balance(), "balance")
+	first := payout()
+	if b := balance(); num(b, "balance") != 0 || num(b, "pending_payouts") != earned {
+		t.Fatalf("balance after payout run = %v; want %v pending", b, earned)
+	}
+
+	if status := settle(first, "paid", "wrong"); status != fiber.StatusUnauthorized {
+		t.Fatalf("settlement with a wrong secret status = %d; want 401", status)
+	}
+	if status := settle(first, "sent", "payout-secret"); status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("settlement as sent status = %d; want 422", status)
+	}
+
+	// a rejected transfer goes back to the seller's balance and is paid again
+	if status := settle(first, "failed", "payout-secret"); status != fiber.StatusOK {
+		t.Fatalf("failed settlement status = %d; want 200", status)
+	}
+	if b := balance(); num(b, "balance") != earned || num(b, "pending_payouts") != 0 {
+		t.Fatalf("balance after failed payout = %v; want %v back", b, earned)
+	}
+
+	second := payout()
+	if status := settle(second, "paid", "payout-secret"); status != fiber.StatusOK {
+		t.Fatalf("paid settlement status = %d; want 200", status)
+	}
+	paid := h.ok(fiber.MethodGet, "/seller/payouts", sellerToken, nil)
+	for _, p := range list(paid, "data") {
+		p := p.(map[string]interface{})
+		if uint(num(p, "id")) == second && (str(p, "status") != "paid" || p["paid_at"] == nil) {
+			t.Fatalf("settled payout = %v; want it paid with a date", p)
+		}
+	}
+	if b := balance(); num(b, "balance") != 0 || num(b, "paid_out") != earned {
+		t.Fatalf("balance after paid payout = %v; want %v paid out", b, earned)
+	}
+	if status := settle(second, "failed", "payout-secret"); status != fiber.StatusConflict {
+		t.Fatalf("settling twice status = %d; want 409", status)
+	}
+
+	// both settlements cleared what the payout runs put in transit
+	var transit float64
+	err := h.db.Raw("SELECT COALESCE(SUM(credit - debit), 0) FROM ledger_entries WHERE account = ?", "payouts_in_transit").
+		Scan(&transit).Error
+	if err != nil || transit != 0 {
+		t.Fatalf("payouts in transit = %v, %v; want 0", transit, err)
+	}
+}
+
+func TestCashOnDeliveryIsPaidOutOnceCollected(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, sellerId := h.newSeller("seller@example.com", "+15550703", 72345679)
+	book := h.listProduct(sellerToken, "Go in Action", 20)
+	profileId := h.flatShipping(sellerToken, 5)
+	admin := h.newAdmin("admin@example.com", "+15550705")
+
+	buyer := h.newBuyer("buyer@example.com", "+15550704")
+	h.ok(fiber.MethodPost, "/users/cart", buyer, map[string]interface{}{"product_id": book, "qty": 1})
+	h.ok(fiber.MethodPost, "/users/cart/shipping", buyer, map[string]interface{}{
+		"seller_id":           sellerId,
+		"shipping_profile_id": profileId,
+	})
+	h.ok(fiber.MethodGet, "/payment", buyer, nil)
+
+	// The buyer pays on delivery. The fake provider has no such option, so the
+	// payment is switched over in the database.
+	err := h.db.Exec("UPDATE payments SET provider = ?, payment_id = 'cod_' || order_id, payment_url = '', status = ?",
+		payment.ProviderCashOnDelivery, domain.PaymentStatusPending).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := str(h.ok(fiber.MethodPost, "/users/order", buyer, nil), "order")
+	orderId := uint(h.find(list(h.ok(fiber.MethodGet, "/users/order", buyer, nil), "orders"), "order_ref_number", ref))
+
+	// Nothing is owed to the seller while the cash is still to be collected.
+	ledger := services.LedgerService{Repo: repository.NewLedgerRepository(h.db)}
+	if b := num(h.ok(fiber.MethodGet, "/seller/balance", sellerToken, nil), "data", "balance"); b != 0 {
+		t.Fatalf("balance before collection = %v; want 0", b)
+	}
+	if batch, err := ledger.RunPayouts(context.Background()); err != nil || batch != nil {
+		t.Fatalf("RunPayouts() before collection = %+v, %v; want nothing to pay", batch, err)
+	}
+
+	collect := fmt.Sprintf("/admin/orders/%d/collect", orderId)
+	if resp := h.request(fiber.MethodPost, collect, sellerToken, nil); resp.Status != fiber.StatusForbidden {
+		t.Fatalf("seller collecting status = %d; want %d", resp.Status, fiber.StatusForbidden)
+	}
+	h.ok(fiber.MethodPost, collect, admin, nil)
+	if resp := h.request(fiber.MethodPost, collect, admin, nil); resp.Status != fiber.StatusConflict {
+		t.Fatalf("collecting twice status = %d; want %d", resp.Status, fiber.StatusConflict)
+	}
+
+	if b := num(h.ok(fiber.MethodGet, "/seller/balance", sellerToken, nil), "data", "balance"); b <= 0 || b >= 25 {
+		t.Fatalf("balance after collection = %v; want the order total less commission", b)
+	}
+	if batch, err := ledger.RunPayouts(context.Background()); err != nil || batch == nil || batch.Count != 1 {
+		t.Fatalf("RunPayouts() after collection = %+v, %v; want one payout", batch, err)
+	}
+}
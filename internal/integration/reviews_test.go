@@ -0,0 +1,127 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestReviewsFromDeliveredOrders(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, sellerId := h.newSeller("seller@example.com", "+15550201", 22345678)
+	book := h.listProduct(sellerToken, "Go in Action", 25)
+	other := h.listProduct(sellerToken, "Rust in Action", 30)
+	profileId := h.flatShipping(sellerToken, 5)
+
+	bookPath := fmt.Sprintf("/products/%d", uint(book))
+	review := map[string]interface{}{
+		"rating": 4,
+		"body":   "Clear and practical",
+		"images": []string{"https://example.com/cover.jpg"},
+	}
+
+	// Buyers can review only once their order arrived.
+	first := h.newBuyer("first@example.com", "+15550202")
+	orderId := h.buy(first, sellerId, profileId, book, 1)
+	if resp := h.request(fiber.MethodPost, bookPath+"/reviews", first, review); resp.Status != fiber.StatusForbidden {
+		t.Fatalf("review before delivery status = %d; want %d", resp.Status, fiber.StatusForbidden)
+	}
+
+	h.deliver(sellerToken, orderId)
+	if resp := h.request(fiber.MethodPost, bookPath+"/reviews", first, map[string]interface{}{"rating": 6}); resp.Status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("review rated 6 status = %d; want %d", resp.Status, fiber.StatusUnprocessableEntity)
+	}
+	firstReview := num(h.ok(fiber.MethodPost, bookPath+"/reviews", first, review), "data", "id")
+	if resp := h.request(fiber.MethodPost, bookPath+"/reviews", first, review); resp.Status != fiber.StatusConflict {
+		t.Fatalf("second review status = %d; want %d", resp.Status, fiber.StatusConflict)
+	}
+
+	second := h.newBuyer("second@example.com", "+15550203")
+	h.deliver(sellerToken, h.buy(second, sellerId, profileId, book, 1))
+	secondReview := num(h.ok(fiber.MethodPost, bookPath+"/reviews", second, map[string]interface{}{"rating": 5}), "data", "id")
+	if resp := h.request(fiber.MethodPost, fmt.Sprintf("/products/%d/reviews", uint(other)), second, review); resp.Status != fiber.StatusForbidden {
+		t.Fatalf("review of a product not bought status = %d; want %d", resp.Status, fiber.StatusForbidden)
+	}
+
+	// Ratings are aggregated on the product and can be sorted on.
+	expectRating := func(average float64, count float64) {
+		t.Helper()
+		product := h.ok(fiber.MethodGet, bookPath, "", nil)
+		if num(product, "data", "rating_average") != average || num(product, "data", "rating_count") != count {
+			t.Fatalf("product = %v; want rating %v from %v reviews", product["data"], average, count)
+		}
+	}
+	expectRating(4.5, 2)
+
+	products := list(h.ok(fiber.MethodGet, "/products?sort=-rating", "", nil), "data")
+	if len(products) != 2 || num(products[0].(map[string]interface{}), "id") != book {
+		t.Fatalf("products by rating = %v; want the reviewed book first", products)
+	}
+
+	// The seller answers publicly.
+	if resp := h.request(fiber.MethodPost, fmt.Sprintf("/seller/reviews/%d/reply", uint(firstReview)), first, map[string]string{"reply": "Thanks"}); resp.Status != fiber.StatusForbidden {
+		t.Fatalf("reply as buyer status = %d; want %d", resp.Status, fiber.StatusForbidden)
+	}
+	h.ok(fiber.MethodPost, fmt.Sprintf("/seller/reviews/%d/reply", uint(firstReview)), sellerToken, map[string]string{"reply": "Thanks for reading"})
+	if reviews := list(h.ok(fiber.MethodGet, "/seller/reviews", sellerToken, nil), "data"); len(reviews) != 2 {
+		t.Fatalf("seller reviews = %v; want both", reviews)
+	}
+
+	reviews := list(h.ok(fiber.MethodGet, bookPath+"/reviews?sort=rating", "", nil), "data")
+	if len(reviews) != 2 {
+		t.Fatalf("reviews = %v; want both", reviews)
+	}
+	published := reviews[0].(map[string]interface{})
+	if str(published, "reply") != "Thanks for reading" || len(list(published, "images")) != 1 {
+		t.Fatalf("review = %v; want the seller's reply and one image", published)
+	}
+
+	// Other buyers can flag a review once, but not their own.
+	flagPath := fmt.Sprintf("/reviews/%d/flag", uint(secondReview))
+	h.ok(fiber.MethodPost, flagPath, first, map[string]string{"reason": "spam"})
+	if resp := h.request(fiber.MethodPost, flagPath, first, map[string]string{"reason": "spam"}); resp.Status != fiber.StatusConflict {
+		t.Fatalf("second flag status = %d; want %d", resp.Status, fiber.StatusConflict)
+	}
+	if resp := h.request(fiber.MethodPost, flagPath, second, map[string]string{"reason": "spam"}); resp.Status != fiber.StatusForbidden {
+		t.Fatalf("flag of own review status = %d; want %d", resp.Status, fiber.StatusForbidden)
+	}
+
+	// Enough flags hide the review until a moderator restores it.
+	for i, phone := range []string{"+15550204", "+15550205"} {
+		h.ok(fiber.MethodPost, flagPath, h.newBuyer(fmt.Sprintf("flagger%d@example.com", i), phone), map[string]string{"reason": "spam"})
+	}
+	expectRating(4, 1)
+
+	admin := h.newAdmin("admin@example.com", "+15550206")
+	if resp := h.request(fiber.MethodGet, "/admin/reviews/flagged", sellerToken, nil); resp.Status != fiber.StatusForbidden {
+		t.Fatalf("flagged reviews as seller status = %d; want %d", resp.Status, fiber.StatusForbidden)
+	}
+	flagged := list(h.ok(fiber.MethodGet, "/admin/reviews/flagged", admin, nil), "data")
+	if len(flagged) != 1 || str(flagged[0].(map[string]interface{}), "status") != "hidden" {
+		t.Fatalf("flagged reviews = %v; want the hidden review", flagged)
+	}
+
+	restored := h.ok(fiber.MethodPost, fmt.Sprintf("/admin/reviews/%d/restore", uint(secondReview)), admin, nil)
+	if str(restored, "data", "status") != "published" || num(restored, "data", "flag_count") != 0 {
+		t.Fatalf("restored review = %v; want it published without flags", restored["data"])
+	}
+	expectRating(4.5, 2)
+	if flagged := list(h.ok(fiber.MethodGet, "/admin/reviews/flagged", admin, nil), "data"); len(flagged) != 0 {
+		t.Fatalf("flagged reviews after restoring = %v; want none", flagged)
+	}
+	// the flags are gone, so the same buyers may flag it again
+	h.ok(fiber.MethodPost, flagPath, first, map[string]string{"reason": "spam"})
+
+	// Only the author edits or removes a review, and the rating follows.
+	firstPath := fmt.Sprintf("/reviews/%d", uint(firstReview))
+	if resp := h.request(fiber.MethodPut, firstPath, second, map[string]interface{}{"rating": 1}); resp.Status != fiber.StatusForbidden {
+		t.Fatalf("edit of another's review status = %d; want %d", resp.Status, fiber.StatusForbidden)
+	}
+	h.ok(fiber.MethodPut, firstPath, first, map[string]interface{}{"rating": 2, "body": "Dated"})
+	expectRating(3.5, 2)
+
+	h.ok(fiber.MethodDelete, firstPath, first, nil)
+	expectRating(5, 1)
+}
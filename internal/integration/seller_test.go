@@ -0,0 +1,37 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestBecomeSellerRollsBackWhenBankAccountFails(t *testing.T) {
+	h := newHarness(t)
+
+	seller := map[string]interface{}{
+		"first_name":        "Sam",
+		"last_name":         "Seller",
+		"bankAccountNumber": 12345678,
+		"swiftCode":         "BANKUS33",
+		"paymentType":       "bank",
+	}
+
+	first := h.signUp("first@example.com", "+15550103")
+	h.ok(fiber.MethodPost, "/users/become-seller", first, seller)
+
+	// Bank account numbers are unique, so the second account cannot be
+	// created and the user must not be left half upgraded.
+	second := h.signUp("second@example.com", "+15550104")
+	if resp := h.request(fiber.MethodPost, "/users/become-seller", second, seller); resp.Status == fiber.StatusOK {
+		t.Fatalf("become-seller with a used bank account succeeded: %v", resp.Body)
+	}
+
+	profile := h.ok(fiber.MethodGet, "/users/profile", second, nil)
+	if userType := str(profile, "profile", "user_type"); userType != "buyer" {
+		t.Fatalf("user type after failed upgrade = %q; want buyer", userType)
+	}
+	if name := str(profile, "profile", "first_name"); len(name) > 0 {
+		t.Fatalf("first name after failed upgrade = %q; want it unchanged", name)
+	}
+}
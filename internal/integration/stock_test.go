@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"go-ecommerce-app/pkg/payment"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestStockAlerts(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, sellerId := h.newSeller("seller@example.com", "+15550401", 42345678)
+	product := h.listProduct(sellerToken, "Go in Action", 20)
+	profileId := h.flatShipping(sellerToken, 5)
+	productPath := fmt.Sprintf("/seller/products/%d", uint(product))
+
+	h.ok(fiber.MethodPatch, productPath, sellerToken, map[string]interface{}{"stock": 3})
+	h.ok(fiber.MethodPut, productPath, sellerToken, map[string]interface{}{"low_stock_threshold": 2})
+
+	stock := func() float64 {
+		return num(h.ok(fiber.MethodGet, fmt.Sprintf("/products/%d", uint(product)), "", nil), "data", "stock")
+	}
+	lowStockTexts := func() int {
+		n := 0
+		for _, m := range h.texts() {
+			if m.Phone == "+15550401" && strings.HasPrefix(m.Body, "Low stock") {
+				n++
+			}
+		}
+		return n
+	}
+
+	// Orders take stock and the seller hears once it falls below the
+	// threshold.
+	buyer := h.newBuyer("buyer@example.com", "+15550402")
+	h.buy(buyer, sellerId, profileId, product, 1)
+	if stock() != 2 || lowStockTexts() != 0 {
+		t.Fatalf("after the first order stock = %v with %d alerts; want 2 and none", stock(), lowStockTexts())
+	}
+	h.buy(buyer, sellerId, profileId, product, 1)
+	if stock() != 1 || lowStockTexts() != 1 {
+		t.Fatalf("after the second order stock = %v with %d alerts; want 1 and one", stock(), lowStockTexts())
+	}
+
+	// Buyers subscribe to products that are out of stock.
+	alertPath := fmt.Sprintf("/products/%d/stock-alert", uint(product))
+	if resp := h.request(fiber.MethodPost, alertPath, buyer, nil); resp.Status != fiber.StatusConflict {
+		t.Fatalf("alert on an in stock product status = %d; want %d", resp.Status, fiber.StatusConflict)
+	}
+
+	h.ok(fiber.MethodPatch, productPath, sellerToken, map[string]interface{}{"stock": 0})
+	h.ok(fiber.MethodPost, alertPath, buyer, nil)
+	if resp := h.request(fiber.MethodPost, alertPath, buyer, nil); resp.Status != fiber.StatusConflict {
+		t.Fatalf("second alert status = %d; want %d", resp.Status, fiber.StatusConflict)
+	}
+	if alerts := list(h.ok(fiber.MethodGet, "/users/stock-alerts", buyer, nil), "data"); len(alerts) != 1 {
+		t.Fatalf("stock alerts = %v; want one", alerts)
+	}
+
+	// a buyer who also wishlisted the product hears about the restock once
+	books := h.ok(fiber.MethodPost, "/wishlists", buyer, map[string]interface{}{"name": "Books"})
+	h.ok(fiber.MethodPost, fmt.Sprintf("/wishlists/%d/items", uint(num(books, "data", "id"))), buyer, map[string]interface{}{"product_id": product})
+
+	h.ok(fiber.MethodPatch, productPath, sellerToken, map[string]interface{}{"stock": 5})
+	restockTexts := 0
+	for _, m := range h.texts() {
+		if m.Phone == "+15550402" && strings.HasPrefix(m.Body, "Back in stock") {
+			restockTexts++
+		}
+	}
+	if restockTexts != 1 {
+		t.Fatalf("buyer got %d back in stock texts; want one", restockTexts)
+	}
+	if alerts := list(h.ok(fiber.MethodGet, "/users/stock-alerts", buyer, nil), "data"); len(alerts) != 0 {
+		t.Fatalf("stock alerts after restock = %v; want them used up", alerts)
+	}
+}
+
+func TestSoldOutAfterPaymentIsRefunded(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, sellerId := h.newSeller("seller@example.com", "+15550411", 42345679)
+	product := h.listProduct(sellerToken, "Go in Action", 20)
+	profileId := h.flatShipping(sellerToken, 5)
+
+	buyer := h.newBuyer("buyer@example.com", "+15550412")
+	h.ok(fiber.MethodPost, "/users/cart", buyer, map[string]interface{}{"product_id": product, "qty": 2})
+	h.ok(fiber.MethodPost, "/users/cart/shipping", buyer, map[string]interface{}{
+		"seller_id":           sellerId,
+		"shipping_profile_id": profileId,
+	})
+	sessionId := str(h.ok(fiber.MethodGet, "/payment", buyer, nil), "result", "id")
+	if err := h.payments.Complete(sessionId); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stock runs short between paying and ordering.
+	h.ok(fiber.MethodPatch, fmt.Sprintf("/seller/products/%d", uint(product)), sellerToken, map[string]interface{}{"stock": 1})
+
+	resp := h.request(fiber.MethodPost, "/users/order", buyer, nil)
+	if resp.Status != fiber.StatusConflict || !strings.Contains(str(resp.Body, "error", "message"), "refunded") {
+		t.Fatalf("sold out order status = %d, body = %v; want a conflict saying the payment was refunded", resp.Status, resp.Body)
+	}
+
+	session, err := h.payments.GetPaymentStatus(context.Background(), sessionId)
+	if err != nil || session.Status != payment.StatusRefunded {
+		t.Fatalf("payment session = %+v, %v; want it refunded", session, err)
+	}
+	if orders := list(h.ok(fiber.MethodGet, "/users/order", buyer, nil), "orders"); len(orders) != 0 {
+		t.Fatalf("orders = %v; want none", orders)
+	}
+}
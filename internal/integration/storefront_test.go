@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSellerStorefront(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, sellerId := h.newSeller("seller@example.com", "+15550601", 62345678)
+	book := h.listProduct(sellerToken, "Go in Action", 25)
+	other := h.listProduct(sellerToken, "Rust in Action", 30)
+	profileId := h.flatShipping(sellerToken, 5)
+
+	if resp := h.request(fiber.MethodGet, "/seller/storefront", sellerToken, nil); resp.Status != fiber.StatusNotFound {
+		t.Fatalf("storefront before setup status = %d; want %d", resp.Status, fiber.StatusNotFound)
+	}
+
+	// The slug is made from the store name unless the seller picks one.
+	store := h.ok(fiber.MethodPut, "/seller/storefront", sellerToken, map[string]interface{}{
+		"store_name":    "Sam's Books & More",
+		"logo_url":      "https://cdn.example.com/logo.png",
+		"description":   "Programming books",
+		"return_policy": "30 days, no questions asked",
+		"contact_email": "sam@example.com",
+	})
+	if slug := str(store, "data", "slug"); slug != "sam-s-books-more" {
+		t.Fatalf("slug = %q; want sam-s-books-more", slug)
+	}
+
+	otherToken, _ := h.newSeller("other@example.com", "+15550602", 62345679)
+	for _, tt := range []struct {
+		body   map[string]interface{}
+		status int
+	}{
+		{map[string]interface{}{"store_name": "Copycat", "slug": "sam-s-books-more"}, fiber.StatusConflict},
+		{map[string]interface{}{"store_name": "Numbers", "slug": "12345"}, fiber.StatusUnprocessableEntity},
+		{map[string]interface{}{"store_name": "Logo", "logo_url": "ftp://example.com/logo.png"}, fiber.StatusUnprocessableEntity},
+		{map[string]interface{}{"store_name": ""}, fiber.StatusUnprocessableEntity},
+	} {
+		if resp := h.request(fiber.MethodPut, "/seller/storefront", otherToken, tt.body); resp.Status != tt.status {
+			t.Fatalf("storefront %v status = %d, body = %v; want %d", tt.body, resp.Status, resp.Body, tt.status)
+		}
+	}
+
+	// Buyers rate the products, and so the seller.
+	for i, product := range []float64{book, other} {
+		buyer := h.newBuyer(fmt.Sprintf("buyer%d@example.com", i), fmt.Sprintf("+1555061%d", i))
+		h.deliver(sellerToken, h.buy(buyer, sellerId, profileId, product, 1))
+		h.ok(fiber.MethodPost, fmt.Sprintf("/products/%d/reviews", uint(product)), buyer, map[string]interface{}{"rating": 4 + i})
+	}
+
+	// The public page is reachable by slug or by seller id.
+	for _, key := range []string{"sam-s-books-more", fmt.Sprint(sellerId)} {
+		page := h.ok(fiber.MethodGet, "/sellers/"+key, "", nil)
+		if str(page, "data", "store", "store_name") != "Sam's Books & More" || str(page, "data", "store", "return_policy") == "" {
+			t.Fatalf("storefront %s = %v; want Sam's store", key, page["data"])
+		}
+		if num(page, "data", "store", "rating_average") != 4.5 || num(page, "data", "store", "rating_count") != 2 {
+			t.Fatalf("storefront %s rating = %v; want 4.5 from 2 reviews", key, page["data"])
+		}
+		if products := list(page, "data", "products"); len(products) != 2 || num(page, "meta", "total") != 2 {
+			t.Fatalf("storefront %s products = %v; want both", key, products)
+		}
+	}
+
+	if resp := h.request(fiber.MethodGet, "/sellers/nobody", "", nil); resp.Status != fiber.StatusNotFound {
+		t.Fatalf("unknown seller status = %d; want %d", resp.Status, fiber.StatusNotFound)
+	}
+}
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestWishlists(t *testing.T) {
+	h := newHarness(t)
+
+	sellerToken, _ := h.newSeller("seller@example.com", "+15550301", 32345678)
+	product := h.listProduct(sellerToken, "Go in Action", 20)
+	productPath := fmt.Sprintf("/seller/products/%d", uint(product))
+
+	buyer := h.newBuyer("buyer@example.com", "+15550302")
+	gifts := h.ok(fiber.MethodPost, "/wishlists", buyer, map[string]interface{}{"name": "Gifts"})
+	listPath := fmt.Sprintf("/wishlists/%d", uint(num(gifts, "data", "id")))
+	sharedPath := "/wishlists/shared/" + str(gifts, "data", "share_token")
+
+	h.ok(fiber.MethodPost, listPath+"/items", buyer, map[string]interface{}{"product_id": product})
+	if resp := h.request(fiber.MethodPost, listPath+"/items", buyer, map[string]interface{}{"product_id": product}); resp.Status != fiber.StatusConflict {
+		t.Fatalf("adding twice status = %d; want %d", resp.Status, fiber.StatusConflict)
+	}
+
+	// Lists are private to their owner until shared.
+	other := h.newBuyer("other@example.com", "+15550303")
+	if resp := h.request(fiber.MethodGet, listPath, other, nil); resp.Status != fiber.StatusNotFound {
+		t.Fatalf("another user's wishlist status = %d; want %d", resp.Status, fiber.StatusNotFound)
+	}
+	if resp := h.request(fiber.MethodGet, sharedPath, "", nil); resp.Status != fiber.StatusNotFound {
+		t.Fatalf("private shared link status = %d; want %d", resp.Status, fiber.StatusNotFound)
+	}
+
+	h.ok(fiber.MethodPut, listPath, buyer, map[string]interface{}{"public": true})
+	items := wishlistItems(h.ok(fiber.MethodGet, sharedPath, "", nil))
+	if len(items) != 1 || num(items[0], "current_price") != 20 || items[0]["in_stock"] != true {
+		t.Fatalf("shared wishlist items = %v; want the product in stock at 20", items)
+	}
+
+	// Watchers hear about price drops and restocks.
+	h.ok(fiber.MethodPut, productPath, sellerToken, map[string]interface{}{"price": 15})
+	h.expectText(t, "+15550302", "Price drop")
+
+	h.ok(fiber.MethodPatch, productPath, sellerToken, map[string]interface{}{"stock": 0})
+	h.ok(fiber.MethodPatch, productPath, sellerToken, map[string]interface{}{"stock": 5})
+	h.expectText(t, "+15550302", "Back in stock")
+	for _, m := range h.texts() {
+		if m.Phone == "+15550303" && !strings.Contains(m.Body, "verification") {
+			t.Fatalf("user without the product on a wishlist was texted %q", m.Body)
+		}
+	}
+
+	// Items move between the wishlist and the cart.
+	cart := h.ok(fiber.MethodPost, fmt.Sprintf("%s/items/%d/move-to-cart?qty=2", listPath, uint(product)), buyer, nil)
+	if lines := list(cart, "data"); len(lines) != 1 || num(lines[0].(map[string]interface{}), "qty") != 2 {
+		t.Fatalf("cart = %v; want two of the product", cart["data"])
+	}
+	if items := wishlistItems(h.ok(fiber.MethodGet, listPath, buyer, nil)); len(items) != 0 {
+		t.Fatalf("wishlist after moving to cart = %v; want it empty", items)
+	}
+
+	saved := h.ok(fiber.MethodPost, fmt.Sprintf("/users/cart/%d/save-for-later", uint(product)), buyer, nil)
+	if str(saved, "data", "name") != "Saved for later" || len(wishlistItems(saved)) != 1 {
+		t.Fatalf("saved for later = %v; want the product on the default list", saved["data"])
+	}
+	if lines := list(h.ok(fiber.MethodGet, "/users/cart", buyer, nil), "cart"); len(lines) != 0 {
+		t.Fatalf("cart after saving for later = %v; want it empty", lines)
+	}
+	if lists := list(h.ok(fiber.MethodGet, "/wishlists", buyer, nil), "data"); len(lists) != 2 {
+		t.Fatalf("wishlists = %v; want Gifts and Saved for later", lists)
+	}
+}
+
+// wishlistItems returns the items of the wishlist in a response.
+func wishlistItems(body map[string]interface{}) []map[string]interface{} {
+	var items []map[string]interface{}
+	for _, item := range list(body, "data", "items") {
+		items = append(items, item.(map[string]interface{}))
+	}
+	return items
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AnalyticsRepository aggregates a seller's order items placed in [from, to).
+type AnalyticsRepository interface {
+	// FindSalesSummary fills the counts and totals of the summary, leaving
+	// the rates derived from them to the caller.
+	FindSalesSummary(ctx context.Context, sellerId uint, from time.Time, to time.Time) (*dto.SalesSummary, error)
+	// FindSalesSeries groups the sales by the given date_trunc bucket, such
+	// as day, week or month. Periods without sales are left out.
+	FindSalesSeries(ctx context.Context, sellerId uint, from time.Time, to time.Time, bucket string) ([]dto.SalesBucket, error)
+	FindTopProducts(ctx context.Context, sellerId uint, from time.Time, to time.Time, limit int) ([]dto.TopProduct, error)
+}
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+func (r *analyticsRepository) FindSalesSummary(ctx context.Context, sellerId uint, from time.Time, to time.Time) (*dto.SalesSummary, error) {
+	var summary dto.SalesSummary
+	err := r.sales(ctx, sellerId, from, to).
+		Select("COUNT(DISTINCT order_items.order_id) AS orders, "+
+			"COALESCE(SUM(order_items.qty), 0) AS units, "+
+			"COALESCE(SUM(order_items.price * order_items.qty), 0) AS revenue, "+
+			"COUNT(DISTINCT orders.user_id) AS buyers, "+
+			"COUNT(DISTINCT CASE WHEN payments.id IS NOT NULL THEN order_items.order_id END) AS refunded_orders, "+
+			"COALESCE(SUM(CASE WHEN payments.id IS NOT NULL THEN order_items.price * order_items.qty END), 0) AS refunded_revenue").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Joins("LEFT JOIN payments ON payments.payment_id = orders.payment_id AND payments.status = ?", domain.PaymentStatusRefunded).
+		Scan(&summary).Error
+	if err != nil {
+		return nil, errors.New("failed to summarise sales")
+	}
+
+	// ordered cart lines are removed at checkout, so the shoppers who did not
+	// buy are the ones whose cart still holds the seller's products
+	buyers := r.sales(ctx, sellerId, from, to).
+		Select("orders.user_id").
+		Joins("JOIN orders ON orders.id = order_items.order_id")
+	err = r.db.WithContext(ctx).Model(&domain.Cart{}).
+		Select("COUNT(DISTINCT CASE WHEN user_id > 0 THEN 'user:' || CAST(user_id AS TEXT) ELSE cart_token END)").
+		Where("seller_id = ? AND created_at >= ? AND created_at < ?", sellerId, from, to).
+		Where("user_id NOT IN (?)", buyers).
+		Scan(&summary.Shoppers).Error
+	if err != nil {
+		return nil, errors.New("failed to count shoppers")
+	}
+	summary.Shoppers += summary.Buyers
+
+	return &summary, nil
+}
+
+func (r *analyticsRepository) FindSalesSeries(ctx context.Context, sellerId uint, from time.Time, to time.Time, bucket string) ([]dto.SalesBucket, error) {
+	var series []dto.SalesBucket
+	err := r.sales(ctx, sellerId, from, to).
+		Select("date_trunc(?, order_items.created_at) AS period, "+
+			"COUNT(DISTINCT order_items.order_id) AS orders, "+
+			"SUM(order_items.qty) AS units, "+
+			"SUM(order_items.price * order_items.qty) AS revenue", bucket).
+		Group("period").
+		Order("period").
+		Scan(&series).Error
+	if err != nil {
+		return nil, errors.New("failed to group sales by period")
+	}
+
+	return series, nil
+}
+
+func (r *analyticsRepository) FindTopProducts(ctx context.Context, sellerId uint, from time.Time, to time.Time, limit int) ([]dto.TopProduct, error) {
+	var products []dto.TopProduct
+	err := r.sales(ctx, sellerId, from, to).
+		Select("order_items.product_id, MAX(order_items.name) AS name, " +
+			"COUNT(DISTINCT order_items.order_id) AS orders, " +
+			"SUM(order_items.qty) AS units, " +
+			"SUM(order_items.price * order_items.qty) AS revenue").
+		Group("order_items.product_id").
+		Order("revenue DESC, order_items.product_id").
+		Limit(limit).
+		Scan(&products).Error
+	if err != nil {
+		return nil, errors.New("failed to rank products")
+	}
+
+	return products, nil
+}
+
+func (r *analyticsRepository) sales(ctx context.Context, sellerId uint, from time.Time, to time.Time) *gorm.DB {
+	return r.db.WithContext(ctx).Table("order_items").
+		Where("order_items.seller_id = ? AND order_items.created_at >= ? AND order_items.created_at < ?", sellerId, from, to)
+}
+
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"go-ecommerce-app/internal/domain"
 
@@ -8,56 +9,128 @@ import (
 )
 
 type CatalogRepository interface {
-	CreateCategory(e *domain.Category) error
-	FindCategories() ([]*domain.Category, error)
-	FindCategoryByID(id int) (*domain.Category, error)
-	EditCategory(e *domain.Category) (*domain.Category, error)
-	DeleteCategory(id int) error
+	CreateCategory(ctx context.Context, e *domain.Category) error
+	FindCategories(ctx context.Context, p Pagination, f CategoryFilter) ([]*domain.Category, PageInfo, error)
+	FindCategoryByID(ctx context.Context, id int) (*domain.Category, error)
+	EditCategory(ctx context.Context, e *domain.Category) (*domain.Category, error)
+	DeleteCategory(ctx context.Context, id int) error
 
 	// Product methods can be added here
-	CreateProduct(e *domain.Product) error
-	FindProducts() ([]*domain.Product, error)
-	FindProductByID(id int) (*domain.Product, error)
-	FindSellerProducts(id int) ([]*domain.Product, error)
-	EditProduct(e *domain.Product) (*domain.Product, error)
-	DeleteProduct(id int) error
+	CreateProduct(ctx context.Context, e *domain.Product) error
+	FindProducts(ctx context.Context, p Pagination, f ProductFilter) ([]*domain.Product, PageInfo, error)
+	FindProductByID(ctx context.Context, id int) (*domain.Product, error)
+	FindSellerProducts(ctx context.Context, id int, p Pagination, f ProductFilter) ([]*domain.Product, PageInfo, error)
+	EditProduct(ctx context.Context, e *domain.Product) (*domain.Product, error)
+	DeleteProduct(ctx context.Context, id int) error
 }
 
 type catalogRepository struct {
 	db *gorm.DB
 }
 
-func (c *catalogRepository) CreateCategory(e *domain.Category) error {
-	err := c.db.Create(e).Error
+var categorySortFields = map[string]string{
+	"id":            "id",
+	"name":          "name",
+	"display_order": "display_order",
+	"created_at":    "created_at",
+}
+
+var productSortFields = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"price":      "price",
+	"stock":      "stock",
+	"rating":     "rating_average",
+	"reviews":    "rating_count",
+	"created_at": "created_at",
+}
+
+type CategoryFilter struct {
+	ParentId *uint
+	Search   string
+}
+
+func (f CategoryFilter) Apply(db *gorm.DB) *gorm.DB {
+	if f.ParentId != nil {
+		db = db.Where("parent_id = ?", *f.ParentId)
+	}
+	if len(f.Search) > 0 {
+		db = db.Where("name ILIKE ?", "%"+f.Search+"%")
+	}
+
+	return db
+}
+
+type ProductFilter struct {
+	CategoryId uint
+	SellerId   uint
+	MinPrice   float64
+	MaxPrice   float64
+	Search     string
+	InStock    bool
+	MinRating  float64
+}
+
+func (f ProductFilter) Apply(db *gorm.DB) *gorm.DB {
+	if f.CategoryId > 0 {
+		db = db.Where("category_id = ?", f.CategoryId)
+	}
+	if f.SellerId > 0 {
+		db = db.Where("user_id = ?", f.SellerId)
+	}
+	if f.MinPrice > 0 {
+		db = db.Where("price >= ?", f.MinPrice)
+	}
+	if f.MaxPrice > 0 {
+		db = db.Where("price <= ?", f.MaxPrice)
+	}
+	if len(f.Search) > 0 {
+		db = db.Where("name ILIKE ? OR description ILIKE ?", "%"+f.Search+"%", "%"+f.Search+"%")
+	}
+	if f.InStock {
+		db = db.Where("stock > 0")
+	}
+	if f.MinRating > 0 {
+		db = db.Where("rating_average >= ?", f.MinRating)
+	}
+
+	return db
+}
+
+func (c *catalogRepository) CreateCategory(ctx context.Context, e *domain.Category) error {
+	err := c.db.WithContext(ctx).Create(e).Error
 	if err != nil {
 		return errors.New("failed to create category: ")
 	}
 	return nil
 }
 
-func (c *catalogRepository) FindCategories() ([]*domain.Category, error) {
+func (c *catalogRepository) FindCategories(ctx context.Context, p Pagination, f CategoryFilter) ([]*domain.Category, PageInfo, error) {
 	var categories []*domain.Category
-	err := c.db.Find(&categories).Error
+	info, err := paginate(c.db.WithContext(ctx), p, f, categorySortFields, &categories)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
 	}
 
-	return categories, nil
+	return categories, info, nil
 }
 
-func (c *catalogRepository) FindCategoryByID(id int) (*domain.Category, error) {
+func (c *catalogRepository) FindCategoryByID(ctx context.Context, id int) (*domain.Category, error) {
 	var category *domain.Category
 
-	err := c.db.First(&category, id).Error
+	err := c.db.WithContext(ctx).First(&category, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NotFoundError("category not found")
+	}
 	if err != nil {
-		return nil, errors.New("category not found")
+		return nil, errors.New("failed to find category")
 	}
 
 	return category, nil
 }
 
-func (r *catalogRepository) EditCategory(e *domain.Category) (*domain.Category, error) {
-	err := r.db.Save(e).Error
+func (r *catalogRepository) EditCategory(ctx context.Context, e *domain.Category) (*domain.Category, error) {
+	err := r.db.WithContext(ctx).Save(e).Error
 
 	if err != nil {
 		return nil, errors.New("failed to update category")
@@ -66,8 +139,8 @@ func (r *catalogRepository) EditCategory(e *domain.Category) (*domain.Category,
 	return e, nil
 }
 
-func (r *catalogRepository) DeleteCategory(id int) error {
-	err := r.db.Delete(&domain.Category{}, id).Error
+func (r *catalogRepository) DeleteCategory(ctx context.Context, id int) error {
+	err := r.db.WithContext(ctx).Delete(&domain.Category{}, id).Error
 
 	if err != nil {
 		return errors.New("failed to delete category")
@@ -77,8 +150,8 @@ func (r *catalogRepository) DeleteCategory(id int) error {
 }
 
 // ///////////////////////////// Products /////////////////////////////////////
-func (c *catalogRepository) CreateProduct(e *domain.Product) error {
-	err := c.db.Model(&domain.Product{}).Create(e).Error
+func (c *catalogRepository) CreateProduct(ctx context.Context, e *domain.Product) error {
+	err := c.db.WithContext(ctx).Model(&domain.Product{}).Create(e).Error
 	if err != nil {
 		return errors.New("failed to create product: ")
 	}
@@ -86,38 +159,38 @@ func (c *catalogRepository) CreateProduct(e *domain.Product) error {
 	return nil
 }
 
-func (c *catalogRepository) FindProducts() ([]*domain.Product, error) {
+func (c *catalogRepository) FindProducts(ctx context.Context, p Pagination, f ProductFilter) ([]*domain.Product, PageInfo, error) {
 	var products []*domain.Product
-	err := c.db.Find(&products).Error
+	info, err := paginate(c.db.WithContext(ctx), p, f, productSortFields, &products)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
 	}
 
-	return products, nil
+	return products, info, nil
 }
 
-func (c *catalogRepository) FindProductByID(id int) (*domain.Product, error) {
+func (c *catalogRepository) FindProductByID(ctx context.Context, id int) (*domain.Product, error) {
 	var product *domain.Product
-	err := c.db.First(&product, id).Error
+	err := c.db.WithContext(ctx).First(&product, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NotFoundError("product not found")
+	}
 	if err != nil {
-		return nil, errors.New("product not found")
+		return nil, errors.New("failed to find product")
 	}
 
 	return product, nil
 }
 
-func (c *catalogRepository) FindSellerProducts(id int) ([]*domain.Product, error) {
-	var products []*domain.Product
-	err := c.db.Where("user_id = ?", id).Find(&products).Error
-	if err != nil {
-		return nil, errors.New("no products found for this seller")
-	}
-
-	return nil, nil
+func (c *catalogRepository) FindSellerProducts(ctx context.Context, id int, p Pagination, f ProductFilter) ([]*domain.Product, PageInfo, error) {
+	f.SellerId = uint(id)
+	return c.FindProducts(ctx, p, f)
 }
 
-func (r *catalogRepository) EditProduct(e *domain.Product) (*domain.Product, error) {
-	err := r.db.Save(e).Error
+func (r *catalogRepository) EditProduct(ctx context.Context, e *domain.Product) (*domain.Product, error) {
+	// ratings are maintained by the review repository and stock by the
+	// inventory repository
+	err := r.db.WithContext(ctx).Omit("rating_average", "rating_count", "stock").Save(e).Error
 	if err != nil {
 		return nil, errors.New("failed to update product")
 	}
@@ -125,8 +198,8 @@ func (r *catalogRepository) EditProduct(e *domain.Product) (*domain.Product, err
 	return e, nil
 }
 
-func (r *catalogRepository) DeleteProduct(id int) error {
-	err := r.db.Delete(&domain.Product{}, id).Error
+func (r *catalogRepository) DeleteProduct(ctx context.Context, id int) error {
+	err := r.db.WithContext(ctx).Delete(&domain.Product{}, id).Error
 	if err != nil {
 		return errors.New("failed to delete product")
 	}
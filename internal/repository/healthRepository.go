@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	MissingTables(ctx context.Context, models []interface{}) ([]string, error)
+}
+
+type healthRepository struct {
+	db *gorm.DB
+}
+
+func (r healthRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.WithContext(ctx).DB()
+	if err != nil {
+		return errors.New("failed to access database connection")
+	}
+
+	if err = sqlDB.PingContext(ctx); err != nil {
+		return errors.New("failed to reach database")
+	}
+
+	return nil
+}
+
+// MissingTables returns the tables of models that have not been migrated yet.
+func (r healthRepository) MissingTables(ctx context.Context, models []interface{}) ([]string, error) {
+	var missing []string
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: r.db.WithContext(ctx)}
+		if err := stmt.Parse(m); err != nil {
+			return nil, errors.New("failed to parse model")
+		}
+
+		if !r.db.WithContext(ctx).Migrator().HasTable(stmt.Table) {
+			missing = append(missing, stmt.Table)
+		}
+	}
+
+	return missing, nil
+}
+
+func NewHealthRepository(db *gorm.DB) HealthRepository {
+	return &healthRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	// Reserve stores e unless the key is already taken. It reports whether e
+	// was stored; when it was not, the existing record is returned.
+	Reserve(ctx context.Context, e *domain.IdempotencyKey) (bool, *domain.IdempotencyKey, error)
+	Complete(ctx context.Context, e *domain.IdempotencyKey) error
+	Release(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, e *domain.IdempotencyKey) (bool, *domain.IdempotencyKey, error) {
+	var existing *domain.IdempotencyKey
+	reserved := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// an expired key is free to be used again
+		err := tx.Where("scope = ? AND key = ? AND expires_at < ?", e.Scope, e.Key, time.Now()).
+			Delete(&domain.IdempotencyKey{}).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(e)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			reserved = true
+			return nil
+		}
+
+		return tx.First(&existing, "scope = ? AND key = ?", e.Scope, e.Key).Error
+	})
+	if err != nil {
+		return false, nil, errors.New("failed to reserve idempotency key")
+	}
+
+	return reserved, existing, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, e *domain.IdempotencyKey) error {
+	err := r.db.WithContext(ctx).Model(e).Select("completed", "status_code", "content_type", "body", "updated_at").Updates(e).Error
+	if err != nil {
+		return errors.New("failed to store idempotent response")
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&domain.IdempotencyKey{}, id).Error
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&domain.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
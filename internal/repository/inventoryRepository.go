@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+
+	"gorm.io/gorm"
+)
+
+type InventoryRepository interface {
+	// AdjustStock adds m.Quantity to the product's stock and records the
+	// movement. Taking out more than is left fails with a conflict.
+	AdjustStock(ctx context.Context, m *domain.InventoryMovement) (*domain.Product, error)
+	// SetStock changes the product's stock to stock and records the
+	// difference as the movement. Nothing is recorded when it is unchanged.
+	SetStock(ctx context.Context, m *domain.InventoryMovement, stock uint) (*domain.Product, error)
+	FindMovements(ctx context.Context, sellerId uint, p Pagination, f MovementFilter) ([]domain.InventoryMovement, PageInfo, error)
+	// FindStockDrift lists the seller's products whose stock differs from
+	// the sum of their movements.
+	FindStockDrift(ctx context.Context, sellerId uint) ([]dto.StockDrift, error)
+	// ReconcileStock makes the stock of the seller's drifting products agree
+	// with their movements and returns what it corrected.
+	ReconcileStock(ctx context.Context, sellerId uint, actorId uint) ([]dto.StockDrift, error)
+}
+
+type inventoryRepository struct {
+	db *gorm.DB
+}
+
+var movementSortFields = map[string]string{
+	"id":         "id",
+	"quantity":   "quantity",
+	"created_at": "created_at",
+}
+
+type MovementFilter struct {
+	ProductId uint
+	Reason    string
+}
+
+func (f MovementFilter) Apply(db *gorm.DB) *gorm.DB {
+	if f.ProductId > 0 {
+		db = db.Where("product_id = ?", f.ProductId)
+	}
+	if len(f.Reason) > 0 {
+		db = db.Where("reason = ?", f.Reason)
+	}
+
+	return db
+}
+
+func NewInventoryRepository(db *gorm.DB) InventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+func (r *inventoryRepository) AdjustStock(ctx context.Context, m *domain.InventoryMovement) (*domain.Product, error) {
+	var product *domain.Product
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Product{}).
+			Where("id = ? AND stock + ? >= 0", m.ProductId, m.Quantity).
+			UpdateColumn("stock", gorm.Expr("stock + ?", m.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return domain.ConflictError("not enough stock left")
+		}
+
+		var err error
+		product, err = r.record(tx, m)
+		return err
+	})
+	if err != nil {
+		return nil, stockError(err)
+	}
+
+	return product, nil
+}
+
+// SetStock only writes the new stock if nothing else changed it since it
+// was read, so the recorded difference is always exact.
+func (r *inventoryRepository) SetStock(ctx context.Context, m *domain.InventoryMovement, stock uint) (*domain.Product, error) {
+	var product *domain.Product
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current *domain.Product
+		if err := tx.First(&current, m.ProductId).Error; err != nil {
+			return domain.NotFoundError("product not found")
+		}
+		if current.Stock == stock {
+			product = current
+			return nil
+		}
+
+		result := tx.Model(&domain.Product{}).
+			Where("id = ? AND stock = ?", m.ProductId, current.Stock).
+			UpdateColumn("stock", stock)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return domain.ConflictError("stock changed while it was being updated, try again")
+		}
+
+		m.Quantity = int(stock) - int(current.Stock)
+		var err error
+		product, err = r.record(tx, m)
+		return err
+	})
+	if err != nil {
+		return nil, stockError(err)
+	}
+
+	return product, nil
+}
+
+func (r *inventoryRepository) FindMovements(ctx context.Context, sellerId uint, p Pagination, f MovementFilter) ([]domain.InventoryMovement, PageInfo, error) {
+	var movements []domain.InventoryMovement
+	info, err := paginate(r.db.WithContext(ctx).Where("seller_id = ?", sellerId), p, f, movementSortFields, &movements)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	return movements, info, nil
+}
+
+func (r *inventoryRepository) FindStockDrift(ctx context.Context, sellerId uint) ([]dto.StockDrift, error) {
+	return findStockDrift(r.db.WithContext(ctx), sellerId)
+}
+
+// ReconcileStock treats the movements as the record of truth and records a
+// reconciliation movement for every correction. A product without any,
+// listed before movements were kept, is given an opening movement for its
+// current stock instead. Movements adding up to less than nothing are
+// written off back to zero, as stock cannot go below it.
+func (r *inventoryRepository) ReconcileStock(ctx context.Context, sellerId uint, actorId uint) ([]dto.StockDrift, error) {
+	var drift []dto.StockDrift
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		drift, err = findStockDrift(tx, sellerId)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range drift {
+			m := domain.InventoryMovement{
+				ProductId: d.ProductId,
+				SellerId:  sellerId,
+				Reason:    domain.InventoryReasonReconciliation,
+				ActorId:   actorId,
+			}
+			switch {
+			case d.Movements == 0:
+				m.Quantity = int(d.Stock)
+				m.StockAfter = d.Stock
+				m.Note = "opening balance"
+			case d.LedgerStock < 0:
+				m.Quantity = -d.LedgerStock
+				m.Note = fmt.Sprintf("stock was %d, movements added up to %d, written off to 0", d.Stock, d.LedgerStock)
+			default:
+				m.StockAfter = uint(d.LedgerStock)
+				m.Note = fmt.Sprintf("stock was %d, set to the %d its movements add up to", d.Stock, d.LedgerStock)
+			}
+
+			if m.StockAfter != d.Stock {
+				err = tx.Model(&domain.Product{}).Where("id = ?", d.ProductId).
+					UpdateColumn("stock", m.StockAfter).Error
+				if err != nil {
+					return err
+				}
+			}
+			if err = tx.Create(&m).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("failed to reconcile stock")
+	}
+
+	return drift, nil
+}
+
+func findStockDrift(db *gorm.DB, sellerId uint) ([]dto.StockDrift, error) {
+	var drift []dto.StockDrift
+	err := db.Table("products").
+		Select("products.id AS product_id, products.name, products.stock, "+
+			"COALESCE(SUM(inventory_movements.quantity), 0) AS ledger_stock, COUNT(inventory_movements.id) AS movements").
+		Joins("LEFT JOIN inventory_movements ON inventory_movements.product_id = products.id").
+		Where("products.user_id = ?", sellerId).
+		Group("products.id, products.name, products.stock").
+		Having("products.stock <> COALESCE(SUM(inventory_movements.quantity), 0)").
+		Order("products.id").
+		Scan(&drift).Error
+	if err != nil {
+		return nil, errors.New("failed to compare stock with inventory movements")
+	}
+
+	return drift, nil
+}
+
+// record stores the movement with the product's resulting stock.
+func (r *inventoryRepository) record(tx *gorm.DB, m *domain.InventoryMovement) (*domain.Product, error) {
+	var product *domain.Product
+	if err := tx.First(&product, m.ProductId).Error; err != nil {
+		return nil, domain.NotFoundError("product not found")
+	}
+
+	m.SellerId = uint(product.UserId)
+	m.StockAfter = product.Stock
+	if err := tx.Create(m).Error; err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// stockError passes application errors through and hides database ones.
+func stockError(err error) error {
+	var appErr *domain.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	return errors.New("failed to update product stock")
+}
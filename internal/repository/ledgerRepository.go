@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LedgerRepository interface {
+	PostEntries(ctx context.Context, entries []domain.LedgerEntry) error
+	SellerBalance(ctx context.Context, sellerId uint) (float64, error)
+	PayableBalances(ctx context.Context) ([]dto.SellerBalance, error)
+	FindSellerEntries(ctx context.Context, sellerId uint, p Pagination) ([]domain.LedgerEntry, PageInfo, error)
+
+	FindBankAccount(ctx context.Context, sellerId uint) (*domain.BankAccount, error)
+	CreatePayoutBatch(ctx context.Context, batch *domain.PayoutBatch) error
+	FindSellerPayouts(ctx context.Context, sellerId uint, p Pagination) ([]domain.Payout, PageInfo, error)
+	SumSellerPayouts(ctx context.Context, sellerId uint, status domain.PayoutStatus) (float64, error)
+	SettlePayout(ctx context.Context, id uint, status domain.PayoutStatus) (*domain.Payout, error)
+}
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+var ledgerSortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+}
+
+var payoutSortFields = map[string]string{
+	"id":         "id",
+	"amount":     "amount",
+	"created_at": "created_at",
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) PostEntries(ctx context.Context, entries []domain.LedgerEntry) error {
+	err := r.db.WithContext(ctx).Create(&entries).Error
+	if err != nil {
+		return errors.New("failed to post ledger entries")
+	}
+
+	return nil
+}
+
+func (r *ledgerRepository) SellerBalance(ctx context.Context, sellerId uint) (float64, error) {
+	var balance float64
+	err := r.db.WithContext(ctx).Model(&domain.LedgerEntry{}).
+		Select("COALESCE(SUM(credit - debit), 0)").
+		Where("account = ? AND seller_id = ?", domain.LedgerAccountSellerPayable, sellerId).
+		Scan(&balance).Error
+
+	return balance, err
+}
+
+// PayableBalances lists every seller whose payable account is in credit.
+func (r *ledgerRepository) PayableBalances(ctx context.Context) ([]dto.SellerBalance, error) {
+	var balances []dto.SellerBalance
+	err := r.db.WithContext(ctx).Model(&domain.LedgerEntry{}).
+		Select("seller_id, SUM(credit - debit) AS balance").
+		Where("account = ?", domain.LedgerAccountSellerPayable).
+		Group("seller_id").
+		Having("SUM(credit - debit) > 0").
+		Order("seller_id").
+		Scan(&balances).Error
+	if err != nil {
+		return nil, errors.New("failed to fetch seller balances")
+	}
+
+	return balances, nil
+}
+
+func (r *ledgerRepository) FindSellerEntries(ctx context.Context, sellerId uint, p Pagination) ([]domain.LedgerEntry, PageInfo, error) {
+	var entries []domain.LedgerEntry
+	query := r.db.WithContext(ctx).Where("account = ? AND seller_id = ?", domain.LedgerAccountSellerPayable, sellerId)
+	info, err := paginate(query, p, nil, ledgerSortFields, &entries)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	return entries, info, nil
+}
+
+func (r *ledgerRepository) FindBankAccount(ctx context.Context, sellerId uint) (*domain.BankAccount, error) {
+	var account *domain.BankAccount
+	err := r.db.WithContext(ctx).Order("id desc").First(&account, "user_id = ?", sellerId).Error
+	if err != nil {
+		return nil, errors.New("bank account not found")
+	}
+
+	return account, nil
+}
+
+// CreatePayoutBatch stores the batch with its payouts and moves every payout
+// amount out of the seller's payable account, into payouts in transit, in the
+// same transaction.
+func (r *ledgerRepository) CreatePayoutBatch(ctx context.Context, batch *domain.PayoutBatch) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return errors.New("failed to create payout batch")
+		}
+
+		var entries []domain.LedgerEntry
+		for _, payout := range batch.Payouts {
+			reference := fmt.Sprintf("payout-%d", payout.ID)
+			description := fmt.Sprintf("payout %d to bank account %d", payout.ID, payout.BankAccount)
+			entries = append(entries,
+				domain.LedgerEntry{
+					Reference:   reference,
+					Account:     domain.LedgerAccountSellerPayable,
+					SellerId:    payout.SellerId,
+					Debit:       payout.Amount,
+					Description: description,
+					PayoutId:    payout.ID,
+				},
+				domain.LedgerEntry{
+					Reference:   reference,
+					Account:     domain.LedgerAccountPayoutsInTransit,
+					SellerId:    payout.SellerId,
+					Credit:      payout.Amount,
+					Description: description,
+					PayoutId:    payout.ID,
+				},
+			)
+		}
+
+		if err := tx.Create(&entries).Error; err != nil {
+			return errors.New("failed to post payout entries")
+		}
+
+		return nil
+	})
+}
+
+// SettlePayout marks a pending payout paid or failed and clears it out of
+// payouts in transit: a paid payout leaves the platform's cash, a failed one
+// goes back to the seller's payable account to be paid again.
+func (r *ledgerRepository) SettlePayout(ctx context.Context, id uint, status domain.PayoutStatus) (*domain.Payout, error) {
+	var payout domain.Payout
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&payout, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.NotFoundError("payout not found")
+			}
+			return errors.New("failed to find payout")
+		}
+
+		updates := map[string]interface{}{"status": status}
+		if status == domain.PayoutStatusPaid {
+			updates["paid_at"] = time.Now()
+		}
+		result := tx.Model(&domain.Payout{}).
+			Where("id = ? AND status = ?", id, domain.PayoutStatusPending).
+			Updates(updates)
+		if result.Error != nil {
+			return errors.New("failed to settle payout")
+		}
+		if result.RowsAffected == 0 {
+			return domain.ConflictError("this payout has already been settled")
+		}
+
+		account := domain.LedgerAccountPlatformCash
+		description := fmt.Sprintf("payout %d paid to bank account %d", payout.ID, payout.BankAccount)
+		if status == domain.PayoutStatusFailed {
+			account = domain.LedgerAccountSellerPayable
+			description = fmt.Sprintf("payout %d to bank account %d failed", payout.ID, payout.BankAccount)
+		}
+		reference := fmt.Sprintf("payout-%d-%s", payout.ID, status)
+		entries := []domain.LedgerEntry{
+			{
+				Reference:   reference,
+				Account:     domain.LedgerAccountPayoutsInTransit,
+				SellerId:    payout.SellerId,
+				Debit:       payout.Amount,
+				Description: description,
+				PayoutId:    payout.ID,
+			},
+			{
+				Reference:   reference,
+				Account:     account,
+				SellerId:    payout.SellerId,
+				Credit:      payout.Amount,
+				Description: description,
+				PayoutId:    payout.ID,
+			},
+		}
+		if err := tx.Create(&entries).Error; err != nil {
+			return errors.New("failed to post payout entries")
+		}
+
+		return tx.First(&payout, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &payout, nil
+}
+
+func (r *ledgerRepository) FindSellerPayouts(ctx context.Context, sellerId uint, p Pagination) ([]domain.Payout, PageInfo, error) {
+	var payouts []domain.Payout
+	info, err := paginate(r.db.WithContext(ctx).Where("seller_id = ?", sellerId), p, nil, payoutSortFields, &payouts)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	return payouts, info, nil
+}
+
+func (r *ledgerRepository) SumSellerPayouts(ctx context.Context, sellerId uint, status domain.PayoutStatus) (float64, error) {
+	var total float64
+	err := r.db.WithContext(ctx).Model(&domain.Payout{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("seller_id = ? AND status = ?", sellerId, status).
+		Scan(&total).Error
+
+	return total, err
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Pagination describes a list request. When Cursor is set the list is read
+// with keyset pagination, otherwise Page selects an offset page.
+type Pagination struct {
+	Page   int
+	Limit  int
+	Cursor string
+	Sort   string
+	Desc   bool
+}
+
+type PageInfo struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	NextPage   int    `json:"next_page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Filter narrows a list query before it is counted and paginated.
+type Filter interface {
+	Apply(db *gorm.DB) *gorm.DB
+}
+
+type noFilter struct{}
+
+func (noFilter) Apply(db *gorm.DB) *gorm.DB {
+	return db
+}
+
+type cursor struct {
+	Value interface{} `json:"v"`
+	ID    interface{} `json:"id"`
+}
+
+func (p Pagination) normalize() Pagination {
+	if p.Limit < 1 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Sort == "" {
+		p.Sort = "id"
+	}
+
+	return p
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	c := cursor{}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, domain.ValidationError("invalid cursor")
+	}
+	if err = json.Unmarshal(b, &c); err != nil || c.ID == nil {
+		return c, domain.ValidationError("invalid cursor")
+	}
+
+	return c, nil
+}
+
+// paginate counts and reads one page of T. sortable maps the public sort keys
+// accepted from clients to their column names.
+func paginate[T any](db *gorm.DB, p Pagination, f Filter, sortable map[string]string, out *[]T) (PageInfo, error) {
+	p = p.normalize()
+	if f == nil {
+		f = noFilter{}
+	}
+
+	column, ok := sortable[p.Sort]
+	if !ok {
+		return PageInfo{}, domain.ValidationError(fmt.Sprintf("cannot sort by %q", p.Sort))
+	}
+
+	base := f.Apply(db.Model(new(T))).Session(&gorm.Session{})
+
+	info := PageInfo{Limit: p.Limit}
+	if err := base.Count(&info.Total).Error; err != nil {
+		return PageInfo{}, err
+	}
+
+	direction, op := "ASC", ">"
+	if p.Desc {
+		direction, op = "DESC", "<"
+	}
+
+	query := base.Order(fmt.Sprintf("%s %s", column, direction))
+	if column != "id" {
+		query = query.Order("id " + direction)
+	}
+
+	if len(p.Cursor) > 0 {
+		c, err := decodeCursor(p.Cursor)
+		if err != nil {
+			return PageInfo{}, err
+		}
+		if column == "id" {
+			query = query.Where(fmt.Sprintf("id %s ?", op), c.ID)
+		} else {
+			query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, op), c.Value, c.ID)
+		}
+	} else {
+		info.Page = p.Page
+		query = query.Offset((p.Page - 1) * p.Limit)
+	}
+
+	var rows []T
+	if err := query.Limit(p.Limit + 1).Find(&rows).Error; err != nil {
+		return PageInfo{}, err
+	}
+
+	if len(rows) > p.Limit {
+		rows = rows[:p.Limit]
+		info.HasMore = true
+		if info.Page > 0 {
+			info.NextPage = info.Page + 1
+		}
+
+		next, err := cursorFor(db, rows[len(rows)-1], column)
+		if err != nil {
+			return PageInfo{}, err
+		}
+		info.NextCursor = next
+	}
+
+	*out = rows
+	return info, nil
+}
+
+func cursorFor(db *gorm.DB, row interface{}, column string) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(row); err != nil {
+		return "", err
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(row))
+	c := cursor{}
+	if field := stmt.Schema.LookUpField(column); field != nil {
+		c.Value, _ = field.ValueOf(context.Background(), value)
+	}
+	if field := stmt.Schema.LookUpField("id"); field != nil {
+		c.ID, _ = field.ValueOf(context.Background(), value)
+	}
+
+	return encodeCursor(c), nil
+}
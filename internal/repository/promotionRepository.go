@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PromotionRepository interface {
+	CreateCoupon(ctx context.Context, e *domain.Coupon) error
+	FindCouponByID(ctx context.Context, id uint) (*domain.Coupon, error)
+	FindCouponByCode(ctx context.Context, code string) (*domain.Coupon, error)
+	FindSellerCoupons(ctx context.Context, sellerId uint, p Pagination) ([]domain.Coupon, PageInfo, error)
+	UpdateCoupon(ctx context.Context, e *domain.Coupon) error
+
+	CountUserRedemptions(ctx context.Context, couponId uint, uId uint) (int64, error)
+	CreateRedemption(ctx context.Context, e *domain.CouponRedemption) error
+
+	FindCartCoupon(ctx context.Context, uId uint) (*domain.CartCoupon, error)
+	SaveCartCoupon(ctx context.Context, e domain.CartCoupon) error
+	DeleteCartCoupon(ctx context.Context, uId uint) error
+}
+
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+var couponSortFields = map[string]string{
+	"id":         "id",
+	"code":       "code",
+	"used_count": "used_count",
+	"created_at": "created_at",
+}
+
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+func (r *promotionRepository) CreateCoupon(ctx context.Context, e *domain.Coupon) error {
+	err := r.db.WithContext(ctx).Create(e).Error
+	if err != nil {
+		return errors.New("failed to create coupon")
+	}
+
+	return nil
+}
+
+func (r *promotionRepository) FindCouponByID(ctx context.Context, id uint) (*domain.Coupon, error) {
+	var coupon *domain.Coupon
+	err := r.db.WithContext(ctx).First(&coupon, id).Error
+	if err != nil {
+		return nil, errors.New("coupon not found")
+	}
+
+	return coupon, nil
+}
+
+func (r *promotionRepository) FindCouponByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	var coupon *domain.Coupon
+	err := r.db.WithContext(ctx).First(&coupon, "code = ?", code).Error
+	if err != nil {
+		return nil, errors.New("coupon not found")
+	}
+
+	return coupon, nil
+}
+
+func (r *promotionRepository) FindSellerCoupons(ctx context.Context, sellerId uint, p Pagination) ([]domain.Coupon, PageInfo, error) {
+	var coupons []domain.Coupon
+	info, err := paginate(r.db.WithContext(ctx).Where("seller_id = ?", sellerId), p, nil, couponSortFields, &coupons)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	return coupons, info, nil
+}
+
+func (r *promotionRepository) UpdateCoupon(ctx context.Context, e *domain.Coupon) error {
+	err := r.db.WithContext(ctx).Save(e).Error
+	if err != nil {
+		return errors.New("failed to update coupon")
+	}
+
+	return nil
+}
+
+func (r *promotionRepository) CountUserRedemptions(ctx context.Context, couponId uint, uId uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.CouponRedemption{}).
+		Where("coupon_id = ? AND user_id = ?", couponId, uId).
+		Count(&count).Error
+
+	return count, err
+}
+
+// CreateRedemption records the redemption and claims one use of the coupon,
+// failing when the global usage limit has already been reached.
+func (r *promotionRepository) CreateRedemption(ctx context.Context, e *domain.CouponRedemption) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Coupon{}).
+			Where("id = ? AND (usage_limit = 0 OR used_count < usage_limit)", e.CouponId).
+			UpdateColumn("used_count", gorm.Expr("used_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ConflictError("coupon usage limit reached")
+		}
+
+		return tx.Create(e).Error
+	})
+}
+
+func (r *promotionRepository) FindCartCoupon(ctx context.Context, uId uint) (*domain.CartCoupon, error) {
+	var cartCoupon *domain.CartCoupon
+	err := r.db.WithContext(ctx).First(&cartCoupon, "user_id = ?", uId).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return cartCoupon, nil
+}
+
+func (r *promotionRepository) SaveCartCoupon(ctx context.Context, e domain.CartCoupon) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"coupon_id", "code", "updated_at"}),
+	}).Create(&e).Error
+}
+
+func (r *promotionRepository) DeleteCartCoupon(ctx context.Context, uId uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", uId).Delete(&domain.CartCoupon{}).Error
+}
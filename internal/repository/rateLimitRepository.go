@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+)
+
+// RateLimitStore keeps rate limit counters and lockouts. The memory store
+// suits a single instance; the database store shares limits between
+// instances.
+type RateLimitStore interface {
+	// Hit counts one hit against key in a fixed window that starts with the
+	// first hit, and returns the counter after the hit.
+	Hit(ctx context.Context, key string, window time.Duration) (domain.RateLimit, error)
+	Find(ctx context.Context, key string) (domain.RateLimit, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type rateLimitRepository struct {
+	db *gorm.DB
+}
+
+func NewRateLimitRepository(db *gorm.DB) RateLimitStore {
+	return &rateLimitRepository{db: db}
+}
+
+func (r *rateLimitRepository) Hit(ctx context.Context, key string, window time.Duration) (domain.RateLimit, error) {
+	now := time.Now()
+	entry := domain.RateLimit{Key: key, Hits: 1, WindowEnd: now.Add(window), UpdatedAt: now}
+
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"hits":       gorm.Expr("CASE WHEN rate_limits.window_end <= ? THEN 1 ELSE rate_limits.hits + 1 END", now),
+			"window_end": gorm.Expr("CASE WHEN rate_limits.window_end <= ? THEN ? ELSE rate_limits.window_end END", now, now.Add(window)),
+			"updated_at": now,
+		}),
+	}).Create(&entry).Error
+	if err != nil {
+		return domain.RateLimit{}, errors.New("failed to count rate limit hit")
+	}
+
+	return r.Find(ctx, key)
+}
+
+func (r *rateLimitRepository) Find(ctx context.Context, key string) (domain.RateLimit, error) {
+	var entry domain.RateLimit
+	err := r.db.WithContext(ctx).Where("key = ?", key).Limit(1).Find(&entry).Error
+	if err != nil {
+		return domain.RateLimit{}, errors.New("failed to read rate limit")
+	}
+
+	if entry.ID > 0 && !entry.WindowEnd.After(time.Now()) {
+		entry.Hits = 0
+	}
+	entry.Key = key
+
+	return entry, nil
+}
+
+func (r *rateLimitRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	entry := domain.RateLimit{Key: key, WindowEnd: time.Now(), LockedUntil: until, UpdatedAt: time.Now()}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"locked_until", "updated_at"}),
+	}).Create(&entry).Error
+}
+
+func (r *rateLimitRepository) Reset(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&domain.RateLimit{}).Error
+}
+
+func (r *rateLimitRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("window_end < ? AND locked_until < ?", before, before).Delete(&domain.RateLimit{})
+	return result.RowsAffected, result.Error
+}
+
+type memoryRateLimitStore struct {
+	mu      sync.Mutex
+	entries map[string]domain.RateLimit
+}
+
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{entries: map[string]domain.RateLimit{}}
+}
+
+func (m *memoryRateLimitStore) Hit(ctx context.Context, key string, window time.Duration) (domain.RateLimit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	entry := m.entries[key]
+	entry.Key = key
+	if !entry.WindowEnd.After(now) {
+		entry.Hits = 0
+		entry.WindowEnd = now.Add(window)
+	}
+	entry.Hits++
+	entry.UpdatedAt = now
+	m.entries[key] = entry
+
+	return entry, nil
+}
+
+func (m *memoryRateLimitStore) Find(ctx context.Context, key string) (domain.RateLimit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.entries[key]
+	entry.Key = key
+	if !entry.WindowEnd.After(time.Now()) {
+		entry.Hits = 0
+	}
+
+	return entry, nil
+}
+
+func (m *memoryRateLimitStore) Lock(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.entries[key]
+	entry.Key = key
+	entry.LockedUntil = until
+	entry.UpdatedAt = time.Now()
+	m.entries[key] = entry
+
+	return nil
+}
+
+func (m *memoryRateLimitStore) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *memoryRateLimitStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int64
+	for key, entry := range m.entries {
+		if entry.WindowEnd.Before(before) && entry.LockedUntil.Before(before) {
+			delete(m.entries, key)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
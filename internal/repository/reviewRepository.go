@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReviewRepository interface {
+	CreateReview(ctx context.Context, e *domain.Review) error
+	UpdateReview(ctx context.Context, e *domain.Review) error
+	DeleteReview(ctx context.Context, e *domain.Review) error
+	ReplyToReview(ctx context.Context, id uint, reply string) error
+	FindReviewById(ctx context.Context, id uint) (*domain.Review, error)
+	FindUserReview(ctx context.Context, productId uint, userId uint) (*domain.Review, error)
+	FindProductReviews(ctx context.Context, productId uint, p Pagination) ([]domain.Review, PageInfo, error)
+	FindSellerReviews(ctx context.Context, sellerId uint, p Pagination) ([]domain.Review, PageInfo, error)
+
+	FindDeliveredOrderItem(ctx context.Context, userId uint, productId uint) (*domain.OrderItem, error)
+	FlagReview(ctx context.Context, flag *domain.ReviewFlag, hideAt int) (*domain.Review, error)
+	// FindFlaggedReviews lists the reviews awaiting moderation, most flagged
+	// first.
+	FindFlaggedReviews(ctx context.Context, p Pagination) ([]domain.Review, PageInfo, error)
+	// RestoreReview dismisses the review's flags and publishes it again.
+	RestoreReview(ctx context.Context, id uint) (*domain.Review, error)
+}
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+var reviewSortFields = map[string]string{
+	"id":         "id",
+	"rating":     "rating",
+	"created_at": "created_at",
+}
+
+var flaggedReviewSortFields = map[string]string{
+	"id":         "id",
+	"flag_count": "flag_count",
+	"created_at": "created_at",
+}
+
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+func (r *reviewRepository) CreateReview(ctx context.Context, e *domain.Review) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(e).Error; err != nil {
+			return err
+		}
+
+		return refreshRating(tx, e.ProductId)
+	})
+	if err != nil {
+		return errors.New("failed to create review")
+	}
+
+	return nil
+}
+
+// UpdateReview saves the review and replaces its images.
+func (r *reviewRepository) UpdateReview(ctx context.Context, e *domain.Review) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(e).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("review_id = ?", e.ID).Delete(&domain.ReviewImage{}).Error; err != nil {
+			return err
+		}
+		for i := range e.Images {
+			e.Images[i].ID = 0
+			e.Images[i].ReviewId = e.ID
+		}
+		if len(e.Images) > 0 {
+			if err := tx.Create(&e.Images).Error; err != nil {
+				return err
+			}
+		}
+
+		return refreshRating(tx, e.ProductId)
+	})
+	if err != nil {
+		return errors.New("failed to update review")
+	}
+
+	return nil
+}
+
+func (r *reviewRepository) DeleteReview(ctx context.Context, e *domain.Review) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("review_id = ?", e.ID).Delete(&domain.ReviewImage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("review_id = ?", e.ID).Delete(&domain.ReviewFlag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&domain.Review{}, e.ID).Error; err != nil {
+			return err
+		}
+
+		return refreshRating(tx, e.ProductId)
+	})
+	if err != nil {
+		return errors.New("failed to delete review")
+	}
+
+	return nil
+}
+
+func (r *reviewRepository) ReplyToReview(ctx context.Context, id uint, reply string) error {
+	err := r.db.WithContext(ctx).Model(&domain.Review{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"reply": reply, "replied_at": gorm.Expr("CURRENT_TIMESTAMP")}).Error
+	if err != nil {
+		return errors.New("failed to reply to review")
+	}
+
+	return nil
+}
+
+func (r *reviewRepository) FindReviewById(ctx context.Context, id uint) (*domain.Review, error) {
+	var review *domain.Review
+	err := r.db.WithContext(ctx).Preload("Images").First(&review, id).Error
+	if err != nil {
+		return nil, errors.New("review not found")
+	}
+
+	return review, nil
+}
+
+func (r *reviewRepository) FindUserReview(ctx context.Context, productId uint, userId uint) (*domain.Review, error) {
+	var review *domain.Review
+	err := r.db.WithContext(ctx).Preload("Images").First(&review, "product_id = ? AND user_id = ?", productId, userId).Error
+	if err != nil {
+		return nil, errors.New("review not found")
+	}
+
+	return review, nil
+}
+
+// FindProductReviews lists the published reviews of a product.
+func (r *reviewRepository) FindProductReviews(ctx context.Context, productId uint, p Pagination) ([]domain.Review, PageInfo, error) {
+	var reviews []domain.Review
+	query := r.db.WithContext(ctx).Preload("Images").
+		Where("product_id = ? AND status = ?", productId, domain.ReviewStatusPublished)
+
+	info, err := paginate(query, p, nil, reviewSortFields, &reviews)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	return reviews, info, nil
+}
+
+// FindSellerReviews lists every review, hidden ones included, of the
+// seller's products.
+func (r *reviewRepository) FindSellerReviews(ctx context.Context, sellerId uint, p Pagination) ([]domain.Review, PageInfo, error) {
+	var reviews []domain.Review
+	db := r.db.WithContext(ctx)
+	products := db.Model(&domain.Product{}).Select("id").Where("user_id = ?", sellerId)
+
+	info, err := paginate(db.Preload("Images").Where("product_id IN (?)", products), p, nil, reviewSortFields, &reviews)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	return reviews, info, nil
+}
+
+// FindDeliveredOrderItem returns the latest item of the product the user
+// ordered and had delivered.
+func (r *reviewRepository) FindDeliveredOrderItem(ctx context.Context, userId uint, productId uint) (*domain.OrderItem, error) {
+	var item *domain.OrderItem
+	err := r.db.WithContext(ctx).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Joins("JOIN order_shipments ON order_shipments.order_id = order_items.order_id AND order_shipments.seller_id = order_items.seller_id").
+		Where("orders.user_id = ? AND order_items.product_id = ? AND order_shipments.status = ?", userId, productId, domain.ShipmentStatusDelivered).
+		Order("order_items.id DESC").
+		First(&item).Error
+	if err != nil {
+		return nil, errors.New("delivered order item not found")
+	}
+
+	return item, nil
+}
+
+// FlagReview records the flag and hides the review once it has been flagged
+// hideAt times. A zero hideAt never hides it.
+func (r *reviewRepository) FlagReview(ctx context.Context, flag *domain.ReviewFlag, hideAt int) (*domain.Review, error) {
+	var review *domain.Review
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&domain.ReviewFlag{}).Where("review_id = ? AND user_id = ?", flag.ReviewId, flag.UserId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return domain.ConflictError("you have already flagged this review")
+		}
+
+		if err := tx.Create(flag).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&domain.Review{}).Where("id = ?", flag.ReviewId).
+			UpdateColumn("flag_count", gorm.Expr("flag_count + 1")).Error
+		if err != nil {
+			return err
+		}
+
+		if err = tx.First(&review, flag.ReviewId).Error; err != nil {
+			return err
+		}
+		if hideAt < 1 || review.Status != domain.ReviewStatusPublished || review.FlagCount < hideAt {
+			return nil
+		}
+
+		review.Status = domain.ReviewStatusHidden
+		if err = tx.Model(review).UpdateColumn("status", review.Status).Error; err != nil {
+			return err
+		}
+
+		return refreshRating(tx, review.ProductId)
+	})
+	if err != nil {
+		var appErr *domain.AppError
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("failed to flag review")
+	}
+
+	return review, nil
+}
+
+func (r *reviewRepository) FindFlaggedReviews(ctx context.Context, p Pagination) ([]domain.Review, PageInfo, error) {
+	var reviews []domain.Review
+	if len(p.Sort) < 1 {
+		p.Sort = "flag_count"
+		p.Desc = true
+	}
+
+	query := r.db.WithContext(ctx).Preload("Images").Where("flag_count > 0")
+	info, err := paginate(query, p, nil, flaggedReviewSortFields, &reviews)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	return reviews, info, nil
+}
+
+func (r *reviewRepository) RestoreReview(ctx context.Context, id uint) (*domain.Review, error) {
+	var review *domain.Review
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&review, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.NotFoundError("review not found")
+			}
+			return err
+		}
+
+		if err := tx.Where("review_id = ?", id).Delete(&domain.ReviewFlag{}).Error; err != nil {
+			return err
+		}
+
+		review.Status = domain.ReviewStatusPublished
+		review.FlagCount = 0
+		err := tx.Model(review).UpdateColumns(map[string]interface{}{
+			"status":     review.Status,
+			"flag_count": review.FlagCount,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return refreshRating(tx, review.ProductId)
+	})
+	if err != nil {
+		var appErr *domain.AppError
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("failed to restore review")
+	}
+
+	return review, nil
+}
+
+// refreshRating recomputes the product's rating from its published reviews.
+func refreshRating(tx *gorm.DB, productId uint) error {
+	var stats struct {
+		Count   int
+		Average float64
+	}
+	err := tx.Model(&domain.Review{}).
+		Select("COUNT(*) AS count, COALESCE(AVG(rating), 0) AS average").
+		Where("product_id = ? AND status = ?", productId, domain.ReviewStatusPublished).
+		Scan(&stats).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&domain.Product{}).Where("id = ?", productId).UpdateColumns(map[string]interface{}{
+		"rating_count":   stats.Count,
+		"rating_average": math.Round(stats.Average*100) / 100,
+	}).Error
+}
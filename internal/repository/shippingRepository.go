@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShippingRepository interface {
+	CreateProfile(ctx context.Context, e *domain.ShippingProfile) error
+	FindProfileByID(ctx context.Context, id uint) (*domain.ShippingProfile, error)
+	FindSellerProfiles(ctx context.Context, sellerId uint, activeOnly bool) ([]domain.ShippingProfile, error)
+	UpdateProfile(ctx context.Context, e *domain.ShippingProfile) error
+	DeleteProfile(ctx context.Context, id uint) error
+
+	FindCartShipping(ctx context.Context, uId uint) ([]domain.CartShipping, error)
+	SaveCartShipping(ctx context.Context, e domain.CartShipping) error
+	DeleteCartShipping(ctx context.Context, uId uint) error
+
+	FindShipment(ctx context.Context, orderId uint, sellerId uint) (*domain.OrderShipment, error)
+	UpdateShipment(ctx context.Context, e *domain.OrderShipment) error
+}
+
+type shippingRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingRepository(db *gorm.DB) ShippingRepository {
+	return &shippingRepository{db: db}
+}
+
+func (r *shippingRepository) CreateProfile(ctx context.Context, e *domain.ShippingProfile) error {
+	err := r.db.WithContext(ctx).Create(e).Error
+	if err != nil {
+		return errors.New("failed to create shipping profile")
+	}
+
+	return nil
+}
+
+func (r *shippingRepository) FindProfileByID(ctx context.Context, id uint) (*domain.ShippingProfile, error) {
+	var profile *domain.ShippingProfile
+	err := r.db.WithContext(ctx).First(&profile, id).Error
+	if err != nil {
+		return nil, errors.New("shipping profile not found")
+	}
+
+	return profile, nil
+}
+
+func (r *shippingRepository) FindSellerProfiles(ctx context.Context, sellerId uint, activeOnly bool) ([]domain.ShippingProfile, error) {
+	var profiles []domain.ShippingProfile
+	query := r.db.WithContext(ctx).Where("seller_id = ?", sellerId)
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+
+	err := query.Order("id").Find(&profiles).Error
+	if err != nil {
+		return nil, errors.New("failed to fetch shipping profiles")
+	}
+
+	return profiles, nil
+}
+
+func (r *shippingRepository) UpdateProfile(ctx context.Context, e *domain.ShippingProfile) error {
+	err := r.db.WithContext(ctx).Save(e).Error
+	if err != nil {
+		return errors.New("failed to update shipping profile")
+	}
+
+	return nil
+}
+
+func (r *shippingRepository) DeleteProfile(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Delete(&domain.ShippingProfile{}, id).Error
+	if err != nil {
+		return errors.New("failed to delete shipping profile")
+	}
+
+	return nil
+}
+
+func (r *shippingRepository) FindCartShipping(ctx context.Context, uId uint) ([]domain.CartShipping, error) {
+	var selections []domain.CartShipping
+	err := r.db.WithContext(ctx).Where("user_id = ?", uId).Find(&selections).Error
+	return selections, err
+}
+
+func (r *shippingRepository) SaveCartShipping(ctx context.Context, e domain.CartShipping) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "seller_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"shipping_profile_id", "updated_at"}),
+	}).Create(&e).Error
+}
+
+func (r *shippingRepository) DeleteCartShipping(ctx context.Context, uId uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", uId).Delete(&domain.CartShipping{}).Error
+}
+
+func (r *shippingRepository) FindShipment(ctx context.Context, orderId uint, sellerId uint) (*domain.OrderShipment, error) {
+	var shipment *domain.OrderShipment
+	err := r.db.WithContext(ctx).First(&shipment, "order_id = ? AND seller_id = ?", orderId, sellerId).Error
+	if err != nil {
+		return nil, errors.New("shipment not found")
+	}
+
+	return shipment, nil
+}
+
+func (r *shippingRepository) UpdateShipment(ctx context.Context, e *domain.OrderShipment) error {
+	err := r.db.WithContext(ctx).Save(e).Error
+	if err != nil {
+		return errors.New("failed to update shipment")
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type StockAlertRepository interface {
+	CreateStockAlert(ctx context.Context, e *domain.StockAlert) error
+	FindStockAlert(ctx context.Context, productId uint, userId uint) (*domain.StockAlert, error)
+	FindUserStockAlerts(ctx context.Context, userId uint) ([]domain.StockAlert, error)
+	DeleteStockAlert(ctx context.Context, id uint) error
+
+	// FindStockAlertSubscribers lists the users waiting for the product to
+	// come back in stock.
+	FindStockAlertSubscribers(ctx context.Context, productId uint) ([]domain.User, error)
+	DeleteProductStockAlerts(ctx context.Context, productId uint) error
+}
+
+type stockAlertRepository struct {
+	db *gorm.DB
+}
+
+func NewStockAlertRepository(db *gorm.DB) StockAlertRepository {
+	return &stockAlertRepository{db: db}
+}
+
+func (r *stockAlertRepository) CreateStockAlert(ctx context.Context, e *domain.StockAlert) error {
+	err := r.db.WithContext(ctx).Create(e).Error
+	if err != nil {
+		return errors.New("failed to create stock alert")
+	}
+
+	return nil
+}
+
+func (r *stockAlertRepository) FindStockAlert(ctx context.Context, productId uint, userId uint) (*domain.StockAlert, error) {
+	var alert *domain.StockAlert
+	err := r.db.WithContext(ctx).First(&alert, "product_id = ? AND user_id = ?", productId, userId).Error
+	if err != nil {
+		return nil, errors.New("stock alert not found")
+	}
+
+	return alert, nil
+}
+
+func (r *stockAlertRepository) FindUserStockAlerts(ctx context.Context, userId uint) ([]domain.StockAlert, error) {
+	var alerts []domain.StockAlert
+	err := r.db.WithContext(ctx).Where("user_id = ?", userId).Order("id").Find(&alerts).Error
+	if err != nil {
+		return nil, errors.New("failed to find stock alerts")
+	}
+
+	return alerts, nil
+}
+
+func (r *stockAlertRepository) DeleteStockAlert(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Delete(&domain.StockAlert{}, id).Error
+	if err != nil {
+		return errors.New("failed to delete stock alert")
+	}
+
+	return nil
+}
+
+func (r *stockAlertRepository) FindStockAlertSubscribers(ctx context.Context, productId uint) ([]domain.User, error) {
+	subscribers := r.db.Model(&domain.StockAlert{}).Select("user_id").Where("product_id = ?", productId)
+
+	var users []domain.User
+	err := r.db.WithContext(ctx).Where("id IN (?)", subscribers).Find(&users).Error
+	if err != nil {
+		return nil, errors.New("failed to find stock alert subscribers")
+	}
+
+	return users, nil
+}
+
+func (r *stockAlertRepository) DeleteProductStockAlerts(ctx context.Context, productId uint) error {
+	err := r.db.WithContext(ctx).Where("product_id = ?", productId).Delete(&domain.StockAlert{}).Error
+	if err != nil {
+		return errors.New("failed to delete stock alerts")
+	}
+
+	return nil
+}
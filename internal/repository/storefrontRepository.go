@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+type StorefrontRepository interface {
+	SaveStorefront(ctx context.Context, e *domain.Storefront) error
+	FindStorefrontBySeller(ctx context.Context, sellerId uint) (*domain.Storefront, error)
+	FindStorefrontBySlug(ctx context.Context, slug string) (*domain.Storefront, error)
+}
+
+type storefrontRepository struct {
+	db *gorm.DB
+}
+
+func NewStorefrontRepository(db *gorm.DB) StorefrontRepository {
+	return &storefrontRepository{db: db}
+}
+
+func (r *storefrontRepository) SaveStorefront(ctx context.Context, e *domain.Storefront) error {
+	err := r.db.WithContext(ctx).Save(e).Error
+	if err != nil {
+		return errors.New("failed to save storefront")
+	}
+
+	return nil
+}
+
+func (r *storefrontRepository) FindStorefrontBySeller(ctx context.Context, sellerId uint) (*domain.Storefront, error) {
+	return r.findStorefront(ctx, "user_id = ?", sellerId)
+}
+
+func (r *storefrontRepository) FindStorefrontBySlug(ctx context.Context, slug string) (*domain.Storefront, error) {
+	return r.findStorefront(ctx, "slug = ?", slug)
+}
+
+func (r *storefrontRepository) findStorefront(ctx context.Context, query string, args ...interface{}) (*domain.Storefront, error) {
+	db := r.db.WithContext(ctx)
+
+	var store *domain.Storefront
+	if err := db.Where(query, args...).First(&store).Error; err != nil {
+		return nil, errors.New("storefront not found")
+	}
+
+	// the seller's rating weighs every published review of their products
+	var stats struct {
+		Count   int
+		Average float64
+	}
+	err := db.Model(&domain.Review{}).
+		Select("COUNT(*) AS count, COALESCE(AVG(rating), 0) AS average").
+		Where("status = ? AND product_id IN (?)", domain.ReviewStatusPublished,
+			db.Model(&domain.Product{}).Select("id").Where("user_id = ?", store.UserId)).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, errors.New("failed to find seller rating")
+	}
+	store.RatingCount = stats.Count
+	store.RatingAverage = math.Round(stats.Average*100) / 100
+
+	return store, nil
+}
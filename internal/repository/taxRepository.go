@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TaxRepository interface {
+	FindRates(ctx context.Context, country string) ([]domain.TaxRate, error)
+	SaveRate(ctx context.Context, e *domain.TaxRate) error
+}
+
+type taxRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxRepository(db *gorm.DB) TaxRepository {
+	return &taxRepository{db: db}
+}
+
+func (r *taxRepository) FindRates(ctx context.Context, country string) ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	err := r.db.WithContext(ctx).Where("country = ?", country).Find(&rates).Error
+	if err != nil {
+		return nil, errors.New("failed to fetch tax rates")
+	}
+
+	return rates, nil
+}
+
+// SaveRate inserts the rate or replaces the one with the same scope.
+func (r *taxRepository) SaveRate(ctx context.Context, e *domain.TaxRate) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "country"}, {Name: "region"}, {Name: "tax_category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "rate", "updated_at"}),
+	}).Create(e).Error
+}
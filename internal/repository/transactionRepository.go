@@ -1,42 +1,173 @@
 package repository
 
 import (
+	"context"
+	"errors"
 	"go-ecommerce-app/internal/domain"
 	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/pkg/payment"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type TransactionRepository interface {
-	CreatePayment(payment *domain.Payment) error
-	FindInitialPayment(uId uint) (*domain.Payment, error)
-	FindOrders(uId uint) ([]domain.OrderItem, error)
-	FindOrderById(uId uint, id uint) (dto.SellerOrderDetails, error)
+	CreatePayment(ctx context.Context, payment *domain.Payment) error
+	// FindUnorderedPayment returns the user's latest payment that is open or
+	// paid and has not been turned into an order yet.
+	FindUnorderedPayment(ctx context.Context, uId uint) (*domain.Payment, error)
+	FindPayment(ctx context.Context, id uint) (*domain.Payment, error)
+	// MarkPaymentOrdered records that the payment backs an order. Marking a
+	// payment twice is a conflict, so one payment pays for one order only.
+	MarkPaymentOrdered(ctx context.Context, id uint) error
+	// MarkPaymentCollected records that the money of a pending offline payment
+	// was collected. Collecting a payment twice is a conflict.
+	MarkPaymentCollected(ctx context.Context, id uint) error
+	FindOpenPayments(ctx context.Context, before time.Time) ([]domain.Payment, error)
+	UpdatePayment(ctx context.Context, payment *domain.Payment) error
+	FindOrders(ctx context.Context, uId uint, p Pagination) ([]domain.SubOrder, PageInfo, error)
+	FindOrderById(ctx context.Context, uId uint, id uint) (*domain.SubOrder, error)
+	// FindPlacedOrder returns any buyer's order with its sub-orders.
+	FindPlacedOrder(ctx context.Context, id uint) (*domain.Order, error)
+	FindSellerTaxReport(ctx context.Context, uId uint, from time.Time, to time.Time) ([]dto.SellerTaxReportLine, error)
 }
 
 type transactionStorage struct {
 	db *gorm.DB
 }
 
-func (t *transactionStorage) CreatePayment(payment *domain.Payment) error {
-	return t.db.Create(payment).Error
+var openPaymentStatuses = []domain.PaymentStatus{domain.PaymentStatusInitial, domain.PaymentStatusPending}
+
+var unorderedPaymentStatuses = []domain.PaymentStatus{domain.PaymentStatusInitial, domain.PaymentStatusPending, domain.PaymentStatusSuccess}
+
+var subOrderSortFields = map[string]string{
+	"id":         "id",
+	"amount":     "amount",
+	"created_at": "created_at",
+}
+
+func (t *transactionStorage) CreatePayment(ctx context.Context, payment *domain.Payment) error {
+	return t.db.WithContext(ctx).Create(payment).Error
 }
 
-func (t *transactionStorage) FindOrders(uId uint) ([]domain.OrderItem, error) {
-	return nil, nil
+func (t *transactionStorage) FindOrders(ctx context.Context, uId uint, p Pagination) ([]domain.SubOrder, PageInfo, error) {
+	var orders []domain.SubOrder
+	info, err := paginate(t.db.WithContext(ctx).Preload("Items").Where("seller_id = ?", uId), p, nil, subOrderSortFields, &orders)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	return orders, info, nil
 }
 
-func (t *transactionStorage) FindInitialPayment(uId uint) (*domain.Payment, error) {
+func (t *transactionStorage) FindUnorderedPayment(ctx context.Context, uId uint) (*domain.Payment, error) {
 	var payment *domain.Payment
-	err := t.db.First(&payment, "user_id = ? AND status=initial", uId).Order("created_at desc").Error
+	err := t.db.WithContext(ctx).Order("created_at desc").
+		First(&payment, "user_id = ? AND status IN ? AND ordered_at IS NULL", uId, unorderedPaymentStatuses).Error
 	if err != nil {
 		return nil, err
 	}
 	return payment, nil
 }
 
-func (t *transactionStorage) FindOrderById(uId uint, id uint) (dto.SellerOrderDetails, error) {
-	return dto.SellerOrderDetails{}, nil
+func (t *transactionStorage) FindPayment(ctx context.Context, id uint) (*domain.Payment, error) {
+	var payment *domain.Payment
+	err := t.db.WithContext(ctx).First(&payment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NotFoundError("payment not found")
+	}
+	if err != nil {
+		return nil, errors.New("failed to find payment")
+	}
+
+	return payment, nil
+}
+
+func (t *transactionStorage) MarkPaymentOrdered(ctx context.Context, id uint) error {
+	result := t.db.WithContext(ctx).Model(&domain.Payment{}).
+		Where("id = ? AND ordered_at IS NULL", id).
+		Update("ordered_at", time.Now())
+	if result.Error != nil {
+		return errors.New("failed to mark payment as ordered")
+	}
+	if result.RowsAffected < 1 {
+		return domain.ConflictError("this payment has already been used for an order")
+	}
+
+	return nil
+}
+
+func (t *transactionStorage) MarkPaymentCollected(ctx context.Context, id uint) error {
+	result := t.db.WithContext(ctx).Model(&domain.Payment{}).
+		Where("id = ? AND status = ? AND provider IN ?", id, domain.PaymentStatusPending, payment.OfflineProviders).
+		Update("status", domain.PaymentStatusSuccess)
+	if result.Error != nil {
+		return errors.New("failed to mark payment as collected")
+	}
+	if result.RowsAffected < 1 {
+		return domain.ConflictError("only payments still to be collected on delivery can be collected")
+	}
+
+	return nil
+}
+
+// FindOpenPayments lists the payments created before the given time that
+// are still waiting for the provider. Offline payments stay pending until
+// delivery, so polling them would never settle anything.
+func (t *transactionStorage) FindOpenPayments(ctx context.Context, before time.Time) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	err := t.db.WithContext(ctx).
+		Where("status IN ? AND created_at < ? AND provider NOT IN ?", openPaymentStatuses, before, payment.OfflineProviders).
+		Order("created_at").
+		Find(&payments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (t *transactionStorage) UpdatePayment(ctx context.Context, payment *domain.Payment) error {
+	return t.db.WithContext(ctx).Save(payment).Error
+}
+
+func (t *transactionStorage) FindOrderById(ctx context.Context, uId uint, id uint) (*domain.SubOrder, error) {
+	var order *domain.SubOrder
+	err := t.db.WithContext(ctx).Preload("Items").First(&order, "id = ? AND seller_id = ?", id, uId).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (t *transactionStorage) FindPlacedOrder(ctx context.Context, id uint) (*domain.Order, error) {
+	var order *domain.Order
+	err := t.db.WithContext(ctx).Preload("SubOrders").First(&order, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NotFoundError("order not found")
+	}
+	if err != nil {
+		return nil, errors.New("failed to find order")
+	}
+
+	return order, nil
+}
+
+func (t *transactionStorage) FindSellerTaxReport(ctx context.Context, uId uint, from time.Time, to time.Time) ([]dto.SellerTaxReportLine, error) {
+	var lines []dto.SellerTaxReportLine
+	err := t.db.WithContext(ctx).Model(&domain.OrderItem{}).
+		Select("tax_country AS country, tax_region AS region, tax_rate, COUNT(*) AS items, "+
+			"SUM(taxable_amount) AS taxable_amount, SUM(tax_amount) AS tax_amount").
+		Where("seller_id = ? AND created_at >= ? AND created_at < ?", uId, from, to).
+		Group("tax_country, tax_region, tax_rate").
+		Order("tax_country, tax_region, tax_rate").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
 }
 
 func NewTransactionRepository(db *gorm.DB) TransactionRepository {
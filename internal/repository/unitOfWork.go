@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Tx holds repositories bound to a single database transaction.
+type Tx struct {
+	Users        UserRepository
+	Catalog      CatalogRepository
+	Transactions TransactionRepository
+	Promotions   PromotionRepository
+	Shipping     ShippingRepository
+	Ledger       LedgerRepository
+	Wishlists    WishlistRepository
+	Inventory    InventoryRepository
+}
+
+// UnitOfWork runs multi-step operations atomically. Do commits when fn
+// returns nil and rolls every change back when it returns an error or
+// panics.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(tx Tx) error) error
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func (u unitOfWork) Do(ctx context.Context, fn func(tx Tx) error) error {
+	return u.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		return fn(Tx{
+			Users:        NewUserRepository(db),
+			Catalog:      NewCatalogRepository(db),
+			Transactions: NewTransactionRepository(db),
+			Promotions:   NewPromotionRepository(db),
+			Shipping:     NewShippingRepository(db),
+			Ledger:       NewLedgerRepository(db),
+			Wishlists:    NewWishlistRepository(db),
+			Inventory:    NewInventoryRepository(db),
+		})
+	})
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return unitOfWork{
+		db: db,
+	}
+}
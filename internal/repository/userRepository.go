@@ -1,141 +1,197 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"go-ecommerce-app/internal/domain"
-	"log"
+	"log/slog"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
-	CreateUser(u domain.User) (domain.User, error)
-	FindUser(email string) (domain.User, error)
-	FindUserByID(id uint) (domain.User, error)
-	UpdateUser(id uint, u domain.User) (domain.User, error)
-
-	CreateBankAccount(e domain.BankAccount) error
-
-	FindCartItems(uId uint) ([]domain.Cart, error)
-	FindCartItem(uId uint, pId uint) (domain.Cart, error)
-	CreateCart(e domain.Cart) error
-	UpdateCart(e domain.Cart) error
-	DeleteCartById(id uint) error
-	DeleteCartItems(uId uint) error
+	CreateUser(ctx context.Context, u domain.User) (domain.User, error)
+	FindUser(ctx context.Context, email string) (domain.User, error)
+	FindUserByID(ctx context.Context, id uint) (domain.User, error)
+	UpdateUser(ctx context.Context, id uint, u domain.User) (domain.User, error)
+
+	CreateBankAccount(ctx context.Context, e domain.BankAccount) error
+
+	FindCartItems(ctx context.Context, uId uint) ([]domain.Cart, error)
+	FindCartItem(ctx context.Context, uId uint, pId uint) (domain.Cart, error)
+	CreateCart(ctx context.Context, e domain.Cart) error
+	UpdateCart(ctx context.Context, e domain.Cart) error
+	DeleteCartById(ctx context.Context, id uint) error
+	DeleteCartItems(ctx context.Context, uId uint) error
+
+	// Guest cart
+	FindGuestCartItems(ctx context.Context, token string) ([]domain.Cart, error)
+	FindGuestCartItem(ctx context.Context, token string, pId uint) (domain.Cart, error)
+	DeleteGuestCartItems(ctx context.Context, token string) error
+	DeleteExpiredGuestCarts(ctx context.Context, before time.Time) (int64, error)
 
 	// Order
-	CreateOrder(o domain.Order) error
-	FindOrders(uId uint) ([]domain.Order, error)
-	FindOrderById(id uint, uId uint) (domain.Order, error)
+	CreateOrder(ctx context.Context, o *domain.Order) error
+	FindOrders(ctx context.Context, uId uint, p Pagination, f OrderFilter) ([]domain.Order, PageInfo, error)
+	FindOrderById(ctx context.Context, id uint, uId uint) (domain.Order, error)
+	// CancelOrder marks the order and its shipments cancelled. Only orders
+	// none of whose shipments has left can be cancelled, and only once.
+	CancelOrder(ctx context.Context, id uint) error
 
 	// Profile
-	CreateProfile(e domain.Address) error
-	UpdateProfile(e domain.Address) error
+	CreateProfile(ctx context.Context, e domain.Address) error
+	UpdateProfile(ctx context.Context, e domain.Address) error
 }
 
 type userRepository struct {
 	db *gorm.DB
 }
 
+var orderSortFields = map[string]string{
+	"id":         "id",
+	"amount":     "amount",
+	"created_at": "created_at",
+}
+
+type OrderFilter struct {
+	Status string
+}
+
+func (f OrderFilter) Apply(db *gorm.DB) *gorm.DB {
+	if len(f.Status) > 0 {
+		db = db.Where("status = ?", f.Status)
+	}
+
+	return db
+}
+
 func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r userRepository) CreateUser(user domain.User) (domain.User, error) {
-	err := r.db.Create(&user).Error
+func (r userRepository) CreateUser(ctx context.Context, user domain.User) (domain.User, error) {
+	err := r.db.WithContext(ctx).Create(&user).Error
 
 	if err != nil {
-		log.Printf("Error creating user: %v", err)
+		slog.ErrorContext(ctx, "creating user failed", "error", err)
 		return domain.User{}, errors.New("failed to create user")
 	}
 
 	return user, nil
 }
 
-func (r userRepository) FindUser(email string) (domain.User, error) {
+func (r userRepository) FindUser(ctx context.Context, email string) (domain.User, error) {
 	var user domain.User
 
-	err := r.db.Preload("Address").First(&user, "email = ?", email).Error
+	err := r.db.WithContext(ctx).Preload("Address").First(&user, "email = ?", email).Error
 	if err != nil {
-		log.Printf("Find user error %v", err)
+		slog.WarnContext(ctx, "finding user failed", "error", err)
 		return domain.User{}, errors.New("user does not exist")
 	}
 
 	return user, nil
 }
 
-func (r userRepository) FindUserByID(id uint) (domain.User, error) {
+func (r userRepository) FindUserByID(ctx context.Context, id uint) (domain.User, error) {
 	var user domain.User
 
-	err := r.db.Preload("Address").
+	err := r.db.WithContext(ctx).Preload("Address").
 		Preload("Cart").
 		Preload("Orders").
 		First(&user, id).Error
 	if err != nil {
-		log.Printf("Find user error %v", err)
+		slog.WarnContext(ctx, "finding user failed", "error", err)
 		return domain.User{}, errors.New("user does not exist")
 	}
 
 	return user, nil
 }
 
-func (r userRepository) UpdateUser(id uint, u domain.User) (domain.User, error) {
+func (r userRepository) UpdateUser(ctx context.Context, id uint, u domain.User) (domain.User, error) {
 	var user domain.User
 
-	err := r.db.Model(&user).Clauses(clause.Returning{}).Where("id = ?", id).Updates(u).Error
+	err := r.db.WithContext(ctx).Model(&user).Clauses(clause.Returning{}).Where("id = ?", id).Updates(u).Error
 	if err != nil {
-		log.Printf("Update user error %v", err)
+		slog.ErrorContext(ctx, "updating user failed", "error", err)
 		return domain.User{}, errors.New("failed to update user")
 	}
 
 	return user, nil
 }
 
-func (r userRepository) CreateBankAccount(e domain.BankAccount) error {
-	return r.db.Create(&e).Error
+func (r userRepository) CreateBankAccount(ctx context.Context, e domain.BankAccount) error {
+	return r.db.WithContext(ctx).Create(&e).Error
 }
 
 // CreateCart implements UserRepository.
-func (r *userRepository) CreateCart(c domain.Cart) error {
-	return r.db.Create(&c).Error
+func (r *userRepository) CreateCart(ctx context.Context, c domain.Cart) error {
+	return r.db.WithContext(ctx).Create(&c).Error
 }
 
 // DeleteCartById implements UserRepository.
-func (r *userRepository) DeleteCartById(id uint) error {
-	err := r.db.Delete(&domain.Cart{}, id).Error
+func (r *userRepository) DeleteCartById(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Delete(&domain.Cart{}, id).Error
 	return err
 }
 
 // DeleteCartItems implements UserRepository.
-func (r *userRepository) DeleteCartItems(uId uint) error {
-	err := r.db.Where("user_id = ?", uId).Delete(&domain.Cart{}).Error
+func (r *userRepository) DeleteCartItems(ctx context.Context, uId uint) error {
+	err := r.db.WithContext(ctx).Where("user_id = ?", uId).Delete(&domain.Cart{}).Error
 	return err
 }
 
 // FindCartItem implements UserRepository.
-func (r *userRepository) FindCartItem(uId uint, pId uint) (domain.Cart, error) {
+func (r *userRepository) FindCartItem(ctx context.Context, uId uint, pId uint) (domain.Cart, error) {
 	cartItem := domain.Cart{}
-	err := r.db.Where("user_id = ? AND product_id=?", uId, pId).First(&cartItem).Error
+	err := r.db.WithContext(ctx).Where("user_id = ? AND product_id=?", uId, pId).First(&cartItem).Error
 	return cartItem, err
 }
 
 // FindCartItems implements UserRepository.
-func (r *userRepository) FindCartItems(uId uint) ([]domain.Cart, error) {
+func (r *userRepository) FindCartItems(ctx context.Context, uId uint) ([]domain.Cart, error) {
 	var carts []domain.Cart
-	err := r.db.Where("user_id = ?", uId).Find(&carts).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", uId).Find(&carts).Error
 	return carts, err
 }
 
 // UpdateCart implements UserRepository.
-func (r *userRepository) UpdateCart(c domain.Cart) error {
+func (r *userRepository) UpdateCart(ctx context.Context, c domain.Cart) error {
 	var cart domain.Cart
-	err := r.db.Model(&cart).Clauses(clause.Returning{}).Where("id = ?", c.ID).Updates(c).Error
+	err := r.db.WithContext(ctx).Model(&cart).Clauses(clause.Returning{}).Where("id = ?", c.ID).Select("*").Omit("created_at").Updates(c).Error
 	return err
 }
 
-func (r userRepository) CreateProfile(e domain.Address) error {
-	err := r.db.Create(&e).Error
+func (r *userRepository) FindGuestCartItems(ctx context.Context, token string) ([]domain.Cart, error) {
+	var carts []domain.Cart
+	err := r.db.WithContext(ctx).Where("user_id = 0 AND cart_token = ?", token).Find(&carts).Error
+	return carts, err
+}
+
+func (r *userRepository) FindGuestCartItem(ctx context.Context, token string, pId uint) (domain.Cart, error) {
+	cartItem := domain.Cart{}
+	err := r.db.WithContext(ctx).Where("user_id = 0 AND cart_token = ? AND product_id = ?", token, pId).First(&cartItem).Error
+	return cartItem, err
+}
+
+func (r *userRepository) DeleteGuestCartItems(ctx context.Context, token string) error {
+	return r.db.WithContext(ctx).Where("user_id = 0 AND cart_token = ?", token).Delete(&domain.Cart{}).Error
+}
+
+// DeleteExpiredGuestCarts removes the guest carts none of whose lines has
+// been touched since before. A cart still in use keeps all its lines.
+func (r *userRepository) DeleteExpiredGuestCarts(ctx context.Context, before time.Time) (int64, error) {
+	expired := r.db.Model(&domain.Cart{}).Select("cart_token").
+		Where("user_id = 0 AND cart_token <> ''").
+		Group("cart_token").
+		Having("MAX(updated_at) < ?", before)
+	result := r.db.WithContext(ctx).Where("user_id = 0 AND cart_token IN (?)", expired).Delete(&domain.Cart{})
+	return result.RowsAffected, result.Error
+}
+
+func (r userRepository) CreateProfile(ctx context.Context, e domain.Address) error {
+	err := r.db.WithContext(ctx).Create(&e).Error
 	if err != nil {
 		return errors.New("failed to create Profile")
 	}
@@ -143,8 +199,8 @@ func (r userRepository) CreateProfile(e domain.Address) error {
 	return nil
 }
 
-func (r userRepository) UpdateProfile(e domain.Address) error {
-	err := r.db.Where("user_id = ?", e.UserID).Updates(e).Error
+func (r userRepository) UpdateProfile(ctx context.Context, e domain.Address) error {
+	err := r.db.WithContext(ctx).Where("user_id = ?", e.UserID).Updates(e).Error
 	if err != nil {
 		return errors.New("failed to update Profile")
 	}
@@ -152,31 +208,93 @@ func (r userRepository) UpdateProfile(e domain.Address) error {
 	return nil
 }
 
-func (r userRepository) CreateOrder(o domain.Order) error {
-	err := r.db.Create(&o).Error
+// CreateOrder stores the order with its sub-orders and links every item to
+// the sub-order of its seller.
+func (r userRepository) CreateOrder(ctx context.Context, o *domain.Order) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(o).Error; err != nil {
+			return err
+		}
+
+		for _, sub := range o.SubOrders {
+			err := tx.Model(&domain.OrderItem{}).
+				Where("order_id = ? AND seller_id = ?", o.ID, sub.SellerId).
+				Update("sub_order_id", sub.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
 		return errors.New("failed to create order")
 	}
 
+	for i := range o.Items {
+		for _, sub := range o.SubOrders {
+			if o.Items[i].SellerId == sub.SellerId {
+				o.Items[i].SubOrderId = sub.ID
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r userRepository) CancelOrder(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var shipped int64
+		err := tx.Model(&domain.OrderShipment{}).
+			Where("order_id = ? AND status NOT IN ?", id, []string{domain.ShipmentStatusPending, domain.ShipmentStatusCancelled}).
+			Count(&shipped).Error
+		if err != nil {
+			return err
+		}
+		if shipped > 0 {
+			return domain.ConflictError("only orders that have not shipped yet can be cancelled")
+		}
+
+		result := tx.Model(&domain.Order{}).
+			Where("id = ? AND COALESCE(status, '') <> ?", id, domain.OrderStatusCancelled).
+			Update("status", domain.OrderStatusCancelled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return domain.ConflictError("the order has already been cancelled")
+		}
+
+		return tx.Model(&domain.OrderShipment{}).Where("order_id = ?", id).
+			Update("status", domain.ShipmentStatusCancelled).Error
+	})
+	if err != nil {
+		var appErr *domain.AppError
+		if errors.As(err, &appErr) {
+			return err
+		}
+		return errors.New("failed to cancel order")
+	}
+
 	return nil
 }
 
-func (r userRepository) FindOrders(uId uint) ([]domain.Order, error) {
+func (r userRepository) FindOrders(ctx context.Context, uId uint, p Pagination, f OrderFilter) ([]domain.Order, PageInfo, error) {
 	var orders []domain.Order
-	err := r.db.Where("user_id = ?", uId).Find(&orders).Error
+	info, err := paginate(r.db.WithContext(ctx).Where("user_id = ?", uId), p, f, orderSortFields, &orders)
 	if err != nil {
-		log.Printf("Find orders error %v", err)
-		return nil, errors.New("failed to fetch orders")
+		slog.ErrorContext(ctx, "finding orders failed", "error", err)
+		return nil, PageInfo{}, err
 	}
 
-	return orders, nil
+	return orders, info, nil
 }
 
-func (r userRepository) FindOrderById(id uint, uId uint) (domain.Order, error) {
+func (r userRepository) FindOrderById(ctx context.Context, id uint, uId uint) (domain.Order, error) {
 	var order domain.Order
-	err := r.db.Preload("Items").Where("id = ? AND user_id=?", id, uId).First(&order).Error
+	err := r.db.WithContext(ctx).Preload("Items").Preload("Discounts").Preload("Shipments").Preload("SubOrders").Where("id = ? AND user_id=?", id, uId).First(&order).Error
 	if err != nil {
-		log.Printf("Find order error %v", err)
+		slog.WarnContext(ctx, "finding order failed", "error", err)
 		return domain.Order{}, errors.New("order does not exist")
 	}
 
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WishlistRepository interface {
+	CreateWishlist(ctx context.Context, e *domain.Wishlist) error
+	UpdateWishlist(ctx context.Context, e *domain.Wishlist) error
+	DeleteWishlist(ctx context.Context, id uint) error
+	FindWishlists(ctx context.Context, userId uint) ([]domain.Wishlist, error)
+	FindWishlistById(ctx context.Context, id uint) (*domain.Wishlist, error)
+	FindWishlistByName(ctx context.Context, userId uint, name string) (*domain.Wishlist, error)
+	FindWishlistByToken(ctx context.Context, token string) (*domain.Wishlist, error)
+
+	CreateWishlistItem(ctx context.Context, e *domain.WishlistItem) error
+	FindWishlistItem(ctx context.Context, wishlistId uint, productId uint) (*domain.WishlistItem, error)
+	DeleteWishlistItem(ctx context.Context, id uint) error
+
+	// FindProductWatchers lists the users with the product on any of their
+	// wishlists.
+	FindProductWatchers(ctx context.Context, productId uint) ([]domain.User, error)
+}
+
+type wishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) WishlistRepository {
+	return &wishlistRepository{db: db}
+}
+
+func (r *wishlistRepository) CreateWishlist(ctx context.Context, e *domain.Wishlist) error {
+	err := r.db.WithContext(ctx).Omit(clause.Associations).Create(e).Error
+	if err != nil {
+		return errors.New("failed to create wishlist")
+	}
+
+	return nil
+}
+
+func (r *wishlistRepository) UpdateWishlist(ctx context.Context, e *domain.Wishlist) error {
+	err := r.db.WithContext(ctx).Omit(clause.Associations).Save(e).Error
+	if err != nil {
+		return errors.New("failed to update wishlist")
+	}
+
+	return nil
+}
+
+func (r *wishlistRepository) DeleteWishlist(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("wishlist_id = ?", id).Delete(&domain.WishlistItem{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&domain.Wishlist{}, id).Error
+	})
+	if err != nil {
+		return errors.New("failed to delete wishlist")
+	}
+
+	return nil
+}
+
+func (r *wishlistRepository) FindWishlists(ctx context.Context, userId uint) ([]domain.Wishlist, error) {
+	var lists []domain.Wishlist
+	err := r.db.WithContext(ctx).Preload("Items").Where("user_id = ?", userId).Order("id").Find(&lists).Error
+	if err != nil {
+		return nil, errors.New("failed to find wishlists")
+	}
+
+	for i := range lists {
+		if err = r.fillItems(ctx, lists[i].Items); err != nil {
+			return nil, err
+		}
+	}
+
+	return lists, nil
+}
+
+func (r *wishlistRepository) FindWishlistById(ctx context.Context, id uint) (*domain.Wishlist, error) {
+	return r.findWishlist(ctx, "id = ?", id)
+}
+
+func (r *wishlistRepository) FindWishlistByName(ctx context.Context, userId uint, name string) (*domain.Wishlist, error) {
+	return r.findWishlist(ctx, "user_id = ? AND name = ?", userId, name)
+}
+
+func (r *wishlistRepository) FindWishlistByToken(ctx context.Context, token string) (*domain.Wishlist, error) {
+	return r.findWishlist(ctx, "share_token = ?", token)
+}
+
+func (r *wishlistRepository) CreateWishlistItem(ctx context.Context, e *domain.WishlistItem) error {
+	err := r.db.WithContext(ctx).Create(e).Error
+	if err != nil {
+		return errors.New("failed to add product to wishlist")
+	}
+
+	return nil
+}
+
+func (r *wishlistRepository) FindWishlistItem(ctx context.Context, wishlistId uint, productId uint) (*domain.WishlistItem, error) {
+	var item *domain.WishlistItem
+	err := r.db.WithContext(ctx).First(&item, "wishlist_id = ? AND product_id = ?", wishlistId, productId).Error
+	if err != nil {
+		return nil, errors.New("wishlist item not found")
+	}
+
+	return item, nil
+}
+
+func (r *wishlistRepository) DeleteWishlistItem(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Delete(&domain.WishlistItem{}, id).Error
+	if err != nil {
+		return errors.New("failed to remove product from wishlist")
+	}
+
+	return nil
+}
+
+func (r *wishlistRepository) FindProductWatchers(ctx context.Context, productId uint) ([]domain.User, error) {
+	watchers := r.db.Model(&domain.WishlistItem{}).
+		Select("wishlists.user_id").
+		Joins("JOIN wishlists ON wishlists.id = wishlist_items.wishlist_id").
+		Where("wishlist_items.product_id = ?", productId)
+
+	var users []domain.User
+	err := r.db.WithContext(ctx).Where("id IN (?)", watchers).Find(&users).Error
+	if err != nil {
+		return nil, errors.New("failed to find wishlist watchers")
+	}
+
+	return users, nil
+}
+
+func (r *wishlistRepository) findWishlist(ctx context.Context, query string, args ...interface{}) (*domain.Wishlist, error) {
+	var list *domain.Wishlist
+	err := r.db.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Where(query, args...).First(&list).Error
+	if err != nil {
+		return nil, errors.New("wishlist not found")
+	}
+
+	if err = r.fillItems(ctx, list.Items); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// fillItems sets the current price and availability of the items from the
+// catalog. Items whose product was removed show as out of stock.
+func (r *wishlistRepository) fillItems(ctx context.Context, items []domain.WishlistItem) error {
+	if len(items) < 1 {
+		return nil
+	}
+
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductId
+	}
+
+	var products []domain.Product
+	err := r.db.WithContext(ctx).Select("id", "price", "stock").Where("id IN ?", ids).Find(&products).Error
+	if err != nil {
+		return errors.New("failed to find wishlist products")
+	}
+
+	byId := make(map[uint]domain.Product, len(products))
+	for _, p := range products {
+		byId[p.ID] = p
+	}
+	for i := range items {
+		p, ok := byId[items[i].ProductId]
+		items[i].CurrentPrice = p.Price
+		items[i].InStock = ok && p.Stock > 0
+	}
+
+	return nil
+}
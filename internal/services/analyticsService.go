@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTopProducts = 10
+	maxTopProducts     = 50
+)
+
+const (
+	SalesExportSeries   = "series"
+	SalesExportProducts = "products"
+)
+
+var salesBuckets = map[string]bool{"day": true, "week": true, "month": true}
+
+type AnalyticsService struct {
+	Repo repository.AnalyticsRepository
+	Auth helper.Auth
+}
+
+func (s AnalyticsService) GetSalesReport(ctx context.Context, seller domain.User, q dto.SalesReportQuery) (*dto.SalesReport, error) {
+	q, err := salesReportQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.Repo.FindSalesSummary(ctx, seller.ID, q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Orders > 0 {
+		summary.AverageOrderValue = roundCents(summary.Revenue / float64(summary.Orders))
+	}
+	summary.Revenue = roundCents(summary.Revenue)
+	summary.RefundedRevenue = roundCents(summary.RefundedRevenue)
+	summary.ConversionRate = ratio(summary.Buyers, summary.Shoppers)
+	summary.RefundRate = ratio(summary.RefundedOrders, summary.Orders)
+
+	series, err := s.Repo.FindSalesSeries(ctx, seller.ID, q.From, q.To, q.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	for i := range series {
+		series[i].Revenue = roundCents(series[i].Revenue)
+	}
+
+	products, err := s.Repo.FindTopProducts(ctx, seller.ID, q.From, q.To, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		products[i].Revenue = roundCents(products[i].Revenue)
+	}
+
+	return &dto.SalesReport{
+		From:        q.From,
+		To:          q.To,
+		Bucket:      q.Bucket,
+		Summary:     *summary,
+		Series:      series,
+		TopProducts: products,
+	}, nil
+}
+
+// ExportSales writes the report's series, or its top products, as CSV.
+func (s AnalyticsService) ExportSales(ctx context.Context, seller domain.User, q dto.SalesReportQuery, report string, w io.Writer) error {
+	if report != SalesExportSeries && report != SalesExportProducts {
+		return domain.ValidationError("report must be series or products")
+	}
+
+	sales, err := s.GetSalesReport(ctx, seller, q)
+	if err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(w)
+	if report == SalesExportProducts {
+		_ = out.Write([]string{"product_id", "name", "orders", "units", "revenue"})
+		for _, p := range sales.TopProducts {
+			_ = out.Write([]string{
+				strconv.FormatUint(uint64(p.ProductId), 10),
+				p.Name,
+				strconv.FormatInt(p.Orders, 10),
+				strconv.FormatInt(p.Units, 10),
+				strconv.FormatFloat(p.Revenue, 'f', 2, 64),
+			})
+		}
+	} else {
+		_ = out.Write([]string{"period", "orders", "units", "revenue"})
+		for _, b := range sales.Series {
+			_ = out.Write([]string{
+				b.Period.Format(time.DateOnly),
+				strconv.FormatInt(b.Orders, 10),
+				strconv.FormatInt(b.Units, 10),
+				strconv.FormatFloat(b.Revenue, 'f', 2, 64),
+			})
+		}
+	}
+	out.Flush()
+
+	return out.Error()
+}
+
+func salesReportQuery(q dto.SalesReportQuery) (dto.SalesReportQuery, error) {
+	if !q.From.Before(q.To) {
+		return q, domain.ValidationError("report start must be before its end")
+	}
+
+	if len(q.Bucket) < 1 {
+		q.Bucket = "day"
+	}
+	if !salesBuckets[q.Bucket] {
+		return q, domain.ValidationError("bucket must be day, week or month")
+	}
+
+	if q.Limit < 1 {
+		q.Limit = defaultTopProducts
+	}
+	q.Limit = min(q.Limit, maxTopProducts)
+
+	return q, nil
+}
+
+// ratio is part over whole to four decimals, zero when there is no whole.
+func ratio(part int64, whole int64) float64 {
+	if whole < 1 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 10000
+}
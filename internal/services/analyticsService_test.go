@@ -0,0 +1,82 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/repository"
+	"testing"
+	"time"
+)
+
+type fakeAnalyticsRepository struct {
+	repository.AnalyticsRepository
+
+	summary dto.SalesSummary
+	series  []dto.SalesBucket
+}
+
+func (r *fakeAnalyticsRepository) FindSalesSummary(ctx context.Context, sellerId uint, from time.Time, to time.Time) (*dto.SalesSummary, error) {
+	summary := r.summary
+	return &summary, nil
+}
+
+func (r *fakeAnalyticsRepository) FindSalesSeries(ctx context.Context, sellerId uint, from time.Time, to time.Time, bucket string) ([]dto.SalesBucket, error) {
+	return r.series, nil
+}
+
+func (r *fakeAnalyticsRepository) FindTopProducts(ctx context.Context, sellerId uint, from time.Time, to time.Time, limit int) ([]dto.TopProduct, error) {
+	return nil, nil
+}
+
+func TestSalesReportQuery(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	q, err := salesReportQuery(dto.SalesReportQuery{From: from, To: to, Limit: 500})
+	if err != nil || q.Bucket != "day" || q.Limit != maxTopProducts {
+		t.Fatalf("salesReportQuery() = %+v, %v; want daily buckets and at most %d products", q, err, maxTopProducts)
+	}
+
+	invalid := []dto.SalesReportQuery{
+		{From: to, To: from},
+		{From: from, To: to, Bucket: "hour"},
+	}
+	for _, q := range invalid {
+		if _, err := salesReportQuery(q); !errors.Is(err, domain.ErrValidation) {
+			t.Fatalf("salesReportQuery(%+v) error = %v; want a validation error", q, err)
+		}
+	}
+}
+
+func TestSalesReportRatesAndExport(t *testing.T) {
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	svc := AnalyticsService{Repo: &fakeAnalyticsRepository{
+		summary: dto.SalesSummary{Orders: 3, Revenue: 65, Buyers: 2, Shoppers: 3, RefundedOrders: 1},
+		series:  []dto.SalesBucket{{Period: march, Orders: 3, Units: 4, Revenue: 65.004}},
+	}}
+	q := dto.SalesReportQuery{From: march, To: march.AddDate(0, 1, 0), Bucket: "month"}
+
+	report, err := svc.GetSalesReport(context.Background(), domain.User{ID: 1}, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := report.Summary
+	if s.AverageOrderValue != 21.67 || s.ConversionRate != 0.6667 || s.RefundRate != 0.3333 {
+		t.Fatalf("summary = %+v; want 21.67 per order, 0.6667 converted and 0.3333 refunded", s)
+	}
+
+	var out bytes.Buffer
+	if err := svc.ExportSales(context.Background(), domain.User{ID: 1}, q, SalesExportSeries, &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "period,orders,units,revenue\n2025-03-01,3,4,65.00\n"; out.String() != want {
+		t.Fatalf("export = %q; want %q", out.String(), want)
+	}
+
+	if err := svc.ExportSales(context.Background(), domain.User{ID: 1}, q, "orders", &out); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("export of an unknown report error = %v; want a validation error", err)
+	}
+}
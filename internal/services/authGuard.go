@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/repository"
+	"log/slog"
+	"time"
+)
+
+// failureWindow is how long failed attempts count towards a lockout.
+const failureWindow = 24 * time.Hour
+
+// AuthGuard throttles sensitive actions per account and locks an account out
+// for progressively longer after repeated failures. A guard without a store
+// allows everything.
+type AuthGuard struct {
+	Store        repository.RateLimitStore
+	AccountLimit int
+	Window       time.Duration
+	Threshold    int
+	Lockout      time.Duration
+	MaxLockout   time.Duration
+}
+
+// Allow rejects the attempt while key is locked out or over its rate limit.
+func (g AuthGuard) Allow(ctx context.Context, key string) error {
+	if g.Store == nil {
+		return nil
+	}
+
+	failures, err := g.Store.Find(ctx, "fail:"+key)
+	if err != nil {
+		return err
+	}
+	if wait := time.Until(failures.LockedUntil); wait > 0 {
+		return domain.RateLimitedError("too many failed attempts, please try again later", wait)
+	}
+
+	if g.AccountLimit < 1 {
+		return nil
+	}
+
+	hits, err := g.Store.Hit(ctx, "rate:"+key, g.Window)
+	if err != nil {
+		return err
+	}
+	if hits.Hits > g.AccountLimit {
+		return domain.RateLimitedError("too many attempts, please try again later", time.Until(hits.WindowEnd))
+	}
+
+	return nil
+}
+
+// Fail records a failed attempt and returns cause, or a rate limit error once
+// the failure locks key out. Every failure past the threshold doubles the
+// lockout, up to MaxLockout.
+func (g AuthGuard) Fail(ctx context.Context, key string, cause error) error {
+	if g.Store == nil || g.Threshold < 1 {
+		return cause
+	}
+
+	failures, err := g.Store.Hit(ctx, "fail:"+key, failureWindow)
+	if err != nil {
+		slog.ErrorContext(ctx, "recording failed attempt failed", "key", key, "error", err)
+		return cause
+	}
+	if failures.Hits < g.Threshold {
+		return cause
+	}
+
+	lockout := g.Lockout
+	for i := g.Threshold; i < failures.Hits && lockout < g.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if g.MaxLockout > 0 && lockout > g.MaxLockout {
+		lockout = g.MaxLockout
+	}
+
+	if err = g.Store.Lock(ctx, "fail:"+key, time.Now().Add(lockout)); err != nil {
+		slog.ErrorContext(ctx, "locking out failed", "key", key, "error", err)
+		return cause
+	}
+
+	return domain.RateLimitedError("too many failed attempts, please try again later", lockout)
+}
+
+// Succeed clears the failures recorded for key.
+func (g AuthGuard) Succeed(ctx context.Context, key string) {
+	if g.Store == nil {
+		return
+	}
+
+	if err := g.Store.Reset(ctx, "fail:"+key); err != nil {
+		slog.ErrorContext(ctx, "clearing failed attempts failed", "key", key, "error", err)
+	}
+}
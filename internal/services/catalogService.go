@@ -1,34 +1,47 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"go-ecommerce-app/configs"
 	"go-ecommerce-app/internal/domain"
 	"go-ecommerce-app/internal/dto"
 	"go-ecommerce-app/internal/helper"
 	"go-ecommerce-app/internal/repository"
+	"go-ecommerce-app/internal/telemetry"
 )
 
 type CatalogService struct {
-	Repo   repository.CatalogRepository
-	Auth   helper.Auth
-	Config configs.AppConfig
+	Repo        repository.CatalogRepository
+	Inventory   InventoryService
+	Wishlists   WishlistService
+	StockAlerts StockAlertService
+	UnitOfWork  repository.UnitOfWork
+	Auth        helper.Auth
+	Config      configs.AppConfig
 }
 
-func (s CatalogService) CreateCategory(input dto.CreateCategoryRequestDto) error {
-	err := s.Repo.CreateCategory(&domain.Category{
+func (s CatalogService) CreateCategory(ctx context.Context, input dto.CreateCategoryRequestDto) error {
+	if len(input.Name) < 1 {
+		return domain.ValidationError("category name is required")
+	}
+
+	err := s.Repo.CreateCategory(ctx, &domain.Category{
 		Name:         input.Name,
 		ImageUrl:     input.ImageURL,
 		DisplayOrder: input.DisplayOrder,
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	telemetry.CatalogChanges.WithLabelValues("category", "created").Inc()
+	return nil
 }
 
-func (s CatalogService) EditCategory(id int, input dto.CreateCategoryRequestDto) (*domain.Category, error) {
-	existCat, err := s.Repo.FindCategoryByID(id)
+func (s CatalogService) EditCategory(ctx context.Context, id int, input dto.CreateCategoryRequestDto) (*domain.Category, error) {
+	existCat, err := s.Repo.FindCategoryByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("category not found")
+		return nil, err
 	}
 
 	if len(input.Name) > 0 {
@@ -44,33 +57,33 @@ func (s CatalogService) EditCategory(id int, input dto.CreateCategoryRequestDto)
 		existCat.DisplayOrder = input.DisplayOrder
 	}
 
-	updatedCat, err := s.Repo.EditCategory(existCat)
+	updatedCat, err := s.Repo.EditCategory(ctx, existCat)
+	if err == nil {
+		telemetry.CatalogChanges.WithLabelValues("category", "updated").Inc()
+	}
 
 	return updatedCat, err
 }
 
-func (s CatalogService) DeleteCategory(id int) error {
-	err := s.Repo.DeleteCategory(id)
+func (s CatalogService) DeleteCategory(ctx context.Context, id int) error {
+	err := s.Repo.DeleteCategory(ctx, id)
 	if err != nil {
-		return errors.New("category not found to delete")
+		return domain.NotFoundError("category not found to delete")
 	}
 
+	telemetry.CatalogChanges.WithLabelValues("category", "deleted").Inc()
+
 	return nil
 }
 
-func (s CatalogService) GetCategories() ([]*domain.Category, error) {
-	categories, err := s.Repo.FindCategories()
-	if err != nil {
-		return nil, errors.New("no categories found")
-	}
-
-	return categories, nil
+func (s CatalogService) GetCategories(ctx context.Context, p repository.Pagination, f repository.CategoryFilter) ([]*domain.Category, repository.PageInfo, error) {
+	return s.Repo.FindCategories(ctx, p, f)
 }
 
-func (s CatalogService) GetCategory(id int) (*domain.Category, error) {
-	cat, err := s.Repo.FindCategoryByID(id)
+func (s CatalogService) GetCategory(ctx context.Context, id int) (*domain.Category, error) {
+	cat, err := s.Repo.FindCategoryByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("category not found")
+		return nil, err
 	}
 
 	return cat, nil
@@ -78,29 +91,72 @@ func (s CatalogService) GetCategory(id int) (*domain.Category, error) {
 
 ////// Products ///////
 
-func (s CatalogService) CreateProduct(input dto.CreateProductRequest, user domain.User) error {
-	err := s.Repo.CreateProduct(&domain.Product{
+func (s CatalogService) CreateProduct(ctx context.Context, input dto.CreateProductRequest, user domain.User) error {
+	if len(input.Name) < 1 {
+		return domain.ValidationError("product name is required")
+	}
+	if input.Price <= 0 {
+		return domain.ValidationError("product price must be greater than zero")
+	}
+	if input.Stock < 0 {
+		return domain.ValidationError("product stock cannot be negative")
+	}
+	if input.Weight < 0 {
+		return domain.ValidationError("product weight cannot be negative")
+	}
+	if input.LowStockThreshold != nil && *input.LowStockThreshold < 0 {
+		return domain.ValidationError("low stock threshold cannot be negative")
+	}
+
+	product := &domain.Product{
 		Name:        input.Name,
 		Description: input.Description,
 		Price:       input.Price,
 		CategoryId:  input.CategoryId,
 		ImageUrl:    input.ImageUrl,
 		UserId:      int(user.ID),
-		Stock:       uint(input.Stock),
+		Weight:      input.Weight,
+		TaxCategory: taxCategory(input.TaxCategory),
+	}
+	if input.LowStockThreshold != nil {
+		product.LowStockThreshold = uint(*input.LowStockThreshold)
+	}
+
+	// the product is listed empty and its initial stock booked as a movement
+	err := s.atomically(ctx, func(svc CatalogService) error {
+		if err := svc.Repo.CreateProduct(ctx, product); err != nil {
+			return err
+		}
+		if input.Stock < 1 {
+			return nil
+		}
+
+		_, err := svc.Inventory.Repo.AdjustStock(ctx, &domain.InventoryMovement{
+			ProductId: product.ID,
+			Quantity:  input.Stock,
+			Reason:    domain.InventoryReasonInitial,
+			ActorId:   user.ID,
+		})
+		return err
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	telemetry.CatalogChanges.WithLabelValues("product", "created").Inc()
+	return nil
 }
 
-func (s CatalogService) EditProduct(id int, input dto.CreateProductRequest, user domain.User) (*domain.Product, error) {
-	existProduct, err := s.Repo.FindProductByID(id)
+func (s CatalogService) EditProduct(ctx context.Context, id int, input dto.CreateProductRequest, user domain.User) (*domain.Product, error) {
+	existProduct, err := s.Repo.FindProductByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("product not found")
+		return nil, err
 	}
 
 	if existProduct.UserId != int(user.ID) {
-		return nil, errors.New("you are not authorized to update this product")
+		return nil, domain.ForbiddenError("you are not authorized to update this product")
 	}
+	oldPrice := existProduct.Price
 
 	if len(input.Name) > 0 {
 		existProduct.Name = input.Name
@@ -114,72 +170,186 @@ func (s CatalogService) EditProduct(id int, input dto.CreateProductRequest, user
 	if input.Price > 0 {
 		existProduct.Price = input.Price
 	}
+	if input.Weight > 0 {
+		existProduct.Weight = input.Weight
+	}
+	if len(input.TaxCategory) > 0 {
+		existProduct.TaxCategory = input.TaxCategory
+	}
+	if input.LowStockThreshold != nil {
+		if *input.LowStockThreshold < 0 {
+			return nil, domain.ValidationError("low stock threshold cannot be negative")
+		}
+		existProduct.LowStockThreshold = uint(*input.LowStockThreshold)
+	}
 
-	updatedProduct, err := s.Repo.EditProduct(existProduct)
+	updatedProduct, err := s.Repo.EditProduct(ctx, existProduct)
+	if err != nil {
+		return nil, err
+	}
+	telemetry.CatalogChanges.WithLabelValues("product", "updated").Inc()
+	s.Wishlists.NotifyPriceDrop(ctx, *updatedProduct, oldPrice)
 
-	return updatedProduct, err
+	return updatedProduct, nil
 }
 
-func (s CatalogService) DeleteProduct(id int, user domain.User) error {
-	existProduct, err := s.Repo.FindProductByID(id)
+func (s CatalogService) DeleteProduct(ctx context.Context, id int, user domain.User) error {
+	existProduct, err := s.Repo.FindProductByID(ctx, id)
 	if err != nil {
-		return errors.New("product not found")
+		return err
 	}
 
 	if existProduct.UserId != int(user.ID) {
-		return errors.New("you are not authorized to delete this product")
+		return domain.ForbiddenError("you are not authorized to delete this product")
 	}
 
-	err = s.Repo.DeleteProduct(int(existProduct.ID))
+	err = s.Repo.DeleteProduct(ctx, int(existProduct.ID))
 	if err != nil {
-		return errors.New("product not found to delete")
+		return domain.NotFoundError("product not found to delete")
 	}
 
+	telemetry.CatalogChanges.WithLabelValues("product", "deleted").Inc()
+
 	return nil
 }
 
-func (s CatalogService) GetProducts() ([]*domain.Product, error) {
-	products, err := s.Repo.FindProducts()
+func (s CatalogService) GetProducts(ctx context.Context, p repository.Pagination, f repository.ProductFilter) ([]*domain.Product, repository.PageInfo, error) {
+	return s.Repo.FindProducts(ctx, p, f)
+}
+
+func (s CatalogService) GetProductById(ctx context.Context, id int) (*domain.Product, error) {
+	product, err := s.Repo.FindProductByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("no products found")
+		return nil, err
 	}
 
-	return products, nil
+	return product, nil
 }
 
-func (s CatalogService) GetProductById(id int) (*domain.Product, error) {
-	product, err := s.Repo.FindProductByID(id)
+func (s CatalogService) GetSellerProducts(ctx context.Context, id int, p repository.Pagination, f repository.ProductFilter) ([]*domain.Product, repository.PageInfo, error) {
+	return s.Repo.FindSellerProducts(ctx, id, p, f)
+}
+
+// UpdateProductStock sets the stock the seller counted, recording the
+// difference as a manual inventory movement.
+func (s CatalogService) UpdateProductStock(ctx context.Context, id int, input dto.UpdateStockRequest, user domain.User) (*domain.Product, error) {
+	if input.Stock < 0 {
+		return nil, domain.ValidationError("product stock cannot be negative")
+	}
+
+	product, err := s.Repo.FindProductByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("product not found")
+		return nil, err
 	}
 
-	return product, nil
-}
+	if product.UserId != int(user.ID) {
+		return nil, domain.ForbiddenError("you are not authorized to update this product")
+	}
 
-func (s CatalogService) GetSellerProducts(id int) ([]*domain.Product, error) {
-	products, err := s.Repo.FindSellerProducts(id)
+	movement := &domain.InventoryMovement{
+		ProductId: product.ID,
+		Reason:    domain.InventoryReasonManual,
+		ActorId:   user.ID,
+		Note:      input.Note,
+	}
+	editProduct, err := s.Inventory.Repo.SetStock(ctx, movement, uint(input.Stock))
 	if err != nil {
-		return nil, errors.New("no products found for this seller")
+		return nil, err
 	}
 
-	return products, err
+	telemetry.CatalogChanges.WithLabelValues("product", "stock_updated").Inc()
+	s.notifyRestock(ctx, *editProduct, movement)
+
+	return editProduct, nil
 }
 
-func (s CatalogService) UpdateProductStock(e domain.Product) (*domain.Product, error) {
-	product, err := s.Repo.FindProductByID(int(e.ID))
-	if err != nil {
-		return nil, errors.New("product not found")
+// ImportStock sets the stock of several of the seller's products at once,
+// recording each change as an import. Either every line is applied or none.
+func (s CatalogService) ImportStock(ctx context.Context, input dto.ImportStockRequest, user domain.User) ([]domain.Product, error) {
+	if len(input.Items) == 0 {
+		return nil, domain.ValidationError("stock import has no items")
 	}
 
-	if product.UserId != e.UserId {
-		return nil, errors.New("you are not authorized to update this product")
+	for _, line := range input.Items {
+		if line.Stock < 0 {
+			return nil, domain.ValidationError("product stock cannot be negative")
+		}
+
+		product, err := s.Repo.FindProductByID(ctx, int(line.ProductId))
+		if err != nil {
+			return nil, err
+		}
+		if product.UserId != int(user.ID) {
+			return nil, domain.ForbiddenError("you are not authorized to update this product")
+		}
 	}
 
-	product.Stock = e.Stock
-	editProduct, err := s.Repo.EditProduct(product)
+	var products []domain.Product
+	var movements []*domain.InventoryMovement
+	err := s.atomically(ctx, func(svc CatalogService) error {
+		products, movements = nil, nil
+		for _, line := range input.Items {
+			movement := &domain.InventoryMovement{
+				ProductId: line.ProductId,
+				Reason:    domain.InventoryReasonImport,
+				ActorId:   user.ID,
+				Note:      input.Note,
+			}
+			product, err := svc.Inventory.Repo.SetStock(ctx, movement, uint(line.Stock))
+			if err != nil {
+				return err
+			}
+
+			products = append(products, *product)
+			movements = append(movements, movement)
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return editProduct, err
+	telemetry.CatalogChanges.WithLabelValues("product", "stock_imported").Inc()
+	for i := range products {
+		s.notifyRestock(ctx, products[i], movements[i])
+	}
+
+	return products, nil
+}
+
+// notifyRestock tells the product's stock alert subscribers and wishlist
+// watchers when the movement brought it back in stock.
+func (s CatalogService) notifyRestock(ctx context.Context, product domain.Product, movement *domain.InventoryMovement) {
+	previous := int(product.Stock) - movement.Quantity
+	if previous == 0 && product.Stock > 0 {
+		// a subscriber who also wishlisted the product is texted once
+		told := s.StockAlerts.NotifyBackInStock(ctx, product)
+		s.Wishlists.NotifyBackInStock(ctx, product, told)
+	}
+}
+
+// atomically runs fn with a copy of the service whose catalog and inventory
+// repositories share one transaction, or on the service itself without a
+// unit of work.
+func (s CatalogService) atomically(ctx context.Context, fn func(svc CatalogService) error) error {
+	if s.UnitOfWork == nil {
+		return fn(s)
+	}
+
+	return s.UnitOfWork.Do(ctx, func(tx repository.Tx) error {
+		svc := s
+		svc.Repo = tx.Catalog
+		svc.Inventory.Repo = tx.Inventory
+
+		return fn(svc)
+	})
+}
+
+func taxCategory(category string) string {
+	if len(category) < 1 {
+		return domain.DefaultTaxCategory
+	}
+
+	return category
 }
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"testing"
+)
+
+func TestGetProductTellsMissingFromFailed(t *testing.T) {
+	svc := CatalogService{Repo: &fakeCatalogRepository{}}
+	if _, err := svc.GetProductById(context.Background(), 1); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("GetProductById() of a missing product error = %v; want not found", err)
+	}
+
+	svc = CatalogService{Repo: &fakeCatalogRepository{err: errors.New("failed to find product")}}
+	if _, err := svc.GetProductById(context.Background(), 1); err == nil || errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("GetProductById() with the database down error = %v; want an internal error", err)
+	}
+}
@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/repository"
+	"go-ecommerce-app/pkg/payment"
+	"slices"
+	"sync"
+	"time"
+)
+
+// The fakes below keep their data in memory. Each embeds the repository
+// interface it stands in for, so a test calling a method the fake does not
+// implement fails loudly with a nil pointer panic.
+
+type fakeUserRepository struct {
+	repository.UserRepository
+
+	mu            sync.Mutex
+	users         map[uint]domain.User
+	expiredBefore time.Time
+}
+
+func newFakeUserRepository() *fakeUserRepository {
+	return &fakeUserRepository{users: map[uint]domain.User{}}
+}
+
+func (r *fakeUserRepository) CreateUser(ctx context.Context, u domain.User) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u.ID = uint(len(r.users) + 1)
+	if len(u.UserType) < 1 {
+		u.UserType = domain.BUYER
+	}
+	r.users[u.ID] = u
+
+	return u, nil
+}
+
+func (r *fakeUserRepository) FindUser(ctx context.Context, email string) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+
+	return domain.User{}, errors.New("user does not exist")
+}
+
+func (r *fakeUserRepository) FindUserByID(ctx context.Context, id uint) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return domain.User{}, errors.New("user does not exist")
+	}
+
+	return u, nil
+}
+
+// UpdateUser copies the non-zero fields of u, like the GORM implementation.
+func (r *fakeUserRepository) UpdateUser(ctx context.Context, id uint, u domain.User) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return domain.User{}, errors.New("failed to update user")
+	}
+	if len(u.FirstName) > 0 {
+		user.FirstName = u.FirstName
+	}
+	if len(u.LastName) > 0 {
+		user.LastName = u.LastName
+	}
+	if len(u.Phone) > 0 {
+		user.Phone = u.Phone
+	}
+	if len(u.Code) > 0 {
+		user.Code = u.Code
+	}
+	if !u.Expiry.IsZero() {
+		user.Expiry = u.Expiry
+	}
+	if u.Verified {
+		user.Verified = true
+	}
+	if len(u.UserType) > 0 {
+		user.UserType = u.UserType
+	}
+	r.users[id] = user
+
+	return user, nil
+}
+
+func (r *fakeUserRepository) DeleteExpiredGuestCarts(ctx context.Context, before time.Time) (int64, error) {
+	r.expiredBefore = before
+	return 0, nil
+}
+
+type fakeTransactionRepository struct {
+	repository.TransactionRepository
+
+	payments []domain.Payment
+	updated  []domain.Payment
+}
+
+func (r *fakeTransactionRepository) CreatePayment(ctx context.Context, p *domain.Payment) error {
+	p.ID = uint(len(r.payments) + 1)
+	r.payments = append(r.payments, *p)
+	return nil
+}
+
+func (r *fakeTransactionRepository) FindOpenPayments(ctx context.Context, before time.Time) ([]domain.Payment, error) {
+	var open []domain.Payment
+	for _, p := range r.payments {
+		if (p.Status == domain.PaymentStatusInitial || p.Status == domain.PaymentStatusPending) && !payment.IsOffline(p.Provider) {
+			open = append(open, p)
+		}
+	}
+	return open, nil
+}
+
+func (r *fakeTransactionRepository) FindUnorderedPayment(ctx context.Context, uId uint) (*domain.Payment, error) {
+	for i := len(r.payments) - 1; i >= 0; i-- {
+		p := r.payments[i]
+		if p.UserId == uId && p.OrderedAt == nil && p.Status != domain.PaymentStatusFailed && p.Status != domain.PaymentStatusExpired {
+			return &p, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *fakeTransactionRepository) UpdatePayment(ctx context.Context, p *domain.Payment) error {
+	r.updated = append(r.updated, *p)
+	for i := range r.payments {
+		if r.payments[i].ID == p.ID {
+			r.payments[i] = *p
+		}
+	}
+	return nil
+}
+
+type fakeLedgerRepository struct {
+	repository.LedgerRepository
+
+	balances []dto.SellerBalance
+	accounts map[uint]domain.BankAccount
+	batches  []domain.PayoutBatch
+	settled  []domain.Payout
+}
+
+func (r *fakeLedgerRepository) PayableBalances(ctx context.Context) ([]dto.SellerBalance, error) {
+	return r.balances, nil
+}
+
+func (r *fakeLedgerRepository) FindBankAccount(ctx context.Context, sellerId uint) (*domain.BankAccount, error) {
+	account, ok := r.accounts[sellerId]
+	if !ok {
+		return nil, errors.New("failed to find bank account")
+	}
+	return &account, nil
+}
+
+func (r *fakeLedgerRepository) CreatePayoutBatch(ctx context.Context, batch *domain.PayoutBatch) error {
+	batch.ID = uint(len(r.batches) + 1)
+	r.batches = append(r.batches, *batch)
+	return nil
+}
+
+func (r *fakeLedgerRepository) SettlePayout(ctx context.Context, id uint, status domain.PayoutStatus) (*domain.Payout, error) {
+	payout := domain.Payout{ID: id, Status: status}
+	r.settled = append(r.settled, payout)
+	return &payout, nil
+}
+
+type fakeCatalogRepository struct {
+	repository.CatalogRepository
+
+	products map[uint]domain.Product
+	err      error
+}
+
+func (r *fakeCatalogRepository) FindProductByID(ctx context.Context, id int) (*domain.Product, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	product, ok := r.products[uint(id)]
+	if !ok {
+		return nil, domain.NotFoundError("product not found")
+	}
+	return &product, nil
+}
+
+func (r *fakeCatalogRepository) FindSellerProducts(ctx context.Context, id int, p repository.Pagination, f repository.ProductFilter) ([]*domain.Product, repository.PageInfo, error) {
+	var products []*domain.Product
+	for _, product := range r.products {
+		if product.UserId == id {
+			products = append(products, &product)
+		}
+	}
+	return products, repository.PageInfo{Total: int64(len(products))}, nil
+}
+
+type fakePromotionRepository struct {
+	repository.PromotionRepository
+
+	coupons     map[uint]domain.Coupon
+	redemptions []domain.CouponRedemption
+	cart        map[uint]domain.CartCoupon
+}
+
+func newFakePromotionRepository() *fakePromotionRepository {
+	return &fakePromotionRepository{coupons: map[uint]domain.Coupon{}, cart: map[uint]domain.CartCoupon{}}
+}
+
+func (r *fakePromotionRepository) CreateCoupon(ctx context.Context, e *domain.Coupon) error {
+	e.ID = uint(len(r.coupons) + 1)
+	r.coupons[e.ID] = *e
+	return nil
+}
+
+func (r *fakePromotionRepository) FindCouponByID(ctx context.Context, id uint) (*domain.Coupon, error) {
+	coupon, ok := r.coupons[id]
+	if !ok {
+		return nil, errors.New("coupon not found")
+	}
+	return &coupon, nil
+}
+
+func (r *fakePromotionRepository) FindCouponByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	for _, coupon := range r.coupons {
+		if coupon.Code == code {
+			return &coupon, nil
+		}
+	}
+	return nil, errors.New("coupon not found")
+}
+
+func (r *fakePromotionRepository) UpdateCoupon(ctx context.Context, e *domain.Coupon) error {
+	r.coupons[e.ID] = *e
+	return nil
+}
+
+func (r *fakePromotionRepository) CountUserRedemptions(ctx context.Context, couponId uint, uId uint) (int64, error) {
+	var count int64
+	for _, redemption := range r.redemptions {
+		if redemption.CouponId == couponId && redemption.UserId == uId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CreateRedemption claims a use of the coupon like the GORM implementation.
+func (r *fakePromotionRepository) CreateRedemption(ctx context.Context, e *domain.CouponRedemption) error {
+	coupon := r.coupons[e.CouponId]
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return domain.ConflictError("coupon usage limit reached")
+	}
+	coupon.UsedCount++
+	r.coupons[e.CouponId] = coupon
+	r.redemptions = append(r.redemptions, *e)
+	return nil
+}
+
+func (r *fakePromotionRepository) FindCartCoupon(ctx context.Context, uId uint) (*domain.CartCoupon, error) {
+	cartCoupon, ok := r.cart[uId]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &cartCoupon, nil
+}
+
+func (r *fakePromotionRepository) SaveCartCoupon(ctx context.Context, e domain.CartCoupon) error {
+	r.cart[e.UserId] = e
+	return nil
+}
+
+func (r *fakePromotionRepository) DeleteCartCoupon(ctx context.Context, uId uint) error {
+	delete(r.cart, uId)
+	return nil
+}
+
+type fakeTaxRepository struct {
+	repository.TaxRepository
+
+	rates []domain.TaxRate
+}
+
+func (r *fakeTaxRepository) FindRates(ctx context.Context, country string) ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	for _, rate := range r.rates {
+		if rate.Country == country {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+type fakeStockAlertRepository struct {
+	repository.StockAlertRepository
+
+	alerts []domain.StockAlert
+	users  map[uint]domain.User
+}
+
+func (r *fakeStockAlertRepository) CreateStockAlert(ctx context.Context, e *domain.StockAlert) error {
+	e.ID = uint(len(r.alerts) + 1)
+	r.alerts = append(r.alerts, *e)
+	return nil
+}
+
+func (r *fakeStockAlertRepository) FindStockAlert(ctx context.Context, productId uint, userId uint) (*domain.StockAlert, error) {
+	for _, alert := range r.alerts {
+		if alert.ProductId == productId && alert.UserId == userId {
+			return &alert, nil
+		}
+	}
+	return nil, errors.New("stock alert not found")
+}
+
+func (r *fakeStockAlertRepository) DeleteStockAlert(ctx context.Context, id uint) error {
+	r.alerts = slices.DeleteFunc(r.alerts, func(a domain.StockAlert) bool { return a.ID == id })
+	return nil
+}
+
+func (r *fakeStockAlertRepository) FindStockAlertSubscribers(ctx context.Context, productId uint) ([]domain.User, error) {
+	var users []domain.User
+	for _, alert := range r.alerts {
+		if alert.ProductId == productId {
+			users = append(users, r.users[alert.UserId])
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeStockAlertRepository) DeleteProductStockAlerts(ctx context.Context, productId uint) error {
+	r.alerts = slices.DeleteFunc(r.alerts, func(a domain.StockAlert) bool { return a.ProductId == productId })
+	return nil
+}
+
+type fakeStorefrontRepository struct {
+	repository.StorefrontRepository
+
+	stores map[uint]domain.Storefront
+}
+
+func (r *fakeStorefrontRepository) SaveStorefront(ctx context.Context, e *domain.Storefront) error {
+	r.stores[e.UserId] = *e
+	return nil
+}
+
+func (r *fakeStorefrontRepository) FindStorefrontBySeller(ctx context.Context, sellerId uint) (*domain.Storefront, error) {
+	store, ok := r.stores[sellerId]
+	if !ok {
+		return nil, errors.New("storefront not found")
+	}
+	return &store, nil
+}
+
+func (r *fakeStorefrontRepository) FindStorefrontBySlug(ctx context.Context, slug string) (*domain.Storefront, error) {
+	for _, store := range r.stores {
+		if store.Slug == slug {
+			return &store, nil
+		}
+	}
+	return nil, errors.New("storefront not found")
+}
+
+type fakeInventoryRepository struct {
+	repository.InventoryRepository
+
+	stock     map[uint]uint
+	movements []domain.InventoryMovement
+}
+
+// AdjustStock refuses to take out more than is left, like the GORM
+// implementation.
+func (r *fakeInventoryRepository) AdjustStock(ctx context.Context, m *domain.InventoryMovement) (*domain.Product, error) {
+	stock, ok := r.stock[m.ProductId]
+	if !ok {
+		return nil, domain.NotFoundError("product not found")
+	}
+	if int(stock)+m.Quantity < 0 {
+		return nil, domain.ConflictError("not enough stock left")
+	}
+
+	r.stock[m.ProductId] = uint(int(stock) + m.Quantity)
+	m.StockAfter = r.stock[m.ProductId]
+	r.movements = append(r.movements, *m)
+	return &domain.Product{ID: m.ProductId, Stock: m.StockAfter}, nil
+}
+
+type fakeShippingRepository struct {
+	repository.ShippingRepository
+
+	profiles   []domain.ShippingProfile
+	selections []domain.CartShipping
+	shipments  []domain.OrderShipment
+}
+
+func (r *fakeShippingRepository) FindSellerProfiles(ctx context.Context, sellerId uint, activeOnly bool) ([]domain.ShippingProfile, error) {
+	var profiles []domain.ShippingProfile
+	for _, p := range r.profiles {
+		if p.SellerId == sellerId && (p.Active || !activeOnly) {
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles, nil
+}
+
+func (r *fakeShippingRepository) FindCartShipping(ctx context.Context, uId uint) ([]domain.CartShipping, error) {
+	return r.selections, nil
+}
+
+func (r *fakeShippingRepository) FindShipment(ctx context.Context, orderId uint, sellerId uint) (*domain.OrderShipment, error) {
+	for _, sh := range r.shipments {
+		if sh.OrderId == orderId && sh.SellerId == sellerId {
+			return &sh, nil
+		}
+	}
+	return nil, errors.New("shipment not found")
+}
+
+func (r *fakeShippingRepository) UpdateShipment(ctx context.Context, e *domain.OrderShipment) error {
+	for i := range r.shipments {
+		if r.shipments[i].ID == e.ID {
+			r.shipments[i] = *e
+		}
+	}
+	return nil
+}
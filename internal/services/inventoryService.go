@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+)
+
+type InventoryService struct {
+	Repo repository.InventoryRepository
+	Auth helper.Auth
+}
+
+// RecordOrder takes the ordered quantities out of stock. It fails with a
+// conflict when an item has run out, and returns the products as they are
+// afterwards, in the order of the items.
+func (s InventoryService) RecordOrder(ctx context.Context, order *domain.Order) ([]domain.Product, error) {
+	var products []domain.Product
+	for _, item := range order.Items {
+		product, err := s.Repo.AdjustStock(ctx, &domain.InventoryMovement{
+			ProductId: item.ProductId,
+			Quantity:  -item.Qty,
+			Reason:    domain.InventoryReasonOrder,
+			ActorId:   order.UserId,
+			OrderId:   order.ID,
+		})
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, domain.ConflictError(fmt.Sprintf("not enough of %s left in stock", item.Name))
+		}
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *product)
+	}
+
+	return products, nil
+}
+
+// RestockOrder puts the ordered quantities back in stock, recording why they
+// came back.
+func (s InventoryService) RestockOrder(ctx context.Context, order *domain.Order, reason domain.InventoryReason) error {
+	for _, item := range order.Items {
+		_, err := s.Repo.AdjustStock(ctx, &domain.InventoryMovement{
+			ProductId: item.ProductId,
+			Quantity:  item.Qty,
+			Reason:    reason,
+			ActorId:   order.UserId,
+			OrderId:   order.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s InventoryService) GetMovements(ctx context.Context, seller domain.User, p repository.Pagination, f repository.MovementFilter) ([]domain.InventoryMovement, repository.PageInfo, error) {
+	return s.Repo.FindMovements(ctx, seller.ID, p, f)
+}
+
+func (s InventoryService) GetStockDrift(ctx context.Context, seller domain.User) ([]dto.StockDrift, error) {
+	return s.Repo.FindStockDrift(ctx, seller.ID)
+}
+
+func (s InventoryService) Reconcile(ctx context.Context, seller domain.User) ([]dto.StockDrift, error) {
+	return s.Repo.ReconcileStock(ctx, seller.ID, seller.ID)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"testing"
+)
+
+func TestRecordOrderTakesItemsOutOfStock(t *testing.T) {
+	repo := &fakeInventoryRepository{stock: map[uint]uint{1: 5, 2: 1}}
+	svc := InventoryService{Repo: repo}
+	order := &domain.Order{ID: 9, UserId: 7, Items: []domain.OrderItem{
+		{ProductId: 1, Name: "Go in Action", Qty: 2},
+		{ProductId: 2, Name: "Bookmark", Qty: 1},
+	}}
+
+	products, err := svc.RecordOrder(context.Background(), order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 2 || products[0].Stock != 3 || products[1].Stock != 0 {
+		t.Fatalf("products = %+v; want 3 and 0 left", products)
+	}
+	for _, m := range repo.movements {
+		if m.Reason != domain.InventoryReasonOrder || m.OrderId != 9 || m.ActorId != 7 || m.Quantity >= 0 {
+			t.Fatalf("movement = %+v; want stock taken out for order 9 by the buyer", m)
+		}
+	}
+}
+
+func TestRecordOrderNamesTheSoldOutItem(t *testing.T) {
+	svc := InventoryService{Repo: &fakeInventoryRepository{stock: map[uint]uint{1: 1}}}
+	order := &domain.Order{Items: []domain.OrderItem{{ProductId: 1, Name: "Go in Action", Qty: 2}}}
+
+	_, err := svc.RecordOrder(context.Background(), order)
+	if !errors.Is(err, domain.ErrConflict) || err.Error() != "not enough of Go in Action left in stock" {
+		t.Fatalf("RecordOrder() error = %v; want a conflict naming the item", err)
+	}
+}
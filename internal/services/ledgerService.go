@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-ecommerce-app/configs"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"math"
+	"sort"
+)
+
+type LedgerService struct {
+	Repo   repository.LedgerRepository
+	Auth   helper.Auth
+	Config configs.AppConfig
+}
+
+// SplitOrder divides a priced cart into one sub-order per seller. Seller
+// coupons are charged to their seller, platform coupons are shared in
+// proportion to what each seller sells or charges for shipping.
+func (s LedgerService) SplitOrder(summary dto.CartSummary) []domain.SubOrder {
+	subOrders := map[uint]*domain.SubOrder{}
+	var sellers []uint
+	itemValue := map[uint]float64{}
+	shippingValue := map[uint]float64{}
+
+	for _, item := range summary.Items {
+		sub, ok := subOrders[item.SellerId]
+		if !ok {
+			sub = &domain.SubOrder{SellerId: item.SellerId}
+			subOrders[item.SellerId] = sub
+			sellers = append(sellers, item.SellerId)
+		}
+		sub.SubTotal += item.Price * float64(item.Qty)
+		itemValue[item.SellerId] += item.Price * float64(item.Qty)
+	}
+	sort.Slice(sellers, func(i, j int) bool { return sellers[i] < sellers[j] })
+
+	sellerOf := map[uint]uint{}
+	for _, item := range summary.Items {
+		sellerOf[item.ID] = item.SellerId
+	}
+	for _, t := range summary.Taxes {
+		subOrders[sellerOf[t.CartItemId]].TaxAmount += t.Amount
+	}
+
+	for _, sh := range summary.Shipping {
+		if sh.Selected != nil && subOrders[sh.SellerId] != nil {
+			subOrders[sh.SellerId].ShippingAmount = sh.Selected.Cost
+			shippingValue[sh.SellerId] = sh.Selected.Cost
+		}
+	}
+
+	itemDiscount := map[uint]float64{}
+	for _, d := range summary.Discounts {
+		weights := itemValue
+		if d.Type == string(domain.CouponTypeFreeShipping) {
+			weights = shippingValue
+		}
+
+		for sellerId, amount := range allocate(d.Amount, sellers, weights, d.SellerId) {
+			subOrders[sellerId].DiscountAmount += amount
+			if d.Type != string(domain.CouponTypeFreeShipping) {
+				itemDiscount[sellerId] += amount
+			}
+		}
+	}
+
+	var result []domain.SubOrder
+	for _, sellerId := range sellers {
+		sub := subOrders[sellerId]
+		sub.SubTotal = roundCents(sub.SubTotal)
+		sub.TaxAmount = roundCents(sub.TaxAmount)
+		sub.DiscountAmount = roundCents(sub.DiscountAmount)
+		sub.Amount = roundCents(math.Max(0, sub.SubTotal-sub.DiscountAmount+sub.ShippingAmount+sub.TaxAmount))
+
+		sub.CommissionRate = s.Config.Payout.CommissionRate
+		sub.Commission = roundCents(math.Max(0, sub.SubTotal-itemDiscount[sellerId]) * sub.CommissionRate / 100)
+		sub.Commission = math.Min(sub.Commission, sub.Amount)
+		sub.SellerAmount = roundCents(sub.Amount - sub.Commission)
+
+		result = append(result, *sub)
+	}
+
+	return result
+}
+
+// RecordOrder posts the paid order to the ledger: the amount received for
+// each sub-order is credited to its seller, less the platform commission.
+func (s LedgerService) RecordOrder(ctx context.Context, order *domain.Order) error {
+	entries := orderEntries(order)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return s.Repo.PostEntries(ctx, entries)
+}
+
+// ReverseOrder takes a cancelled order back out of the ledger by posting
+// the opposite of what RecordOrder posted for it.
+func (s LedgerService) ReverseOrder(ctx context.Context, order *domain.Order) error {
+	entries := orderEntries(order)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	for i := range entries {
+		entries[i].Reference += "-cancellation"
+		entries[i].Description = "cancelled " + entries[i].Description
+		entries[i].Debit, entries[i].Credit = entries[i].Credit, entries[i].Debit
+	}
+
+	return s.Repo.PostEntries(ctx, entries)
+}
+
+// orderEntries are the postings crediting each sub-order to its seller.
+func orderEntries(order *domain.Order) []domain.LedgerEntry {
+	var entries []domain.LedgerEntry
+	for _, sub := range order.SubOrders {
+		reference := fmt.Sprintf("sub-order-%d", sub.ID)
+		description := fmt.Sprintf("order %s", order.OrderRefNumber)
+		entry := domain.LedgerEntry{
+			Reference:   reference,
+			SellerId:    sub.SellerId,
+			Description: description,
+			OrderId:     order.ID,
+			SubOrderId:  sub.ID,
+		}
+
+		if sub.Amount > 0 {
+			cash := entry
+			cash.Account = domain.LedgerAccountPlatformCash
+			cash.Debit = sub.Amount
+			entries = append(entries, cash)
+		}
+		if sub.SellerAmount > 0 {
+			payable := entry
+			payable.Account = domain.LedgerAccountSellerPayable
+			payable.Credit = sub.SellerAmount
+			entries = append(entries, payable)
+		}
+		if sub.Commission > 0 {
+			commission := entry
+			commission.Account = domain.LedgerAccountCommission
+			commission.Credit = sub.Commission
+			commission.Description = fmt.Sprintf("%g%% commission on order %s", sub.CommissionRate, order.OrderRefNumber)
+			entries = append(entries, commission)
+		}
+	}
+
+	return entries
+}
+
+func (s LedgerService) GetBalance(ctx context.Context, seller domain.User) (dto.SellerBalance, error) {
+	balance, err := s.Repo.SellerBalance(ctx, seller.ID)
+	if err != nil {
+		return dto.SellerBalance{}, err
+	}
+
+	pending, err := s.Repo.SumSellerPayouts(ctx, seller.ID, domain.PayoutStatusPending)
+	if err != nil {
+		return dto.SellerBalance{}, err
+	}
+
+	paid, err := s.Repo.SumSellerPayouts(ctx, seller.ID, domain.PayoutStatusPaid)
+	if err != nil {
+		return dto.SellerBalance{}, err
+	}
+
+	return dto.SellerBalance{
+		SellerId:       seller.ID,
+		Balance:        roundCents(balance),
+		PendingPayouts: roundCents(pending),
+		PaidOut:        roundCents(paid),
+	}, nil
+}
+
+func (s LedgerService) GetStatement(ctx context.Context, seller domain.User, p repository.Pagination) ([]domain.LedgerEntry, repository.PageInfo, error) {
+	return s.Repo.FindSellerEntries(ctx, seller.ID, p)
+}
+
+func (s LedgerService) GetPayouts(ctx context.Context, seller domain.User, p repository.Pagination) ([]domain.Payout, repository.PageInfo, error) {
+	return s.Repo.FindSellerPayouts(ctx, seller.ID, p)
+}
+
+// RunPayouts pays every seller balance out to the seller's bank account on
+// file. Sellers without a bank account keep their balance until they add one.
+// It returns nil when there is nothing to pay.
+func (s LedgerService) RunPayouts(ctx context.Context) (*domain.PayoutBatch, error) {
+	balances, err := s.Repo.PayableBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &domain.PayoutBatch{}
+	for _, b := range balances {
+		amount := roundCents(b.Balance)
+		if amount <= 0 {
+			continue
+		}
+
+		account, err := s.Repo.FindBankAccount(ctx, b.SellerId)
+		if err != nil {
+			continue
+		}
+
+		batch.Payouts = append(batch.Payouts, domain.Payout{
+			SellerId:      b.SellerId,
+			BankAccountId: account.ID,
+			BankAccount:   account.BankAccount,
+			SwiftCode:     account.SwiftCode,
+			Amount:        amount,
+			Status:        domain.PayoutStatusPending,
+		})
+		batch.Total += amount
+	}
+
+	if len(batch.Payouts) == 0 {
+		return nil, nil
+	}
+	batch.Count = len(batch.Payouts)
+	batch.Total = roundCents(batch.Total)
+
+	err = s.Repo.CreatePayoutBatch(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// SettlePayout records the bank's outcome for a pending payout. A failed
+// payout returns its amount to the seller's balance for the next run.
+func (s LedgerService) SettlePayout(ctx context.Context, id uint, status domain.PayoutStatus) (*domain.Payout, error) {
+	if status != domain.PayoutStatusPaid && status != domain.PayoutStatusFailed {
+		return nil, domain.ValidationError("status must be paid or failed")
+	}
+
+	return s.Repo.SettlePayout(ctx, id, status)
+}
+
+// allocate shares amount between sellers in proportion to weights. When
+// sellerId is set the whole amount goes to that seller. The last seller takes
+// the rounding remainder so the parts always add up to amount.
+func allocate(amount float64, sellers []uint, weights map[uint]float64, sellerId uint) map[uint]float64 {
+	parts := map[uint]float64{}
+	if sellerId > 0 {
+		if _, ok := weights[sellerId]; ok {
+			parts[sellerId] = amount
+		}
+		return parts
+	}
+
+	var total float64
+	var eligible []uint
+	for _, id := range sellers {
+		if weights[id] > 0 {
+			total += weights[id]
+			eligible = append(eligible, id)
+		}
+	}
+	if total <= 0 {
+		return parts
+	}
+
+	remaining := amount
+	for i, id := range eligible {
+		if i == len(eligible)-1 {
+			parts[id] = roundCents(remaining)
+			break
+		}
+		parts[id] = roundCents(amount * weights[id] / total)
+		remaining -= parts[id]
+	}
+
+	return parts
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"testing"
+)
+
+func TestRunPayoutsPaysSellersWithBankAccounts(t *testing.T) {
+	repo := &fakeLedgerRepository{
+		balances: []dto.SellerBalance{
+			{SellerId: 1, Balance: 120.504},
+			{SellerId: 2, Balance: 0},
+			{SellerId: 3, Balance: 80},
+		},
+		accounts: map[uint]domain.BankAccount{
+			1: {ID: 10, UserId: 1, BankAccount: 123456, SwiftCode: "BANKUS33"},
+			2: {ID: 20, UserId: 2, BankAccount: 654321, SwiftCode: "BANKUS33"},
+		},
+	}
+	svc := LedgerService{Repo: repo}
+
+	batch, err := svc.RunPayouts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch == nil || batch.Count != 1 {
+		t.Fatalf("batch = %+v; want one payout", batch)
+	}
+
+	p := batch.Payouts[0]
+	if p.SellerId != 1 || p.BankAccountId != 10 || p.Amount != 120.5 || p.Status != domain.PayoutStatusPending {
+		t.Fatalf("payout = %+v; want 120.5 pending to seller 1", p)
+	}
+	if len(repo.batches) != 1 {
+		t.Fatalf("stored %d batches; want 1", len(repo.batches))
+	}
+}
+
+func TestRunPayoutsWithNothingPayable(t *testing.T) {
+	svc := LedgerService{Repo: &fakeLedgerRepository{}}
+
+	batch, err := svc.RunPayouts(context.Background())
+	if err != nil || batch != nil {
+		t.Fatalf("RunPayouts() = %+v, %v; want no batch", batch, err)
+	}
+}
+
+func TestSettlePayoutOnlyAsPaidOrFailed(t *testing.T) {
+	repo := &fakeLedgerRepository{}
+	svc := LedgerService{Repo: repo}
+
+	if _, err := svc.SettlePayout(context.Background(), 1, domain.PayoutStatusPending); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("settling as pending error = %v; want a validation error", err)
+	}
+
+	payout, err := svc.SettlePayout(context.Background(), 1, domain.PayoutStatusFailed)
+	if err != nil || payout.Status != domain.PayoutStatusFailed || len(repo.settled) != 1 {
+		t.Fatalf("SettlePayout() = %+v, %v; want the payout failed", payout, err)
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/telemetry"
+	"go-ecommerce-app/pkg/notification"
+	"log/slog"
+	"sync"
+)
+
+// NotificationService texts users about events they asked to hear about. A
+// failed text is logged and counted but never fails the change behind it.
+type NotificationService struct {
+	Client notification.NotificationClient
+	// Background tracks the texts sent by NotifyInBackground so shutdown can
+	// wait for them. Without it they are sent before the call returns.
+	Background *sync.WaitGroup
+}
+
+// Notify texts message to every user with a phone number and returns how
+// many texts were sent.
+func (s NotificationService) Notify(ctx context.Context, kind string, users []domain.User, message string) int {
+	if s.Client == nil {
+		return 0
+	}
+
+	sent := 0
+	for _, u := range users {
+		if len(u.Phone) < 1 {
+			continue
+		}
+
+		if err := s.Client.SendSMS(ctx, u.Phone, message); err != nil {
+			telemetry.Notifications.WithLabelValues(kind, "failed").Inc()
+			slog.WarnContext(ctx, "sending notification failed", "kind", kind, "user_id", u.ID, "error", err)
+			continue
+		}
+
+		telemetry.Notifications.WithLabelValues(kind, "sent").Inc()
+		sent++
+	}
+
+	return sent
+}
+
+// NotifyInBackground texts like Notify without holding up the caller, for
+// alerts sent while a request is being served.
+func (s NotificationService) NotifyInBackground(ctx context.Context, kind string, users []domain.User, message string) {
+	if s.Background == nil {
+		s.Notify(ctx, kind, users, message)
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	s.Background.Add(1)
+	go func() {
+		defer s.Background.Done()
+		s.Notify(ctx, kind, users, message)
+	}()
+}
+
+// Wait blocks until every text sent in the background has gone out.
+func (s NotificationService) Wait() {
+	if s.Background != nil {
+		s.Background.Wait()
+	}
+}
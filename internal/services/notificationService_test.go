@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/pkg/notification"
+	"testing"
+)
+
+func TestNotifySkipsUsersWithoutPhone(t *testing.T) {
+	client := notification.NewFakeClient()
+	svc := NotificationService{Client: client}
+
+	sent := svc.Notify(context.Background(), "test", []domain.User{
+		{ID: 1, Phone: "+15550001"},
+		{ID: 2},
+		{ID: 3, Phone: "+15550003"},
+	}, "hello")
+	if sent != 2 {
+		t.Fatalf("Notify() = %d; want 2", sent)
+	}
+
+	messages := client.Messages()
+	if len(messages) != 2 || messages[0].Phone != "+15550001" || messages[1].Body != "hello" {
+		t.Fatalf("messages = %+v; want hello to both phones", messages)
+	}
+}
+
+func TestNotifyCountsOnlyDeliveredTexts(t *testing.T) {
+	client := notification.NewFakeClient()
+	client.Err = errors.New("provider down")
+	svc := NotificationService{Client: client}
+
+	if sent := svc.Notify(context.Background(), "test", []domain.User{{ID: 1, Phone: "+15550001"}}, "hello"); sent != 0 {
+		t.Fatalf("Notify() = %d; want 0 when sending fails", sent)
+	}
+}
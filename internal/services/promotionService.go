@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"math"
+	"strings"
+	"time"
+)
+
+type PromotionService struct {
+	Repo  repository.PromotionRepository
+	CRepo repository.CatalogRepository
+	Auth  helper.Auth
+}
+
+// CreateCoupon creates a coupon for the seller's products, or a platform
+// wide one when an admin creates it.
+func (s PromotionService) CreateCoupon(ctx context.Context, input dto.CreateCouponRequest, seller domain.User) (*domain.Coupon, error) {
+	code := strings.ToUpper(strings.TrimSpace(input.Code))
+	if len(code) < 3 {
+		return nil, domain.ValidationError("coupon code must be at least 3 characters")
+	}
+
+	couponType := domain.CouponType(input.Type)
+	switch couponType {
+	case domain.CouponTypePercentage:
+		if input.Value <= 0 || input.Value > 100 {
+			return nil, domain.ValidationError("percentage must be between 0 and 100")
+		}
+	case domain.CouponTypeFixed:
+		if input.Value <= 0 {
+			return nil, domain.ValidationError("fixed discount must be greater than zero")
+		}
+	case domain.CouponTypeFreeShipping:
+		input.Value = 0
+	default:
+		return nil, domain.ValidationError("coupon type must be percentage, fixed or free_shipping")
+	}
+
+	if input.UsageLimit < 0 || input.PerUserLimit < 0 || input.MinSpend < 0 {
+		return nil, domain.ValidationError("limits and minimum spend cannot be negative")
+	}
+
+	startsAt := time.Now()
+	if input.StartsAt != nil {
+		startsAt = *input.StartsAt
+	}
+	if input.EndsAt != nil && !input.EndsAt.After(startsAt) {
+		return nil, domain.ValidationError("coupon must end after it starts")
+	}
+
+	if input.CategoryId > 0 {
+		if _, err := s.CRepo.FindCategoryByID(ctx, int(input.CategoryId)); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.Repo.FindCouponByCode(ctx, code); err == nil {
+		return nil, domain.ConflictError("coupon code already exists")
+	}
+
+	coupon := &domain.Coupon{
+		Code:         code,
+		Description:  input.Description,
+		Type:         couponType,
+		Value:        input.Value,
+		SellerId:     couponOwner(seller),
+		CategoryId:   input.CategoryId,
+		MinSpend:     input.MinSpend,
+		UsageLimit:   input.UsageLimit,
+		PerUserLimit: input.PerUserLimit,
+		StartsAt:     startsAt,
+		EndsAt:       input.EndsAt,
+		Active:       true,
+	}
+
+	err := s.Repo.CreateCoupon(ctx, coupon)
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (s PromotionService) GetSellerCoupons(ctx context.Context, seller domain.User, p repository.Pagination) ([]domain.Coupon, repository.PageInfo, error) {
+	return s.Repo.FindSellerCoupons(ctx, couponOwner(seller), p)
+}
+
+func (s PromotionService) DeactivateCoupon(ctx context.Context, id uint, seller domain.User) error {
+	coupon, err := s.Repo.FindCouponByID(ctx, id)
+	if err != nil {
+		return domain.NotFoundError("coupon not found")
+	}
+
+	if coupon.SellerId != couponOwner(seller) {
+		return domain.ForbiddenError("you are not authorized to update this coupon")
+	}
+
+	coupon.Active = false
+	return s.Repo.UpdateCoupon(ctx, coupon)
+}
+
+// couponOwner is the seller a user's coupons are scoped to. Admins manage the
+// platform's coupons, which apply to every seller.
+func couponOwner(u domain.User) uint {
+	if u.UserType == domain.ADMIN {
+		return 0
+	}
+	return u.ID
+}
+
+// ApplyCoupon validates the code against the current cart and attaches it to
+// the user's cart, replacing any coupon applied before.
+func (s PromotionService) ApplyCoupon(ctx context.Context, code string, uId uint, items []domain.Cart) (dto.DiscountLine, error) {
+	coupon, err := s.Repo.FindCouponByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return dto.DiscountLine{}, domain.NotFoundError("coupon not found")
+	}
+
+	line, err := s.evaluate(ctx, coupon, uId, items, nil)
+	if err != nil {
+		return dto.DiscountLine{}, err
+	}
+
+	err = s.Repo.SaveCartCoupon(ctx, domain.CartCoupon{
+		UserId:   uId,
+		CouponId: coupon.ID,
+		Code:     coupon.Code,
+	})
+	if err != nil {
+		return dto.DiscountLine{}, errors.New("failed to apply coupon")
+	}
+
+	return line, nil
+}
+
+func (s PromotionService) RemoveCoupon(ctx context.Context, uId uint) error {
+	return s.Repo.DeleteCartCoupon(ctx, uId)
+}
+
+// CartDiscounts prices the coupon attached to the user's cart. shipping holds
+// the shipping cost per seller. A validation error means the coupon no longer
+// applies to the cart.
+func (s PromotionService) CartDiscounts(ctx context.Context, uId uint, items []domain.Cart, shipping map[uint]float64) ([]dto.DiscountLine, error) {
+	cartCoupon, err := s.Repo.FindCartCoupon(ctx, uId)
+	if err != nil {
+		return []dto.DiscountLine{}, nil
+	}
+
+	coupon, err := s.Repo.FindCouponByID(ctx, cartCoupon.CouponId)
+	if err != nil {
+		return nil, domain.ValidationError(fmt.Sprintf("coupon %s no longer exists", cartCoupon.Code))
+	}
+
+	line, err := s.evaluate(ctx, coupon, uId, items, shipping)
+	if err != nil {
+		return nil, err
+	}
+
+	return []dto.DiscountLine{line}, nil
+}
+
+// RedeemDiscounts records the discounts used by an order and detaches the
+// coupon from the cart.
+func (s PromotionService) RedeemDiscounts(ctx context.Context, uId uint, orderId uint, lines []dto.DiscountLine) error {
+	for _, line := range lines {
+		err := s.Repo.CreateRedemption(ctx, &domain.CouponRedemption{
+			CouponId: line.CouponId,
+			UserId:   uId,
+			OrderId:  orderId,
+			Discount: line.Amount,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.Repo.DeleteCartCoupon(ctx, uId)
+}
+
+func (s PromotionService) evaluate(ctx context.Context, coupon *domain.Coupon, uId uint, items []domain.Cart, shipping map[uint]float64) (dto.DiscountLine, error) {
+	now := time.Now()
+	if !coupon.Active || now.Before(coupon.StartsAt) || (coupon.EndsAt != nil && now.After(*coupon.EndsAt)) {
+		return dto.DiscountLine{}, domain.ValidationError(fmt.Sprintf("coupon %s is not active", coupon.Code))
+	}
+
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return dto.DiscountLine{}, domain.ValidationError(fmt.Sprintf("coupon %s has been fully redeemed", coupon.Code))
+	}
+
+	if coupon.PerUserLimit > 0 {
+		used, err := s.Repo.CountUserRedemptions(ctx, coupon.ID, uId)
+		if err != nil {
+			return dto.DiscountLine{}, err
+		}
+		if used >= int64(coupon.PerUserLimit) {
+			return dto.DiscountLine{}, domain.ValidationError(fmt.Sprintf("you have already used coupon %s", coupon.Code))
+		}
+	}
+
+	var eligible float64
+	var eligibleItems []uint
+	for _, item := range items {
+		if coupon.SellerId > 0 && item.SellerId != coupon.SellerId {
+			continue
+		}
+		if coupon.CategoryId > 0 {
+			product, err := s.CRepo.FindProductByID(ctx, int(item.ProductId))
+			if err != nil || product.CategoryId != coupon.CategoryId {
+				continue
+			}
+		}
+		eligible += item.Price * float64(item.Qty)
+		eligibleItems = append(eligibleItems, item.ID)
+	}
+
+	if eligible <= 0 {
+		return dto.DiscountLine{}, domain.ValidationError(fmt.Sprintf("coupon %s does not apply to any item in your cart", coupon.Code))
+	}
+	if eligible < coupon.MinSpend {
+		return dto.DiscountLine{}, domain.ValidationError(fmt.Sprintf("coupon %s requires a minimum spend of %.2f", coupon.Code, coupon.MinSpend))
+	}
+
+	line := dto.DiscountLine{
+		CouponId:    coupon.ID,
+		Type:        string(coupon.Type),
+		SellerId:    coupon.SellerId,
+		Code:        coupon.Code,
+		Description: coupon.Description,
+		CartItemIds: eligibleItems,
+	}
+
+	switch coupon.Type {
+	case domain.CouponTypePercentage:
+		line.Amount = eligible * coupon.Value / 100
+		if len(line.Description) < 1 {
+			line.Description = fmt.Sprintf("%g%% off", coupon.Value)
+		}
+	case domain.CouponTypeFixed:
+		line.Amount = math.Min(coupon.Value, eligible)
+		if len(line.Description) < 1 {
+			line.Description = fmt.Sprintf("%.2f off", coupon.Value)
+		}
+	case domain.CouponTypeFreeShipping:
+		for sellerId, cost := range shipping {
+			if coupon.SellerId == 0 || coupon.SellerId == sellerId {
+				line.Amount += cost
+			}
+		}
+		if len(line.Description) < 1 {
+			line.Description = "Free shipping"
+		}
+	}
+	line.Amount = roundCents(line.Amount)
+
+	return line, nil
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"testing"
+)
+
+func TestAdminsCreatePlatformCoupons(t *testing.T) {
+	repo := newFakePromotionRepository()
+	svc := PromotionService{Repo: repo, CRepo: &fakeCatalogRepository{}}
+	admin := domain.User{ID: 1, UserType: domain.ADMIN}
+	seller := domain.User{ID: 2, UserType: domain.SELLER}
+	ctx := context.Background()
+
+	coupon, err := svc.CreateCoupon(ctx, dto.CreateCouponRequest{Code: "welcome", Type: "percentage", Value: 10}, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coupon.SellerId != 0 {
+		t.Fatalf("admin coupon seller = %d; want 0 for every seller", coupon.SellerId)
+	}
+
+	items := []domain.Cart{
+		{ProductId: 1, SellerId: 2, Price: 20, Qty: 1},
+		{ProductId: 2, SellerId: 3, Price: 30, Qty: 1},
+	}
+	line, err := svc.ApplyCoupon(ctx, "WELCOME", 7, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line.Amount != 5 {
+		t.Fatalf("platform coupon discount = %v; want 10%% of both sellers' items", line.Amount)
+	}
+
+	// Sellers cannot touch the platform's coupons, admins can.
+	if err := svc.DeactivateCoupon(ctx, coupon.ID, seller); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("seller deactivating a platform coupon = %v; want forbidden", err)
+	}
+	if err := svc.DeactivateCoupon(ctx, coupon.ID, admin); err != nil {
+		t.Fatal(err)
+	}
+	if repo.coupons[coupon.ID].Active {
+		t.Fatal("platform coupon still active after the admin deactivated it")
+	}
+}
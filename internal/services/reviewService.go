@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-ecommerce-app/configs"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"strings"
+)
+
+const (
+	maxReviewLength = 2000
+	maxReviewImages = 5
+	maxFlagReason   = 500
+)
+
+type ReviewService struct {
+	Repo   repository.ReviewRepository
+	CRepo  repository.CatalogRepository
+	Auth   helper.Auth
+	Config configs.AppConfig
+}
+
+// CreateReview publishes the user's review of a product. Only buyers who had
+// the product delivered may review it, once.
+func (s ReviewService) CreateReview(ctx context.Context, productId uint, input dto.ReviewRequest, u domain.User) (*domain.Review, error) {
+	product, err := s.CRepo.FindProductByID(ctx, int(productId))
+	if err != nil {
+		return nil, err
+	}
+	if product.UserId == int(u.ID) {
+		return nil, domain.ForbiddenError("you cannot review your own product")
+	}
+
+	images, err := validateReview(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.Repo.FindUserReview(ctx, productId, u.ID); err == nil {
+		return nil, domain.ConflictError("you have already reviewed this product")
+	}
+
+	item, err := s.Repo.FindDeliveredOrderItem(ctx, u.ID, productId)
+	if err != nil {
+		return nil, domain.ForbiddenError("only buyers who received this product can review it")
+	}
+
+	review := &domain.Review{
+		ProductId:   productId,
+		UserId:      u.ID,
+		OrderItemId: item.ID,
+		Rating:      input.Rating,
+		Body:        strings.TrimSpace(input.Body),
+		Images:      images,
+		Status:      domain.ReviewStatusPublished,
+	}
+	if err = s.Repo.CreateReview(ctx, review); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (s ReviewService) UpdateReview(ctx context.Context, id uint, input dto.ReviewRequest, u domain.User) (*domain.Review, error) {
+	review, err := s.ownReview(ctx, id, u)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := validateReview(input)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Rating = input.Rating
+	review.Body = strings.TrimSpace(input.Body)
+	review.Images = images
+	if err = s.Repo.UpdateReview(ctx, review); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (s ReviewService) DeleteReview(ctx context.Context, id uint, u domain.User) error {
+	review, err := s.ownReview(ctx, id, u)
+	if err != nil {
+		return err
+	}
+
+	return s.Repo.DeleteReview(ctx, review)
+}
+
+func (s ReviewService) GetProductReviews(ctx context.Context, productId uint, p repository.Pagination) ([]domain.Review, repository.PageInfo, error) {
+	if _, err := s.CRepo.FindProductByID(ctx, int(productId)); err != nil {
+		return nil, repository.PageInfo{}, err
+	}
+
+	return s.Repo.FindProductReviews(ctx, productId, p)
+}
+
+func (s ReviewService) GetSellerReviews(ctx context.Context, seller domain.User, p repository.Pagination) ([]domain.Review, repository.PageInfo, error) {
+	return s.Repo.FindSellerReviews(ctx, seller.ID, p)
+}
+
+// ReplyToReview sets the seller's public answer to a review of one of their
+// products, replacing an earlier reply.
+func (s ReviewService) ReplyToReview(ctx context.Context, id uint, input dto.ReviewReplyRequest, seller domain.User) (*domain.Review, error) {
+	reply := strings.TrimSpace(input.Reply)
+	if len(reply) < 1 {
+		return nil, domain.ValidationError("reply is required")
+	}
+	if len(reply) > maxReviewLength {
+		return nil, domain.ValidationError(fmt.Sprintf("reply must be at most %d characters", maxReviewLength))
+	}
+
+	review, err := s.Repo.FindReviewById(ctx, id)
+	if err != nil {
+		return nil, domain.NotFoundError("review not found")
+	}
+
+	product, err := s.CRepo.FindProductByID(ctx, int(review.ProductId))
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+	if err != nil || product.UserId != int(seller.ID) {
+		return nil, domain.ForbiddenError("you can only reply to reviews of your own products")
+	}
+
+	if err = s.Repo.ReplyToReview(ctx, id, reply); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.FindReviewById(ctx, id)
+}
+
+// FlagReview reports a review for moderation. Enough flags hide the review.
+func (s ReviewService) FlagReview(ctx context.Context, id uint, input dto.ReviewFlagRequest, u domain.User) error {
+	reason := strings.TrimSpace(input.Reason)
+	if len(reason) > maxFlagReason {
+		return domain.ValidationError(fmt.Sprintf("reason must be at most %d characters", maxFlagReason))
+	}
+
+	review, err := s.Repo.FindReviewById(ctx, id)
+	if err != nil || review.Status != domain.ReviewStatusPublished {
+		return domain.NotFoundError("review not found")
+	}
+	if review.UserId == u.ID {
+		return domain.ForbiddenError("you cannot flag your own review")
+	}
+
+	_, err = s.Repo.FlagReview(ctx, &domain.ReviewFlag{
+		ReviewId: id,
+		UserId:   u.ID,
+		Reason:   reason,
+	}, s.Config.Review.FlagThreshold)
+
+	return err
+}
+
+// GetFlaggedReviews lists the reviews buyers flagged, hidden or not, for
+// the platform's moderators.
+func (s ReviewService) GetFlaggedReviews(ctx context.Context, p repository.Pagination) ([]domain.Review, repository.PageInfo, error) {
+	return s.Repo.FindFlaggedReviews(ctx, p)
+}
+
+// RestoreReview is a moderator dismissing the flags on a review, publishing
+// it again if the flags had hidden it.
+func (s ReviewService) RestoreReview(ctx context.Context, id uint) (*domain.Review, error) {
+	return s.Repo.RestoreReview(ctx, id)
+}
+
+func (s ReviewService) ownReview(ctx context.Context, id uint, u domain.User) (*domain.Review, error) {
+	review, err := s.Repo.FindReviewById(ctx, id)
+	if err != nil {
+		return nil, domain.NotFoundError("review not found")
+	}
+	if review.UserId != u.ID {
+		return nil, domain.ForbiddenError("you can only change your own reviews")
+	}
+
+	return review, nil
+}
+
+func validateReview(input dto.ReviewRequest) ([]domain.ReviewImage, error) {
+	if input.Rating < 1 || input.Rating > 5 {
+		return nil, domain.ValidationError("rating must be between 1 and 5")
+	}
+	if len(strings.TrimSpace(input.Body)) > maxReviewLength {
+		return nil, domain.ValidationError(fmt.Sprintf("review must be at most %d characters", maxReviewLength))
+	}
+	if len(input.Images) > maxReviewImages {
+		return nil, domain.ValidationError(fmt.Sprintf("a review can have at most %d images", maxReviewImages))
+	}
+
+	images := []domain.ReviewImage{}
+	for _, image := range input.Images {
+		if !helper.IsWebUrl(image) {
+			return nil, domain.ValidationError(fmt.Sprintf("image %q must be an http or https url", image))
+		}
+		images = append(images, domain.ReviewImage{Url: image})
+	}
+
+	return images, nil
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"strings"
+	"testing"
+)
+
+func TestValidateReview(t *testing.T) {
+	tests := []struct {
+		name  string
+		input dto.ReviewRequest
+		valid bool
+	}{
+		{"rating only", dto.ReviewRequest{Rating: 5}, true},
+		{"with images", dto.ReviewRequest{Rating: 3, Body: "ok", Images: []string{"https://cdn.example.com/a.jpg"}}, true},
+		{"no rating", dto.ReviewRequest{Body: "great"}, false},
+		{"rating too high", dto.ReviewRequest{Rating: 6}, false},
+		{"body too long", dto.ReviewRequest{Rating: 4, Body: strings.Repeat("a", maxReviewLength+1)}, false},
+		{"too many images", dto.ReviewRequest{Rating: 4, Images: make([]string, maxReviewImages+1)}, false},
+		{"image not a url", dto.ReviewRequest{Rating: 4, Images: []string{"javascript:alert(1)"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			images, err := validateReview(tt.input)
+			if tt.valid {
+				if err != nil || len(images) != len(tt.input.Images) {
+					t.Fatalf("validateReview() = %v, %v; want %d images", images, err, len(tt.input.Images))
+				}
+				return
+			}
+
+			if !errors.Is(err, domain.ErrValidation) {
+				t.Fatalf("validateReview() error = %v; want a validation error", err)
+			}
+		})
+	}
+}
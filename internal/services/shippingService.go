@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultShipping is offered by sellers who have no active shipping profile,
+// so their products can still be checked out. It ships anywhere for free.
+var defaultShipping = dto.ShippingOption{Name: "Standard shipping"}
+
+type ShippingService struct {
+	Repo  repository.ShippingRepository
+	CRepo repository.CatalogRepository
+	Auth  helper.Auth
+}
+
+func (s ShippingService) CreateProfile(ctx context.Context, input dto.ShippingProfileRequest, seller domain.User) (*domain.ShippingProfile, error) {
+	profile := &domain.ShippingProfile{SellerId: seller.ID, Active: true}
+	if err := applyShippingProfile(profile, input); err != nil {
+		return nil, err
+	}
+
+	err := s.Repo.CreateProfile(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+func (s ShippingService) UpdateProfile(ctx context.Context, id uint, input dto.ShippingProfileRequest, seller domain.User) (*domain.ShippingProfile, error) {
+	profile, err := s.findSellerProfile(ctx, id, seller)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = applyShippingProfile(profile, input); err != nil {
+		return nil, err
+	}
+
+	err = s.Repo.UpdateProfile(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+func (s ShippingService) DeleteProfile(ctx context.Context, id uint, seller domain.User) error {
+	profile, err := s.findSellerProfile(ctx, id, seller)
+	if err != nil {
+		return err
+	}
+
+	return s.Repo.DeleteProfile(ctx, profile.ID)
+}
+
+func (s ShippingService) GetSellerProfiles(ctx context.Context, seller domain.User) ([]domain.ShippingProfile, error) {
+	return s.Repo.FindSellerProfiles(ctx, seller.ID, false)
+}
+
+// CartShipping groups the cart by seller and quotes every active profile that
+// ships to address, together with the method the buyer picked for the seller.
+// The default method of a seller without profiles is always picked.
+func (s ShippingService) CartShipping(ctx context.Context, uId uint, address *domain.Address, items []domain.Cart) ([]dto.SellerShipping, error) {
+	bySeller := map[uint][]domain.Cart{}
+	var sellers []uint
+	for _, item := range items {
+		if _, ok := bySeller[item.SellerId]; !ok {
+			sellers = append(sellers, item.SellerId)
+		}
+		bySeller[item.SellerId] = append(bySeller[item.SellerId], item)
+	}
+	sort.Slice(sellers, func(i, j int) bool { return sellers[i] < sellers[j] })
+
+	selections, err := s.Repo.FindCartShipping(ctx, uId)
+	if err != nil {
+		return nil, errors.New("failed to fetch shipping selections")
+	}
+	selected := map[uint]uint{}
+	for _, sel := range selections {
+		selected[sel.SellerId] = sel.ShippingProfileId
+	}
+
+	result := []dto.SellerShipping{}
+	for _, sellerId := range sellers {
+		shipping, err := s.quoteSeller(ctx, sellerId, address, bySeller[sellerId])
+		if err != nil {
+			return nil, err
+		}
+
+		for i, option := range shipping.Options {
+			if option.ShippingProfileId == selected[sellerId] || option.ShippingProfileId == defaultShipping.ShippingProfileId {
+				shipping.Selected = &shipping.Options[i]
+			}
+		}
+		result = append(result, shipping)
+	}
+
+	return result, nil
+}
+
+// SelectMethod stores the buyer's shipping method for one seller after making
+// sure it is offered for the current cart and destination.
+func (s ShippingService) SelectMethod(ctx context.Context, uId uint, input dto.SelectShippingRequest, address *domain.Address, items []domain.Cart) error {
+	var sellerItems []domain.Cart
+	for _, item := range items {
+		if item.SellerId == input.SellerId {
+			sellerItems = append(sellerItems, item)
+		}
+	}
+	if len(sellerItems) == 0 {
+		return domain.ValidationError("your cart has no items from this seller")
+	}
+
+	shipping, err := s.quoteSeller(ctx, input.SellerId, address, sellerItems)
+	if err != nil {
+		return err
+	}
+
+	for _, option := range shipping.Options {
+		if option.ShippingProfileId == input.ShippingProfileId {
+			return s.Repo.SaveCartShipping(ctx, domain.CartShipping{
+				UserId:            uId,
+				SellerId:          input.SellerId,
+				ShippingProfileId: input.ShippingProfileId,
+			})
+		}
+	}
+
+	return domain.ValidationError("shipping method is not available for this seller and address")
+}
+
+func (s ShippingService) ClearSelections(ctx context.Context, uId uint) error {
+	return s.Repo.DeleteCartShipping(ctx, uId)
+}
+
+// MarkShipped attaches the carrier and tracking number to the seller's part
+// of an order.
+func (s ShippingService) MarkShipped(ctx context.Context, orderId uint, input dto.ShipOrderRequest, seller domain.User) (*domain.OrderShipment, error) {
+	if len(strings.TrimSpace(input.TrackingNumber)) < 1 {
+		return nil, domain.ValidationError("tracking number is required")
+	}
+
+	shipment, err := s.Repo.FindShipment(ctx, orderId, seller.ID)
+	if err != nil {
+		return nil, domain.NotFoundError("shipment not found")
+	}
+
+	// a shipped order may have its tracking corrected, a delivered one not
+	if shipment.Status != domain.ShipmentStatusPending && shipment.Status != domain.ShipmentStatusShipped {
+		return nil, domain.ConflictError("only pending or shipped orders can be marked as shipped")
+	}
+
+	now := time.Now()
+	shipment.Carrier = input.Carrier
+	shipment.TrackingNumber = strings.TrimSpace(input.TrackingNumber)
+	shipment.Status = domain.ShipmentStatusShipped
+	if shipment.ShippedAt == nil {
+		shipment.ShippedAt = &now
+	}
+
+	err = s.Repo.UpdateShipment(ctx, shipment)
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// MarkDelivered records that the seller's shipment for the order reached the
+// buyer. Only shipped orders can be delivered.
+func (s ShippingService) MarkDelivered(ctx context.Context, orderId uint, seller domain.User) (*domain.OrderShipment, error) {
+	shipment, err := s.Repo.FindShipment(ctx, orderId, seller.ID)
+	if err != nil {
+		return nil, domain.NotFoundError("shipment not found")
+	}
+
+	if shipment.Status != domain.ShipmentStatusShipped {
+		return nil, domain.ConflictError("only shipped orders can be marked as delivered")
+	}
+
+	now := time.Now()
+	shipment.Status = domain.ShipmentStatusDelivered
+	shipment.DeliveredAt = &now
+
+	err = s.Repo.UpdateShipment(ctx, shipment)
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+func (s ShippingService) quoteSeller(ctx context.Context, sellerId uint, address *domain.Address, items []domain.Cart) (dto.SellerShipping, error) {
+	shipping := dto.SellerShipping{SellerId: sellerId, Options: []dto.ShippingOption{}}
+
+	var weight float64
+	for _, item := range items {
+		shipping.SubTotal += item.Price * float64(item.Qty)
+		if product, err := s.CRepo.FindProductByID(ctx, int(item.ProductId)); err == nil {
+			weight += product.Weight * float64(item.Qty)
+		}
+	}
+	shipping.SubTotal = roundCents(shipping.SubTotal)
+
+	if address == nil {
+		return shipping, nil
+	}
+
+	profiles, err := s.Repo.FindSellerProfiles(ctx, sellerId, true)
+	if err != nil {
+		return dto.SellerShipping{}, err
+	}
+	if len(profiles) == 0 {
+		shipping.Options = append(shipping.Options, defaultShipping)
+		return shipping, nil
+	}
+
+	for _, profile := range profiles {
+		if !shipsTo(profile, address.Country) {
+			continue
+		}
+
+		shipping.Options = append(shipping.Options, dto.ShippingOption{
+			ShippingProfileId: profile.ID,
+			Name:              profile.Name,
+			Cost:              shippingCost(profile, shipping.SubTotal, weight),
+			EstimatedDays:     profile.EstimatedDays,
+		})
+	}
+
+	return shipping, nil
+}
+
+func (s ShippingService) findSellerProfile(ctx context.Context, id uint, seller domain.User) (*domain.ShippingProfile, error) {
+	profile, err := s.Repo.FindProfileByID(ctx, id)
+	if err != nil {
+		return nil, domain.NotFoundError("shipping profile not found")
+	}
+
+	if profile.SellerId != seller.ID {
+		return nil, domain.ForbiddenError("you are not authorized to update this shipping profile")
+	}
+
+	return profile, nil
+}
+
+func applyShippingProfile(profile *domain.ShippingProfile, input dto.ShippingProfileRequest) error {
+	if len(strings.TrimSpace(input.Name)) < 1 {
+		return domain.ValidationError("shipping profile name is required")
+	}
+
+	rateType := domain.ShippingRateType(input.Type)
+	if rateType != domain.ShippingRateFlat && rateType != domain.ShippingRateWeight {
+		return domain.ValidationError("shipping profile type must be flat or weight")
+	}
+
+	if input.Rate < 0 || input.PerKg < 0 || input.FreeOver < 0 || input.EstimatedDays < 0 {
+		return domain.ValidationError("shipping rates cannot be negative")
+	}
+
+	var countries []string
+	for _, c := range input.Countries {
+		if c = normalizeRegion(c); len(c) > 0 {
+			countries = append(countries, c)
+		}
+	}
+
+	profile.Name = strings.TrimSpace(input.Name)
+	profile.Type = rateType
+	profile.Rate = input.Rate
+	profile.PerKg = input.PerKg
+	profile.FreeOver = input.FreeOver
+	profile.Countries = strings.Join(countries, ",")
+	profile.EstimatedDays = input.EstimatedDays
+
+	return nil
+}
+
+func shipsTo(profile domain.ShippingProfile, country string) bool {
+	if len(profile.Countries) < 1 {
+		return true
+	}
+
+	country = normalizeRegion(country)
+	for _, c := range strings.Split(profile.Countries, ",") {
+		if c == country {
+			return true
+		}
+	}
+
+	return false
+}
+
+func shippingCost(profile domain.ShippingProfile, subTotal float64, weight float64) float64 {
+	if profile.FreeOver > 0 && subTotal >= profile.FreeOver {
+		return 0
+	}
+
+	cost := profile.Rate
+	if profile.Type == domain.ShippingRateWeight {
+		cost += profile.PerKg * weight
+	}
+
+	return roundCents(cost)
+}
+
+// missingShipping lists the sellers the buyer still has to pick a shipping
+// method for.
+func missingShipping(shipping []dto.SellerShipping) error {
+	var missing []uint
+	for _, sh := range shipping {
+		if sh.Selected == nil {
+			missing = append(missing, sh.SellerId)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return domain.NewErrorWithDetails(domain.ErrValidation,
+		"please choose a shipping method for every seller in your cart", map[string]interface{}{
+			"seller_ids": missing,
+		})
+}
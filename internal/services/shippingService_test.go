@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"testing"
+	"time"
+)
+
+func TestCartShippingFallsBackForSellersWithoutProfiles(t *testing.T) {
+	svc := ShippingService{
+		Repo: &fakeShippingRepository{profiles: []domain.ShippingProfile{
+			{ID: 5, SellerId: 2, Name: "Courier", Type: domain.ShippingRateFlat, Rate: 4, Active: true},
+			{ID: 6, SellerId: 3, Name: "Retired", Type: domain.ShippingRateFlat, Rate: 9, Active: false},
+		}},
+		CRepo: &fakeCatalogRepository{},
+	}
+	items := []domain.Cart{
+		{ProductId: 1, SellerId: 1, Price: 10, Qty: 1},
+		{ProductId: 2, SellerId: 2, Price: 10, Qty: 1},
+		{ProductId: 3, SellerId: 3, Price: 10, Qty: 1},
+	}
+
+	shipping, err := svc.CartShipping(context.Background(), 7, &domain.Address{Country: "US"}, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sh := range shipping {
+		if sh.SellerId == 2 {
+			if sh.Selected != nil || len(sh.Options) != 1 || sh.Options[0].ShippingProfileId != 5 {
+				t.Fatalf("seller 2 shipping = %+v; want its own profile, not yet picked", sh)
+			}
+			continue
+		}
+		if sh.Selected == nil || sh.Selected.Name != defaultShipping.Name || sh.Selected.Cost != 0 {
+			t.Fatalf("seller %d shipping = %+v; want the free default picked", sh.SellerId, sh)
+		}
+	}
+	if err := missingShipping(shipping); err == nil {
+		t.Fatal("missingShipping() = nil; want seller 2 still to pick")
+	}
+	if err := missingShipping([]dto.SellerShipping{shipping[0], shipping[2]}); err != nil {
+		t.Fatalf("missingShipping() of sellers on the default = %v; want nil", err)
+	}
+}
+
+func TestMarkShippedOnlyBeforeDelivery(t *testing.T) {
+	shippedAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakeShippingRepository{shipments: []domain.OrderShipment{
+		{ID: 1, OrderId: 1, SellerId: 2, Status: domain.ShipmentStatusPending},
+		{ID: 2, OrderId: 2, SellerId: 2, Status: domain.ShipmentStatusShipped, TrackingNumber: "1Z1", ShippedAt: &shippedAt},
+		{ID: 3, OrderId: 3, SellerId: 2, Status: domain.ShipmentStatusDelivered},
+	}}
+	svc := ShippingService{Repo: repo}
+	seller := domain.User{ID: 2}
+	input := dto.ShipOrderRequest{Carrier: "UPS", TrackingNumber: "1Z2"}
+
+	shipment, err := svc.MarkShipped(context.Background(), 1, input, seller)
+	if err != nil || shipment.Status != domain.ShipmentStatusShipped || shipment.ShippedAt == nil {
+		t.Fatalf("MarkShipped() of a pending order = %+v, %v; want it shipped", shipment, err)
+	}
+
+	shipment, err = svc.MarkShipped(context.Background(), 2, input, seller)
+	if err != nil || shipment.TrackingNumber != "1Z2" || !shipment.ShippedAt.Equal(shippedAt) {
+		t.Fatalf("MarkShipped() of a shipped order = %+v, %v; want the tracking corrected", shipment, err)
+	}
+
+	if _, err = svc.MarkShipped(context.Background(), 3, input, seller); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("MarkShipped() of a delivered order error = %v; want a conflict", err)
+	}
+	if repo.shipments[2].Status != domain.ShipmentStatusDelivered {
+		t.Fatalf("delivered shipment = %+v; want it left delivered", repo.shipments[2])
+	}
+}
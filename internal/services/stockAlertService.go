@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"log/slog"
+)
+
+type StockAlertService struct {
+	Repo   repository.StockAlertRepository
+	CRepo  repository.CatalogRepository
+	URepo  repository.UserRepository
+	Alerts NotificationService
+	Auth   helper.Auth
+}
+
+// Subscribe asks for a text once the out of stock product is replenished.
+func (s StockAlertService) Subscribe(ctx context.Context, productId uint, u domain.User) (*domain.StockAlert, error) {
+	product, err := s.CRepo.FindProductByID(ctx, int(productId))
+	if err != nil {
+		return nil, err
+	}
+	if product.Stock > 0 {
+		return nil, domain.ConflictError("product is in stock")
+	}
+
+	if _, err = s.Repo.FindStockAlert(ctx, productId, u.ID); err == nil {
+		return nil, domain.ConflictError("you are already subscribed to this product")
+	}
+
+	alert := &domain.StockAlert{
+		ProductId: productId,
+		UserId:    u.ID,
+	}
+	if err = s.Repo.CreateStockAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+func (s StockAlertService) Unsubscribe(ctx context.Context, productId uint, u domain.User) error {
+	alert, err := s.Repo.FindStockAlert(ctx, productId, u.ID)
+	if err != nil {
+		return domain.NotFoundError("you are not subscribed to this product")
+	}
+
+	return s.Repo.DeleteStockAlert(ctx, alert.ID)
+}
+
+func (s StockAlertService) GetSubscriptions(ctx context.Context, u domain.User) ([]domain.StockAlert, error) {
+	return s.Repo.FindUserStockAlerts(ctx, u.ID)
+}
+
+// NotifyBackInStock texts the product's subscribers and ends their
+// subscriptions. It returns the users it texted.
+func (s StockAlertService) NotifyBackInStock(ctx context.Context, product domain.Product) []domain.User {
+	users, err := s.Repo.FindStockAlertSubscribers(ctx, product.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "finding stock alert subscribers failed", "product_id", product.ID, "error", err)
+		return nil
+	}
+	if len(users) < 1 {
+		return nil
+	}
+
+	s.Alerts.NotifyInBackground(ctx, "back_in_stock", users, fmt.Sprintf("Back in stock: %s is available again", product.Name))
+	if err = s.Repo.DeleteProductStockAlerts(ctx, product.ID); err != nil {
+		slog.WarnContext(ctx, "clearing stock alerts failed", "product_id", product.ID, "error", err)
+	}
+
+	return users
+}
+
+// CheckLowStock alerts the seller when the product's stock went from
+// previous to below its low stock threshold.
+func (s StockAlertService) CheckLowStock(ctx context.Context, product domain.Product, previous uint) {
+	threshold := product.LowStockThreshold
+	if threshold < 1 || product.Stock >= threshold || previous < threshold {
+		return
+	}
+
+	seller, err := s.URepo.FindUserByID(ctx, uint(product.UserId))
+	if err != nil {
+		slog.WarnContext(ctx, "finding seller for low stock alert failed", "product_id", product.ID, "error", err)
+		return
+	}
+
+	s.Alerts.NotifyInBackground(ctx, "low_stock", []domain.User{seller}, fmt.Sprintf("Low stock: only %d of %s left", product.Stock, product.Name))
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/pkg/notification"
+	"testing"
+)
+
+func TestSubscribeOnlyToOutOfStockProducts(t *testing.T) {
+	repo := &fakeStockAlertRepository{}
+	svc := StockAlertService{
+		Repo: repo,
+		CRepo: &fakeCatalogRepository{products: map[uint]domain.Product{
+			1: {ID: 1, Name: "Go in Action", Stock: 0},
+			2: {ID: 2, Name: "Bookmark", Stock: 3},
+		}},
+	}
+	buyer := domain.User{ID: 7}
+	ctx := context.Background()
+
+	if _, err := svc.Subscribe(ctx, 1, buyer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Subscribe(ctx, 1, buyer); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("subscribing twice error = %v; want a conflict", err)
+	}
+	if _, err := svc.Subscribe(ctx, 2, buyer); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("subscribing to a product in stock error = %v; want a conflict", err)
+	}
+	if _, err := svc.Subscribe(ctx, 3, buyer); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("subscribing to a missing product error = %v; want not found", err)
+	}
+
+	if err := svc.Unsubscribe(ctx, 1, buyer); err != nil || len(repo.alerts) != 0 {
+		t.Fatalf("Unsubscribe() = %v, leaving %v; want no alerts", err, repo.alerts)
+	}
+	if err := svc.Unsubscribe(ctx, 1, buyer); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("unsubscribing twice error = %v; want not found", err)
+	}
+}
+
+func TestNotifyBackInStockEndsSubscriptions(t *testing.T) {
+	client := notification.NewFakeClient()
+	repo := &fakeStockAlertRepository{
+		alerts: []domain.StockAlert{{ID: 1, ProductId: 1, UserId: 7}, {ID: 2, ProductId: 2, UserId: 7}},
+		users:  map[uint]domain.User{7: {ID: 7, Phone: "+15550001"}},
+	}
+	svc := StockAlertService{Repo: repo, Alerts: NotificationService{Client: client}}
+
+	svc.NotifyBackInStock(context.Background(), domain.Product{ID: 1, Name: "Go in Action", Stock: 4})
+
+	messages := client.Messages()
+	if len(messages) != 1 || messages[0].Body != "Back in stock: Go in Action is available again" {
+		t.Fatalf("messages = %+v; want one back in stock text", messages)
+	}
+	if len(repo.alerts) != 1 || repo.alerts[0].ProductId != 2 {
+		t.Fatalf("alerts = %+v; want only the other product's", repo.alerts)
+	}
+}
+
+func TestCheckLowStockAlertsOnceBelowThreshold(t *testing.T) {
+	client := notification.NewFakeClient()
+	users := newFakeUserRepository()
+	seller, _ := users.CreateUser(context.Background(), domain.User{Email: "seller@example.com", Phone: "+15550002"})
+	svc := StockAlertService{URepo: users, Alerts: NotificationService{Client: client}}
+	product := domain.Product{ID: 1, Name: "Go in Action", UserId: int(seller.ID), LowStockThreshold: 5}
+
+	tests := []struct {
+		stock    uint
+		previous uint
+		alerts   int
+	}{
+		{stock: 6, previous: 8, alerts: 0},
+		{stock: 4, previous: 6, alerts: 1},
+		{stock: 3, previous: 4, alerts: 1},
+		{stock: 5, previous: 3, alerts: 1},
+	}
+	for _, tt := range tests {
+		product.Stock = tt.stock
+		svc.CheckLowStock(context.Background(), product, tt.previous)
+		if got := len(client.Messages()); got != tt.alerts {
+			t.Fatalf("after stock went from %d to %d, %d alerts were sent; want %d", tt.previous, tt.stock, got, tt.alerts)
+		}
+	}
+	if body := client.Messages()[0].Body; body != "Low stock: only 4 of Go in Action left" {
+		t.Fatalf("low stock text = %q", body)
+	}
+}
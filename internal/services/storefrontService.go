@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxStoreName = 100
+	maxStoreText = 5000
+	minSlug      = 3
+	maxSlug      = 60
+)
+
+// slugs need a letter so they can never be mistaken for a seller id
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+var slugLetter = regexp.MustCompile(`[a-z]`)
+
+type StorefrontService struct {
+	Repo  repository.StorefrontRepository
+	CRepo repository.CatalogRepository
+	Auth  helper.Auth
+}
+
+// SaveStorefront sets up the seller's storefront or updates it. Without a
+// slug the current one is kept, or one is made from the store name.
+func (s StorefrontService) SaveStorefront(ctx context.Context, input dto.StorefrontRequest, seller domain.User) (*domain.Storefront, error) {
+	store, err := s.Repo.FindStorefrontBySeller(ctx, seller.ID)
+	if err != nil {
+		store = &domain.Storefront{UserId: seller.ID}
+	}
+
+	name := strings.TrimSpace(input.StoreName)
+	if len(name) < 1 {
+		return nil, domain.ValidationError("store name is required")
+	}
+	if len(name) > maxStoreName {
+		return nil, domain.ValidationError(fmt.Sprintf("store name must be at most %d characters", maxStoreName))
+	}
+
+	slug := strings.ToLower(strings.TrimSpace(input.Slug))
+	if len(slug) < 1 {
+		slug = store.Slug
+	}
+	if len(slug) < 1 {
+		slug = storeSlug(name, seller.ID)
+	}
+	if err = validateSlug(slug); err != nil {
+		return nil, err
+	}
+	if other, err := s.Repo.FindStorefrontBySlug(ctx, slug); err == nil && other.UserId != seller.ID {
+		return nil, domain.ConflictError(fmt.Sprintf("the store address %q is taken", slug))
+	}
+
+	if len(input.LogoUrl) > 0 && !helper.IsWebUrl(input.LogoUrl) {
+		return nil, domain.ValidationError("logo must be an http or https url")
+	}
+	if len(input.ContactEmail) > 0 {
+		if _, err = mail.ParseAddress(input.ContactEmail); err != nil {
+			return nil, domain.ValidationError("contact email is not valid")
+		}
+	}
+	if len(input.Description) > maxStoreText || len(input.ReturnPolicy) > maxStoreText {
+		return nil, domain.ValidationError(fmt.Sprintf("description and return policy must be at most %d characters", maxStoreText))
+	}
+
+	store.StoreName = name
+	store.Slug = slug
+	store.LogoUrl = input.LogoUrl
+	store.Description = strings.TrimSpace(input.Description)
+	store.ReturnPolicy = strings.TrimSpace(input.ReturnPolicy)
+	store.ContactEmail = input.ContactEmail
+	store.ContactPhone = input.ContactPhone
+
+	if err = s.Repo.SaveStorefront(ctx, store); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.FindStorefrontBySeller(ctx, seller.ID)
+}
+
+// storeSlug makes a slug from the store name, cut at a hyphen to fit. Names
+// that give no usable slug, such as ones without latin letters, get one
+// made from the seller's id.
+func storeSlug(name string, sellerId uint) string {
+	slug := helper.Slugify(name)
+	if len(slug) > maxSlug {
+		cut := maxSlug
+		if i := strings.LastIndex(slug[:maxSlug+1], "-"); i > 0 {
+			cut = i
+		}
+		slug = strings.TrimRight(slug[:cut], "-")
+	}
+
+	if validateSlug(slug) != nil {
+		return fmt.Sprintf("store-%d", sellerId)
+	}
+	return slug
+}
+
+func (s StorefrontService) GetOwnStorefront(ctx context.Context, seller domain.User) (*domain.Storefront, error) {
+	store, err := s.Repo.FindStorefrontBySeller(ctx, seller.ID)
+	if err != nil {
+		return nil, domain.NotFoundError("you have not set up a storefront yet")
+	}
+
+	return store, nil
+}
+
+// GetStorefront finds a storefront by its slug, or by the seller's id when
+// key is a number, with a page of the seller's products.
+func (s StorefrontService) GetStorefront(ctx context.Context, key string, p repository.Pagination, f repository.ProductFilter) (*domain.Storefront, []*domain.Product, repository.PageInfo, error) {
+	var store *domain.Storefront
+	var err error
+	if id, convErr := strconv.Atoi(key); convErr == nil {
+		store, err = s.Repo.FindStorefrontBySeller(ctx, uint(id))
+	} else {
+		store, err = s.Repo.FindStorefrontBySlug(ctx, strings.ToLower(key))
+	}
+	if err != nil {
+		return nil, nil, repository.PageInfo{}, domain.NotFoundError("seller not found")
+	}
+
+	products, info, err := s.CRepo.FindSellerProducts(ctx, int(store.UserId), p, f)
+	if err != nil {
+		return nil, nil, repository.PageInfo{}, err
+	}
+
+	return store, products, info, nil
+}
+
+func validateSlug(slug string) error {
+	if len(slug) < minSlug || len(slug) > maxSlug {
+		return domain.ValidationError(fmt.Sprintf("store address must be %d to %d characters", minSlug, maxSlug))
+	}
+	if !slugPattern.MatchString(slug) || !slugLetter.MatchString(slug) {
+		return domain.ValidationError("store address may only use lowercase letters, digits and hyphens, and needs a letter")
+	}
+
+	return nil
+}
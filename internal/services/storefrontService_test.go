@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/repository"
+	"strings"
+	"testing"
+)
+
+func TestStoreSlug(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Sam's Books & More", "sam-s-books-more"},
+		{long, strings.TrimSuffix(strings.Repeat("word-", 12), "-")},
+		{strings.Repeat("a", 70), strings.Repeat("a", maxSlug)},
+		{"Книжный магазин", "store-7"},
+		{"2024", "store-7"},
+	}
+
+	for _, tt := range tests {
+		got := storeSlug(tt.name, 7)
+		if got != tt.want {
+			t.Fatalf("storeSlug(%q) = %q; want %q", tt.name, got, tt.want)
+		}
+		if err := validateSlug(got); err != nil {
+			t.Fatalf("storeSlug(%q) = %q, which is not valid: %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestSaveStorefront(t *testing.T) {
+	repo := &fakeStorefrontRepository{stores: map[uint]domain.Storefront{
+		2: {UserId: 2, StoreName: "Taken", Slug: "taken"},
+	}}
+	svc := StorefrontService{Repo: repo}
+	seller := domain.User{ID: 1}
+	ctx := context.Background()
+
+	store, err := svc.SaveStorefront(ctx, dto.StorefrontRequest{StoreName: " Sam's Books "}, seller)
+	if err != nil || store.StoreName != "Sam's Books" || store.Slug != "sam-s-books" {
+		t.Fatalf("SaveStorefront() = %+v, %v; want a slug made from the name", store, err)
+	}
+
+	// renaming the store keeps its address
+	store, err = svc.SaveStorefront(ctx, dto.StorefrontRequest{StoreName: "Sam's Rare Books"}, seller)
+	if err != nil || store.Slug != "sam-s-books" {
+		t.Fatalf("renamed storefront = %+v, %v; want the slug kept", store, err)
+	}
+
+	invalid := []dto.StorefrontRequest{
+		{StoreName: "  "},
+		{StoreName: "Sam's Books", Slug: "12345"},
+		{StoreName: "Sam's Books", LogoUrl: "ftp://example.com/logo.png"},
+		{StoreName: "Sam's Books", ContactEmail: "not an email"},
+		{StoreName: "Sam's Books", Description: strings.Repeat("a", maxStoreText+1)},
+	}
+	for _, input := range invalid {
+		if _, err := svc.SaveStorefront(ctx, input, seller); !errors.Is(err, domain.ErrValidation) {
+			t.Fatalf("SaveStorefront(%+v) error = %v; want a validation error", input, err)
+		}
+	}
+
+	if _, err := svc.SaveStorefront(ctx, dto.StorefrontRequest{StoreName: "Sam's Books", Slug: "Taken"}, seller); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("taking another seller's slug error = %v; want a conflict", err)
+	}
+}
+
+func TestGetStorefrontBySlugOrSellerId(t *testing.T) {
+	svc := StorefrontService{
+		Repo: &fakeStorefrontRepository{stores: map[uint]domain.Storefront{
+			1: {UserId: 1, StoreName: "Sam's Books", Slug: "sam-s-books"},
+		}},
+		CRepo: &fakeCatalogRepository{products: map[uint]domain.Product{
+			1: {ID: 1, Name: "Go in Action", UserId: 1},
+			2: {ID: 2, Name: "Bookmark", UserId: 2},
+		}},
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"sam-s-books", "Sam-S-Books", "1"} {
+		store, products, _, err := svc.GetStorefront(ctx, key, repository.Pagination{}, repository.ProductFilter{})
+		if err != nil || store.UserId != 1 || len(products) != 1 || products[0].ID != 1 {
+			t.Fatalf("GetStorefront(%q) = %+v, %v, %v; want seller 1 with their product", key, store, products, err)
+		}
+	}
+
+	if _, _, _, err := svc.GetStorefront(ctx, "2", repository.Pagination{}, repository.ProductFilter{}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("GetStorefront() of a seller without one error = %v; want not found", err)
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/repository"
+	"math"
+	"os"
+	"strings"
+)
+
+type TaxService struct {
+	Repo  repository.TaxRepository
+	CRepo repository.CatalogRepository
+}
+
+// LoadRates reads a JSON array of tax rates and saves each of them, replacing
+// rates already stored for the same country, region and tax category.
+func (s TaxService) LoadRates(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read tax rates: %w", err)
+	}
+
+	var rates []domain.TaxRate
+	if err = json.Unmarshal(data, &rates); err != nil {
+		return fmt.Errorf("failed to parse tax rates: %w", err)
+	}
+
+	for _, rate := range rates {
+		rate.Country = normalizeRegion(rate.Country)
+		rate.Region = normalizeRegion(rate.Region)
+		if len(rate.Country) < 1 || rate.Rate < 0 {
+			return errors.New("tax rates need a country and a non negative rate")
+		}
+
+		if err = s.Repo.SaveRate(ctx, &rate); err != nil {
+			return fmt.Errorf("failed to save tax rate for %s: %w", rate.Country, err)
+		}
+	}
+
+	return nil
+}
+
+// CalculateTax computes the tax of every cart line shipped to address. Each
+// discount is spread over the lines it applies to in proportion to their
+// value, so tax is charged on what the buyer actually pays.
+func (s TaxService) CalculateTax(ctx context.Context, address domain.Address, items []domain.Cart, discounts []dto.DiscountLine) ([]dto.TaxLine, float64, error) {
+	country := normalizeRegion(address.Country)
+	region := normalizeRegion(address.Region)
+
+	lines := []dto.TaxLine{}
+	if len(country) < 1 || len(items) == 0 {
+		return lines, 0, nil
+	}
+
+	rates, err := s.Repo.FindRates(ctx, country)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	discounted := lineDiscounts(items, discounts)
+
+	var total float64
+	for _, item := range items {
+		category := domain.DefaultTaxCategory
+		if product, err := s.CRepo.FindProductByID(ctx, int(item.ProductId)); err == nil && len(product.TaxCategory) > 0 {
+			category = product.TaxCategory
+		}
+
+		rate := rateFor(rates, region, category)
+		taxable := roundCents(math.Max(0, item.Price*float64(item.Qty)-discounted[item.ID]))
+		amount := roundCents(taxable * rate / 100)
+		total += amount
+
+		lines = append(lines, dto.TaxLine{
+			CartItemId: item.ID,
+			ProductId:  item.ProductId,
+			Country:    country,
+			Region:     region,
+			Rate:       rate,
+			Taxable:    taxable,
+			Amount:     amount,
+		})
+	}
+
+	return lines, roundCents(total), nil
+}
+
+// lineDiscounts returns the discount taken off each cart line. Free shipping
+// does not reduce the value of the items.
+func lineDiscounts(items []domain.Cart, discounts []dto.DiscountLine) map[uint]float64 {
+	value := map[uint]float64{}
+	for _, item := range items {
+		value[item.ID] = item.Price * float64(item.Qty)
+	}
+
+	discounted := map[uint]float64{}
+	for _, d := range discounts {
+		if d.Type == string(domain.CouponTypeFreeShipping) {
+			continue
+		}
+
+		var eligible float64
+		for _, id := range d.CartItemIds {
+			eligible += value[id]
+		}
+		if eligible <= 0 {
+			continue
+		}
+
+		for _, id := range d.CartItemIds {
+			discounted[id] += d.Amount * value[id] / eligible
+		}
+	}
+
+	return discounted
+}
+
+// rateFor picks the most specific rate: region and category, region only,
+// category only, then the country default.
+func rateFor(rates []domain.TaxRate, region string, category string) float64 {
+	candidates := [][2]string{
+		{region, category},
+		{region, ""},
+		{"", category},
+		{"", ""},
+	}
+
+	for _, c := range candidates {
+		for _, r := range rates {
+			if r.Region == c[0] && r.TaxCategory == c[1] {
+				return r.Rate
+			}
+		}
+	}
+
+	return 0
+}
+
+func normalizeRegion(value string) string {
+	return strings.ToUpper(strings.TrimSpace(value))
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"testing"
+)
+
+func TestDiscountsOnlyLowerTheTaxOfLinesTheyApplyTo(t *testing.T) {
+	catalog := &fakeCatalogRepository{products: map[uint]domain.Product{
+		1: {ID: 1, UserId: 1, CategoryId: 10},
+		2: {ID: 2, UserId: 1, CategoryId: 20},
+		3: {ID: 3, UserId: 2, CategoryId: 10},
+	}}
+	promotions := newFakePromotionRepository()
+	promotions.coupons[1] = domain.Coupon{ID: 1, Code: "BOOKS", Type: domain.CouponTypeFixed, Value: 30, SellerId: 1, CategoryId: 10, Active: true}
+	promotionSvc := PromotionService{Repo: promotions, CRepo: catalog}
+	taxSvc := TaxService{Repo: &fakeTaxRepository{rates: []domain.TaxRate{{Country: "US", Rate: 10}}}, CRepo: catalog}
+	ctx := context.Background()
+
+	// Only the first line is the coupon seller's and in its category.
+	items := []domain.Cart{
+		{ID: 1, ProductId: 1, SellerId: 1, Price: 50, Qty: 1},
+		{ID: 2, ProductId: 2, SellerId: 1, Price: 50, Qty: 1},
+		{ID: 3, ProductId: 3, SellerId: 2, Price: 50, Qty: 1},
+	}
+	line, err := promotionSvc.ApplyCoupon(ctx, "books", 7, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines, total, err := taxSvc.CalculateTax(ctx, domain.Address{Country: "US"}, items, []dto.DiscountLine{line})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint]float64{1: 20, 2: 50, 3: 50}
+	for _, l := range lines {
+		if l.Taxable != want[l.CartItemId] {
+			t.Fatalf("line %d taxable = %v; want %v", l.CartItemId, l.Taxable, want[l.CartItemId])
+		}
+	}
+	if total != 12 {
+		t.Fatalf("tax total = %v; want 10%% of 120", total)
+	}
+}
@@ -1,55 +1,229 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"go-ecommerce-app/internal/domain"
 	"go-ecommerce-app/internal/dto"
 	"go-ecommerce-app/internal/helper"
 	"go-ecommerce-app/internal/repository"
-
-	"github.com/stripe/stripe-go/v78"
+	"go-ecommerce-app/internal/telemetry"
+	"go-ecommerce-app/pkg/payment"
+	"log/slog"
+	"time"
 )
 
 type TransactionService struct {
 	Repo repository.TransactionRepository
 	Auth helper.Auth
+	Pc   payment.PaymentClient
+}
+
+func (s *TransactionService) GetOrders(ctx context.Context, u domain.User, p repository.Pagination) ([]domain.SubOrder, repository.PageInfo, error) {
+	return s.Repo.FindOrders(ctx, u.ID, p)
+}
+
+func (s *TransactionService) GetOrderDetails(ctx context.Context, u domain.User, id uint) (*domain.SubOrder, error) {
+	order, err := s.Repo.FindOrderById(ctx, u.ID, id)
+	if err != nil {
+		return nil, domain.NotFoundError("order does not exist")
+	}
+	return order, nil
 }
 
-func (s *TransactionService) GetOrders(u domain.User) ([]domain.OrderItem, error) {
-	orders, err := s.Repo.FindOrders(u.ID)
+func (s *TransactionService) GetTaxReport(ctx context.Context, u domain.User, from time.Time, to time.Time) ([]dto.SellerTaxReportLine, error) {
+	if !from.Before(to) {
+		return nil, domain.ValidationError("report start must be before its end")
+	}
+
+	return s.Repo.FindSellerTaxReport(ctx, u.ID, from, to)
+}
+
+// GetActivePayment returns the payment the user still has to complete
+// online. Sessions the provider has expired or failed are closed first, so
+// the caller can start a new one. A payment that went through but has not
+// been ordered yet is a conflict, as paying again would charge the buyer
+// twice for the same cart.
+func (s TransactionService) GetActivePayment(ctx context.Context, uId uint) (*domain.Payment, error) {
+	p, err := s.Repo.FindUnorderedPayment(ctx, uId)
 	if err != nil {
 		return nil, err
 	}
-	return orders, nil
+
+	if err = s.SyncPayment(ctx, p); err != nil {
+		slog.WarnContext(ctx, "syncing payment failed", "payment_id", p.ID, "error", err)
+	}
+
+	if payable(*p) {
+		return nil, domain.ConflictError("your payment has been received, place the order to complete checkout")
+	}
+
+	if len(p.PaymentUrl) < 1 || (p.Status != domain.PaymentStatusInitial && p.Status != domain.PaymentStatusPending) {
+		return nil, nil
+	}
+
+	return p, nil
 }
 
-func (s *TransactionService) GetOrderDetails(u domain.User, id uint) (dto.SellerOrderDetails, error) {
-	order, err := s.Repo.FindOrderById(u.ID, id)
+// PaidPayment returns the user's payment an order can be placed against:
+// one paid online, or one to be collected on delivery.
+func (s TransactionService) PaidPayment(ctx context.Context, uId uint) (*domain.Payment, error) {
+	p, err := s.Repo.FindUnorderedPayment(ctx, uId)
 	if err != nil {
-		return dto.SellerOrderDetails{}, err
+		return nil, domain.ConflictError("there is no payment for this order, please pay first")
 	}
-	return order, nil
+
+	if err = s.SyncPayment(ctx, p); err != nil {
+		slog.WarnContext(ctx, "syncing payment failed", "payment_id", p.ID, "error", err)
+	}
+
+	if !payable(*p) {
+		return nil, domain.ConflictError("the payment has not been completed")
+	}
+
+	return p, nil
+}
+
+// paidCheckout returns the checkout the payment was taken for.
+func paidCheckout(p domain.Payment) (dto.CartSummary, error) {
+	var checkout dto.CartSummary
+	if err := json.Unmarshal([]byte(p.Checkout), &checkout); err != nil || len(checkout.Items) < 1 {
+		return dto.CartSummary{}, domain.ConflictError("the payment does not record what it was for, please pay again")
+	}
+
+	return checkout, nil
+}
+
+// payable reports whether an order may be placed against the payment.
+func payable(p domain.Payment) bool {
+	return p.Status == domain.PaymentStatusSuccess ||
+		p.Status == domain.PaymentStatusPending && payment.IsOffline(p.Provider)
+}
+
+// SyncPayment updates the payment from the state of its provider session.
+// Checkouts with nothing to pay have no session to sync.
+func (s TransactionService) SyncPayment(ctx context.Context, p *domain.Payment) error {
+	if p.Provider == payment.ProviderNone {
+		return nil
+	}
+	if s.Pc == nil {
+		return errors.New("payment client is not configured")
+	}
+
+	ps, err := s.Pc.GetPaymentStatus(ctx, p.PaymentId)
+	if err != nil {
+		return err
+	}
+
+	status := paymentStatus(ps.Status)
+	if status == p.Status {
+		return nil
+	}
+
+	p.Status = status
+	p.Response = ps.Detail
+
+	if err = s.Repo.UpdatePayment(ctx, p); err != nil {
+		return err
+	}
+
+	telemetry.Payments.WithLabelValues(p.Provider, string(p.Status)).Inc()
+	return nil
+}
+
+// RefundPayment gives the buyer back the money of a payment no order could
+// be placed for.
+func (s TransactionService) RefundPayment(ctx context.Context, p *domain.Payment) error {
+	ps := &payment.Session{Status: payment.StatusRefunded, Detail: "nothing was paid"}
+	if p.Provider != payment.ProviderNone {
+		if s.Pc == nil {
+			return errors.New("payment client is not configured")
+		}
+
+		var err error
+		ps, err = s.Pc.RefundPayment(ctx, p.PaymentId)
+		if err != nil {
+			return err
+		}
+	}
+
+	p.Status = paymentStatus(ps.Status)
+	p.Response = ps.Detail
+	if err := s.Repo.UpdatePayment(ctx, p); err != nil {
+		return err
+	}
+
+	telemetry.Payments.WithLabelValues(p.Provider, string(p.Status)).Inc()
+	return nil
 }
 
-func (s TransactionService) GetActivePayment(uId uint) (*domain.Payment, error) {
-	return s.Repo.FindInitialPayment(uId)
+// ReconcilePayments syncs every payment left open for longer than age and
+// returns how many of them were checked.
+func (s TransactionService) ReconcilePayments(ctx context.Context, age time.Duration) (int, error) {
+	payments, err := s.Repo.FindOpenPayments(ctx, time.Now().Add(-age))
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range payments {
+		if err = s.SyncPayment(ctx, &payments[i]); err != nil {
+			slog.WarnContext(ctx, "reconciling payment failed", "payment_id", payments[i].ID, "error", err)
+		}
+	}
+
+	return len(payments), nil
 }
 
-func (s TransactionService) StoreCreatedPayment(uId uint, ps *stripe.CheckoutSession, amount float64, orderId string) error {
-	payment := &domain.Payment{
+// StoreCreatedPayment records the session started for the checkout, keeping
+// the checkout so the order is placed for exactly what was charged.
+func (s TransactionService) StoreCreatedPayment(ctx context.Context, uId uint, ps *payment.Session, checkout dto.CartSummary, orderId string) error {
+	snapshot, err := json.Marshal(checkout)
+	if err != nil {
+		return errors.New("failed to record the checkout")
+	}
+
+	p := &domain.Payment{
 		UserId:     uId,
-		Amount:     amount,
-		Status:     domain.PaymentStatusInitial,
+		Amount:     checkout.Total,
+		Checkout:   string(snapshot),
+		Provider:   ps.Provider,
+		Status:     paymentStatus(ps.Status),
 		PaymentUrl: ps.URL,
 		PaymentId:  ps.ID,
 		OrderId:    orderId,
+		Response:   ps.Detail,
+		ExpiresAt:  ps.ExpiresAt,
+	}
+	if err = s.Repo.CreatePayment(ctx, p); err != nil {
+		return err
+	}
+
+	telemetry.Payments.WithLabelValues(p.Provider, string(p.Status)).Inc()
+	return nil
+}
+
+func paymentStatus(status payment.Status) domain.PaymentStatus {
+	switch status {
+	case payment.StatusPaid:
+		return domain.PaymentStatusSuccess
+	case payment.StatusPending:
+		return domain.PaymentStatusPending
+	case payment.StatusFailed:
+		return domain.PaymentStatusFailed
+	case payment.StatusExpired:
+		return domain.PaymentStatusExpired
+	case payment.StatusRefunded:
+		return domain.PaymentStatusRefunded
 	}
 
-	return s.Repo.CreatePayment(payment)
+	return domain.PaymentStatusInitial
 }
 
-func NewTransactionService(r repository.TransactionRepository, auth helper.Auth) TransactionService {
+func NewTransactionService(r repository.TransactionRepository, auth helper.Auth, pc payment.PaymentClient) TransactionService {
 	return TransactionService{
 		Repo: r,
 		Auth: auth,
+		Pc:   pc,
 	}
 }
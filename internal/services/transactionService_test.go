@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/pkg/payment"
+	"testing"
+	"time"
+)
+
+func TestReconcilePaymentsSettlesProviderSessions(t *testing.T) {
+	ctx := context.Background()
+	pc := payment.NewFakeClient("http://localhost/success")
+	repo := &fakeTransactionRepository{}
+	svc := NewTransactionService(repo, helper.SetupAuth("test-secret"), pc)
+
+	paid, _ := pc.CreatePayment(ctx, 40, 1, "order-1", nil, nil)
+	open, _ := pc.CreatePayment(ctx, 25, 2, "order-2", nil, nil)
+	for i, s := range []*payment.Session{paid, open} {
+		if err := svc.StoreCreatedPayment(ctx, uint(i+1), s, dto.CartSummary{Total: 10}, s.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pc.Complete(paid.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	checked, err := svc.ReconcilePayments(ctx, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checked != 2 {
+		t.Fatalf("checked %d payments; want 2", checked)
+	}
+
+	if len(repo.updated) != 1 {
+		t.Fatalf("updated %d payments; want only the paid one", len(repo.updated))
+	}
+	if got := repo.updated[0]; got.PaymentId != paid.ID || got.Status != domain.PaymentStatusSuccess {
+		t.Fatalf("updated payment = %s %s; want %s success", got.PaymentId, got.Status, paid.ID)
+	}
+}
+
+func TestPaidPaymentRequiresACompletedPayment(t *testing.T) {
+	ctx := context.Background()
+	pc := payment.NewFakeClient("http://localhost/success")
+	svc := NewTransactionService(&fakeTransactionRepository{}, helper.SetupAuth("test-secret"), pc)
+
+	if _, err := svc.PaidPayment(ctx, 1); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("PaidPayment() without a payment error = %v; want a conflict", err)
+	}
+
+	session, _ := pc.CreatePayment(ctx, 40, 1, "order-1", nil, nil)
+	if err := svc.StoreCreatedPayment(ctx, 1, session, dto.CartSummary{Total: 40}, "order-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.PaidPayment(ctx, 1); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("PaidPayment() before paying error = %v; want a conflict", err)
+	}
+
+	if err := pc.Complete(session.ID); err != nil {
+		t.Fatal(err)
+	}
+	paid, err := svc.PaidPayment(ctx, 1)
+	if err != nil || paid.PaymentId != session.ID || paid.Status != domain.PaymentStatusSuccess {
+		t.Fatalf("PaidPayment() = %+v, %v; want the completed session", paid, err)
+	}
+
+	// Until it is ordered, the buyer is not sent to pay a second time.
+	if _, err := svc.GetActivePayment(ctx, 1); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("GetActivePayment() after paying error = %v; want a conflict", err)
+	}
+}
+
+func TestPaidPaymentAcceptsCashOnDelivery(t *testing.T) {
+	ctx := context.Background()
+	pc := payment.NewCashOnDeliveryClient()
+	svc := NewTransactionService(&fakeTransactionRepository{}, helper.SetupAuth("test-secret"), pc)
+
+	session, _ := pc.CreatePayment(ctx, 40, 1, "order-1", nil, nil)
+	if err := svc.StoreCreatedPayment(ctx, 1, session, dto.CartSummary{Total: 40}, "order-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.PaidPayment(ctx, 1); err != nil {
+		t.Fatalf("PaidPayment() for cash on delivery error = %v", err)
+	}
+}
+
+func TestSyncPaymentWithoutClient(t *testing.T) {
+	svc := TransactionService{Repo: &fakeTransactionRepository{}}
+
+	if err := svc.SyncPayment(context.Background(), &domain.Payment{PaymentId: "fake_1"}); err == nil {
+		t.Fatal("SyncPayment() succeeded without a payment client")
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"go-ecommerce-app/configs"
@@ -8,66 +9,108 @@ import (
 	"go-ecommerce-app/internal/dto"
 	"go-ecommerce-app/internal/helper"
 	"go-ecommerce-app/internal/repository"
+	"go-ecommerce-app/internal/telemetry"
 	"go-ecommerce-app/pkg/notification"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type UserService struct {
-	Repo   repository.UserRepository
-	CRepo  repository.CatalogRepository
-	Auth   helper.Auth
-	Config configs.AppConfig
+	Repo        repository.UserRepository
+	CRepo       repository.CatalogRepository
+	Promotions  PromotionService
+	Tax         TaxService
+	Shipping    ShippingService
+	Ledger      LedgerService
+	Payments    TransactionService
+	Inventory   InventoryService
+	Wishlists   WishlistService
+	StockAlerts StockAlertService
+	Guard       AuthGuard
+	Notifier    notification.NotificationClient
+	UnitOfWork  repository.UnitOfWork
+	Auth        helper.Auth
+	Config      configs.AppConfig
 }
 
-func (s *UserService) SignUp(input dto.UserSignUp) (string, error) {
-	hPassword, err := s.Auth.CreateHashedPassword(input.Password)
+func (s *UserService) SignUp(ctx context.Context, input dto.UserSignUp) (string, error) {
+	if len(input.Email) < 1 {
+		return "", domain.ValidationError("email is required")
+	}
 
+	if _, err := s.Repo.FindUser(ctx, input.Email); err == nil {
+		return "", domain.ConflictError("an account with this email already exists")
+	}
+
+	hPassword, err := s.Auth.CreateHashedPassword(input.Password)
 	if err != nil {
-		return "", err
+		return "", domain.ValidationError(err.Error())
 	}
 
-	user, _ := s.Repo.CreateUser(domain.User{
+	user, err := s.Repo.CreateUser(ctx, domain.User{
 		Email:    input.Email,
 		Password: hPassword,
 		Phone:    input.Phone,
 	})
+	if err != nil {
+		return "", err
+	}
+	telemetry.Signups.Inc()
+
+	s.mergeGuestCartOnAuth(ctx, input.CartToken, user.ID)
 
 	return s.Auth.GenerateToken(user.ID, user.Email, user.UserType)
 }
 
-func (s *UserService) findUserByEmail(email string) (*domain.User, error) {
-	user, err := s.Repo.FindUser(email)
+func (s *UserService) findUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	user, err := s.Repo.FindUser(ctx, email)
 
 	return &user, err
 }
 
-func (s *UserService) Login(email string, password string) (string, error) {
-	user, err := s.findUserByEmail(email)
+func (s *UserService) Login(ctx context.Context, input dto.UserLogin) (string, error) {
+	guardKey := "login:" + strings.ToLower(strings.TrimSpace(input.Email))
+	if err := s.Guard.Allow(ctx, guardKey); err != nil {
+		return "", err
+	}
+
+	user, err := s.findUserByEmail(ctx, input.Email)
 	if err != nil {
-		return "", errors.New("user does not exist")
+		return "", s.Guard.Fail(ctx, guardKey, domain.UnauthorizedError("invalid credentials"))
 	}
 
 	// Compare password and generate token if successful login
-	err = s.Auth.VerifyPassword(password, user.Password)
+	err = s.Auth.VerifyPassword(input.Password, user.Password)
 	if err != nil {
-		return "", err
+		return "", s.Guard.Fail(ctx, guardKey, domain.UnauthorizedError("invalid credentials"))
 	}
+	s.Guard.Succeed(ctx, guardKey)
+
+	s.mergeGuestCartOnAuth(ctx, input.CartToken, user.ID)
 
 	// generate token
 	return s.Auth.GenerateToken(user.ID, user.Email, user.UserType)
 }
 
-func (s *UserService) isVerifiedUser(id uint) bool {
-	currentUser, err := s.Repo.FindUserByID(id)
+func (s *UserService) isVerifiedUser(ctx context.Context, id uint) bool {
+	currentUser, err := s.Repo.FindUserByID(ctx, id)
 
 	return err == nil && currentUser.Verified
 }
 
-func (s *UserService) GetVerificationCode(e domain.User) error {
+func (s *UserService) GetVerificationCode(ctx context.Context, e domain.User) error {
 
 	// if user already verified
-	if s.isVerifiedUser(e.ID) {
-		return errors.New("user already verified")
+	if s.isVerifiedUser(ctx, e.ID) {
+		return domain.ConflictError("user already verified")
+	}
+
+	// limit how many codes are sent to one account
+	if err := s.Guard.Allow(ctx, fmt.Sprintf("verify-code:%d", e.ID)); err != nil {
+		return err
 	}
 
 	// generate verification code
@@ -81,45 +124,55 @@ func (s *UserService) GetVerificationCode(e domain.User) error {
 		Expiry: time.Now().Add(30 * time.Minute),
 		Code:   code,
 	}
-	_, err = s.Repo.UpdateUser(e.ID, user)
+	_, err = s.Repo.UpdateUser(ctx, e.ID, user)
 	if err != nil {
 		return errors.New("unable to update user with verification code")
 	}
 
-	user, _ = s.Repo.FindUserByID(e.ID)
-	msg := fmt.Sprintf("Your verification code is %d", code)
+	user, _ = s.Repo.FindUserByID(ctx, e.ID)
+	msg := fmt.Sprintf("Your verification code is %s", code)
 
 	// send SMS
-	notificationClient := notification.NewNotificationClient(s.Config)
-	err = notificationClient.SendSMS(user.Phone, msg)
+	err = s.Notifier.SendSMS(ctx, user.Phone, msg)
 	if err != nil {
+		telemetry.SmsSent.WithLabelValues("failed").Inc()
 		return errors.New("unable to send SMS")
 	}
 
+	if s.Config.Sms.Enabled {
+		telemetry.SmsSent.WithLabelValues("sent").Inc()
+	}
+
 	return nil
 }
 
-func (s *UserService) VerifyCode(id uint, code string) error {
+func (s *UserService) VerifyCode(ctx context.Context, id uint, code string) error {
 	// if user already verified
-	if s.isVerifiedUser(id) {
-		return errors.New("user already verified")
+	if s.isVerifiedUser(ctx, id) {
+		return domain.ConflictError("user already verified")
 	}
 
-	user, err := s.Repo.FindUserByID(id)
+	guardKey := fmt.Sprintf("verify:%d", id)
+	if err := s.Guard.Allow(ctx, guardKey); err != nil {
+		return err
+	}
+
+	user, err := s.Repo.FindUserByID(ctx, id)
 	if err != nil {
 		return err
 	}
 	if user.Code != code {
-		return errors.New("verification code does not match")
+		return s.Guard.Fail(ctx, guardKey, domain.ValidationError("verification code does not match"))
 	}
 	if !time.Now().Before(user.Expiry) {
-		return errors.New("verification code expired")
+		return domain.ValidationError("verification code expired")
 	}
+	s.Guard.Succeed(ctx, guardKey)
 
 	updateUser := domain.User{
 		Verified: true,
 	}
-	_, err = s.Repo.UpdateUser(id, updateUser)
+	_, err = s.Repo.UpdateUser(ctx, id, updateUser)
 	if err != nil {
 		return errors.New("unable to verify user")
 	}
@@ -127,9 +180,9 @@ func (s *UserService) VerifyCode(id uint, code string) error {
 	return nil
 }
 
-func (s *UserService) CreateProfile(id uint, input dto.ProfileInput) error {
+func (s *UserService) CreateProfile(ctx context.Context, id uint, input dto.ProfileInput) error {
 
-	user, err := s.Repo.FindUserByID(id)
+	user, err := s.Repo.FindUserByID(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -140,29 +193,27 @@ func (s *UserService) CreateProfile(id uint, input dto.ProfileInput) error {
 	if input.LastName != "" {
 		user.LastName = input.LastName
 	}
-	_, err = s.Repo.UpdateUser(id, user)
-	if err != nil {
-		return err
-	}
-
 	address := domain.Address{
 		AddressLine1: input.AddressInput.AddressLine1,
 		AddressLine2: input.AddressInput.AddressLine2,
 		City:         input.AddressInput.City,
+		Region:       input.AddressInput.Region,
 		Country:      input.AddressInput.Country,
 		PostCode:     input.AddressInput.PostCode,
 		UserID:       id,
 	}
-	err = s.Repo.CreateProfile(address)
-	if err != nil {
-		return err
-	}
 
-	return nil
+	return s.atomically(ctx, func(svc *UserService) error {
+		if _, err := svc.Repo.UpdateUser(ctx, id, user); err != nil {
+			return err
+		}
+
+		return svc.Repo.CreateProfile(ctx, address)
+	})
 }
 
-func (s *UserService) GetProfile(id uint) (*domain.User, error) {
-	user, err := s.Repo.FindUserByID(id)
+func (s *UserService) GetProfile(ctx context.Context, id uint) (*domain.User, error) {
+	user, err := s.Repo.FindUserByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -170,8 +221,8 @@ func (s *UserService) GetProfile(id uint) (*domain.User, error) {
 	return &user, nil
 }
 
-func (s *UserService) UpdateProfile(id uint, input dto.ProfileInput) error {
-	user, err := s.Repo.FindUserByID(id)
+func (s *UserService) UpdateProfile(ctx context.Context, id uint, input dto.ProfileInput) error {
+	user, err := s.Repo.FindUserByID(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -183,59 +234,62 @@ func (s *UserService) UpdateProfile(id uint, input dto.ProfileInput) error {
 		user.LastName = input.LastName
 	}
 
-	_, err = s.Repo.UpdateUser(id, user)
 	address := domain.Address{
 		AddressLine1: input.AddressInput.AddressLine1,
 		AddressLine2: input.AddressInput.AddressLine2,
 		City:         input.AddressInput.City,
+		Region:       input.AddressInput.Region,
 		Country:      input.AddressInput.Country,
 		PostCode:     input.AddressInput.PostCode,
 		UserID:       id,
 	}
 
-	err = s.Repo.UpdateProfile(address)
-	if err != nil {
-		return err
-	}
+	return s.atomically(ctx, func(svc *UserService) error {
+		if _, err := svc.Repo.UpdateUser(ctx, id, user); err != nil {
+			return err
+		}
 
-	return nil
+		return svc.Repo.UpdateProfile(ctx, address)
+	})
 }
 
-func (s *UserService) BecomeSeller(id uint, input dto.SellerInput) (string, error) {
+func (s *UserService) BecomeSeller(ctx context.Context, id uint, input dto.SellerInput) (string, error) {
 	// Find existing user
-	user, _ := s.Repo.FindUserByID(id)
+	user, _ := s.Repo.FindUserByID(ctx, id)
 
 	if user.UserType == domain.SELLER {
-		return "", errors.New("user is already a seller")
+		return "", domain.ConflictError("user is already a seller")
 	}
 
-	// update user
-	seller, err := s.Repo.UpdateUser(id, domain.User{
-		FirstName: input.FirstName,
-		LastName:  input.LastName,
-		Phone:     input.PhoneNumber,
-		UserType:  domain.SELLER,
+	// update user and create bank account together
+	err := s.atomically(ctx, func(svc *UserService) error {
+		_, err := svc.Repo.UpdateUser(ctx, id, domain.User{
+			FirstName: input.FirstName,
+			LastName:  input.LastName,
+			Phone:     input.PhoneNumber,
+			UserType:  domain.SELLER,
+		})
+		if err != nil {
+			return err
+		}
+
+		return svc.Repo.CreateBankAccount(ctx, domain.BankAccount{
+			BankAccount: input.BankAccountNumber,
+			SwiftCode:   input.SwiftCode,
+			UserId:      id,
+			PaymentType: input.PaymentType,
+		})
 	})
 	if err != nil {
 		return "", err
 	}
 
 	// generate token
-	token, _ := s.Auth.GenerateToken(user.ID, user.Email, seller.UserType)
-
-	// Create bank account
-	err = s.Repo.CreateBankAccount(domain.BankAccount{
-		BankAccount: input.BankAccountNumber,
-		SwiftCode:   input.SwiftCode,
-		UserId:      id,
-		PaymentType: input.PaymentType,
-	})
-
-	return token, err
+	return s.Auth.GenerateToken(user.ID, user.Email, domain.SELLER)
 }
 
-func (s *UserService) FindCart(id uint) ([]domain.Cart, float64, error) {
-	cartItems, err := s.Repo.FindCartItems(id)
+func (s *UserService) FindCart(ctx context.Context, id uint) ([]domain.Cart, float64, error) {
+	cartItems, err := s.Repo.FindCartItems(ctx, id)
 
 	if err != nil {
 		return nil, 0, errors.New("cart does not exist")
@@ -250,117 +304,723 @@ func (s *UserService) FindCart(id uint) ([]domain.Cart, float64, error) {
 	return cartItems, totalAmount, err
 }
 
-func (s *UserService) CreateCart(input dto.CreateCartRequest, u domain.User) ([]domain.Cart, error) {
+// RevalidateCart refreshes every cart line from the live product and reports
+// the changes the buyer has to acknowledge before paying.
+func (s *UserService) RevalidateCart(ctx context.Context, id uint) ([]domain.Cart, []dto.CartWarning, error) {
+	cartItems, err := s.Repo.FindCartItems(ctx, id)
+	if err != nil {
+		return nil, nil, errors.New("cart does not exist")
+	}
+
+	warnings := []dto.CartWarning{}
+
+	for i, item := range cartItems {
+		product, err := s.CRepo.FindProductByID(ctx, int(item.ProductId))
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, err
+		}
+		if product == nil || product.ID < 1 {
+			warnings = append(warnings, dto.CartWarning{
+				CartItemId: item.ID,
+				ProductId:  item.ProductId,
+				Code:       dto.CartWarningProductRemoved,
+				Message:    fmt.Sprintf("%s is no longer available", item.Name),
+			})
+			continue
+		}
+
+		if product.Price != item.Price || product.Name != item.Name || product.ImageUrl != item.ImageUrl {
+			if product.Price != item.Price && item.PreviousPrice == 0 {
+				item.PreviousPrice = item.Price
+			}
+			item.Price = product.Price
+			item.Name = product.Name
+			item.ImageUrl = product.ImageUrl
+			if item.PreviousPrice == item.Price {
+				item.PreviousPrice = 0
+			}
+
+			err = s.Repo.UpdateCart(ctx, item)
+			if err != nil {
+				return nil, nil, errors.New("failed to update cart item")
+			}
+			cartItems[i] = item
+		}
+
+		if item.PreviousPrice > 0 {
+			warnings = append(warnings, dto.CartWarning{
+				CartItemId: item.ID,
+				ProductId:  item.ProductId,
+				Code:       dto.CartWarningPriceChanged,
+				Message:    fmt.Sprintf("price of %s changed from %.2f to %.2f", item.Name, item.PreviousPrice, item.Price),
+				OldPrice:   item.PreviousPrice,
+				NewPrice:   item.Price,
+			})
+		}
+
+		if product.Stock == 0 {
+			warnings = append(warnings, dto.CartWarning{
+				CartItemId: item.ID,
+				ProductId:  item.ProductId,
+				Code:       dto.CartWarningOutOfStock,
+				Message:    fmt.Sprintf("%s is out of stock", item.Name),
+			})
+		} else if product.Stock < item.Qty {
+			warnings = append(warnings, dto.CartWarning{
+				CartItemId: item.ID,
+				ProductId:  item.ProductId,
+				Code:       dto.CartWarningInsufficientStock,
+				Message:    fmt.Sprintf("only %d of %s left in stock", product.Stock, item.Name),
+				Available:  product.Stock,
+			})
+		}
+	}
+
+	return cartItems, warnings, nil
+}
+
+// CartSummary prices the revalidated cart, including the shipping chosen per
+// seller and the discount from any applied coupon, and collects every warning
+// the buyer has to review.
+func (s *UserService) CartSummary(ctx context.Context, id uint) (dto.CartSummary, error) {
+	cartItems, warnings, err := s.RevalidateCart(ctx, id)
+	if err != nil {
+		return dto.CartSummary{}, err
+	}
+
+	summary := dto.CartSummary{
+		Items:     cartItems,
+		Discounts: []dto.DiscountLine{},
+		Taxes:     []dto.TaxLine{},
+		Warnings:  warnings,
+	}
+	for _, item := range cartItems {
+		summary.SubTotal += item.Price * float64(item.Qty)
+	}
+
+	user, err := s.Repo.FindUserByID(ctx, id)
+	if err == nil && user.Address.ID > 0 {
+		summary.ShippingAddress = &user.Address
+	}
+
+	summary.Shipping, err = s.Shipping.CartShipping(ctx, id, summary.ShippingAddress, cartItems)
+	if err != nil {
+		return dto.CartSummary{}, err
+	}
+
+	shippingCosts := map[uint]float64{}
+	for _, sh := range summary.Shipping {
+		if sh.Selected != nil {
+			shippingCosts[sh.SellerId] = sh.Selected.Cost
+			summary.ShippingTotal += sh.Selected.Cost
+		}
+	}
+
+	discounts, err := s.Promotions.CartDiscounts(ctx, id, cartItems, shippingCosts)
+	if errors.Is(err, domain.ErrValidation) {
+		summary.Warnings = append(summary.Warnings, dto.CartWarning{
+			Code:    dto.CartWarningCouponInvalid,
+			Message: err.Error(),
+		})
+	} else if err != nil {
+		return dto.CartSummary{}, err
+	} else {
+		summary.Discounts = discounts
+	}
+
+	for _, d := range summary.Discounts {
+		summary.DiscountTotal += d.Amount
+	}
+	summary.SubTotal = roundCents(summary.SubTotal)
+	summary.ShippingTotal = roundCents(summary.ShippingTotal)
+	summary.DiscountTotal = roundCents(math.Min(summary.DiscountTotal, summary.SubTotal+summary.ShippingTotal))
+
+	if summary.ShippingAddress != nil {
+		summary.Taxes, summary.TaxTotal, err = s.Tax.CalculateTax(ctx, *summary.ShippingAddress, cartItems, summary.Discounts)
+		if err != nil {
+			return dto.CartSummary{}, err
+		}
+	}
+
+	summary.Total = roundCents(summary.SubTotal - summary.DiscountTotal + summary.ShippingTotal + summary.TaxTotal)
+
+	return summary, nil
+}
+
+// AcknowledgeCart accepts every outstanding change: new prices are kept,
+// unavailable lines are removed, quantities are reduced to the stock left and
+// coupons that no longer apply are detached.
+func (s *UserService) AcknowledgeCart(ctx context.Context, id uint) (dto.CartSummary, error) {
+	summary, err := s.CartSummary(ctx, id)
+	if err != nil {
+		return dto.CartSummary{}, err
+	}
+
+	items := map[uint]domain.Cart{}
+	for _, item := range summary.Items {
+		items[item.ID] = item
+	}
+
+	for _, w := range summary.Warnings {
+		item := items[w.CartItemId]
+
+		switch w.Code {
+		case dto.CartWarningProductRemoved, dto.CartWarningOutOfStock:
+			err = s.Repo.DeleteCartById(ctx, item.ID)
+		case dto.CartWarningInsufficientStock:
+			item.Qty = w.Available
+			err = s.Repo.UpdateCart(ctx, item)
+		case dto.CartWarningPriceChanged:
+			item.PreviousPrice = 0
+			err = s.Repo.UpdateCart(ctx, item)
+		case dto.CartWarningCouponInvalid:
+			err = s.Promotions.RemoveCoupon(ctx, id)
+		}
+		if err != nil {
+			return dto.CartSummary{}, errors.New("failed to update cart item")
+		}
+		items[item.ID] = item
+	}
+
+	return s.CartSummary(ctx, id)
+}
+
+// PrepareCheckout returns the priced cart, or a conflict error listing the
+// warnings while any change is still unacknowledged.
+func (s *UserService) PrepareCheckout(ctx context.Context, id uint) (dto.CartSummary, error) {
+	summary, err := s.CartSummary(ctx, id)
+	if err != nil {
+		return dto.CartSummary{}, err
+	}
+
+	if len(summary.Warnings) > 0 {
+		return dto.CartSummary{}, domain.NewErrorWithDetails(domain.ErrConflict,
+			"cart has changed, please review and acknowledge the changes before checkout", summary.Warnings)
+	}
+
+	if len(summary.Items) == 0 {
+		return dto.CartSummary{}, domain.ValidationError("cart is empty")
+	}
+
+	if summary.ShippingAddress == nil {
+		return dto.CartSummary{}, domain.ValidationError("a shipping address is required to checkout")
+	}
+
+	if err = missingShipping(summary.Shipping); err != nil {
+		return dto.CartSummary{}, err
+	}
+
+	return summary, nil
+}
+
+func (s *UserService) SelectShipping(ctx context.Context, id uint, input dto.SelectShippingRequest) (dto.CartSummary, error) {
+	cartItems, err := s.Repo.FindCartItems(ctx, id)
+	if err != nil {
+		return dto.CartSummary{}, errors.New("cart does not exist")
+	}
+
+	user, err := s.Repo.FindUserByID(ctx, id)
+	if err != nil || user.Address.ID < 1 {
+		return dto.CartSummary{}, domain.ValidationError("please add a shipping address first")
+	}
+
+	err = s.Shipping.SelectMethod(ctx, id, input, &user.Address, cartItems)
+	if err != nil {
+		return dto.CartSummary{}, err
+	}
+
+	return s.CartSummary(ctx, id)
+}
+
+func (s *UserService) ApplyCoupon(ctx context.Context, id uint, code string) (dto.CartSummary, error) {
+	cartItems, err := s.Repo.FindCartItems(ctx, id)
+	if err != nil {
+		return dto.CartSummary{}, errors.New("cart does not exist")
+	}
+
+	_, err = s.Promotions.ApplyCoupon(ctx, code, id, cartItems)
+	if err != nil {
+		return dto.CartSummary{}, err
+	}
+
+	return s.CartSummary(ctx, id)
+}
+
+func (s *UserService) RemoveCoupon(ctx context.Context, id uint) (dto.CartSummary, error) {
+	err := s.Promotions.RemoveCoupon(ctx, id)
+	if err != nil {
+		return dto.CartSummary{}, errors.New("failed to remove coupon")
+	}
+
+	return s.CartSummary(ctx, id)
+}
+
+func (s *UserService) CreateCart(ctx context.Context, input dto.CreateCartRequest, u domain.User) ([]domain.Cart, error) {
 	// check if the cart exists for the user
-	cart, _ := s.Repo.FindCartItem(u.ID, input.ProductId)
+	cart, _ := s.Repo.FindCartItem(ctx, u.ID, input.ProductId)
 
-	if cart.ID > 0 {
-		if input.ProductId == 0 {
-			return nil, errors.New("product id is required")
+	err := s.saveCartItem(ctx, cart, input, domain.Cart{UserId: u.ID})
+	if err != nil {
+		return nil, err
+	}
+	telemetry.CartItemsAdded.WithLabelValues("user").Inc()
+
+	return s.Repo.FindCartItems(ctx, u.ID)
+}
+
+// CreateGuestCart adds to the anonymous cart identified by token, issuing a
+// new token when the visitor does not have one yet.
+func (s *UserService) CreateGuestCart(ctx context.Context, input dto.CreateCartRequest, token string) ([]domain.Cart, string, error) {
+	if len(token) < 1 {
+		newToken, err := helper.RandomToken(16)
+		if err != nil {
+			return nil, "", errors.New("failed to generate cart token")
 		}
+		token = newToken
+	}
 
-		if input.Qty < 1 {
-			err := s.Repo.DeleteCartById(cart.ID)
+	cart, _ := s.Repo.FindGuestCartItem(ctx, token, input.ProductId)
+
+	err := s.saveCartItem(ctx, cart, input, domain.Cart{CartToken: token})
+	if err != nil {
+		return nil, "", err
+	}
+	telemetry.CartItemsAdded.WithLabelValues("guest").Inc()
+
+	cartItems, err := s.Repo.FindGuestCartItems(ctx, token)
+	return cartItems, token, err
+}
+
+func (s *UserService) FindGuestCart(ctx context.Context, token string) ([]domain.Cart, float64, error) {
+	if len(token) < 1 {
+		return []domain.Cart{}, 0, nil
+	}
+
+	cartItems, err := s.Repo.FindGuestCartItems(ctx, token)
+	if err != nil {
+		return nil, 0, errors.New("cart does not exist")
+	}
+
+	var totalAmount float64
+	for _, item := range cartItems {
+		totalAmount += item.Price * float64(item.Qty)
+	}
+
+	return cartItems, totalAmount, nil
+}
+
+// MergeGuestCart moves the guest cart lines into the user's cart, adding
+// quantities for products that are already there.
+func (s *UserService) MergeGuestCart(ctx context.Context, token string, uId uint) error {
+	guestItems, err := s.Repo.FindGuestCartItems(ctx, token)
+	if err != nil {
+		return errors.New("cart does not exist")
+	}
+
+	return s.atomically(ctx, func(svc *UserService) error {
+		for _, item := range guestItems {
+			existing, _ := svc.Repo.FindCartItem(ctx, uId, item.ProductId)
+			if existing.ID > 0 {
+				existing.Qty += item.Qty
+				err = svc.Repo.UpdateCart(ctx, existing)
+			} else {
+				item.ID = 0
+				item.UserId = uId
+				item.CartToken = ""
+				err = svc.Repo.CreateCart(ctx, item)
+			}
 			if err != nil {
-				return nil, errors.New("failed to delete cart item")
+				return errors.New("failed to merge cart item")
 			}
+		}
+
+		return svc.Repo.DeleteGuestCartItems(ctx, token)
+	})
+}
+
+// SaveForLater moves a cart line to one of the user's wishlists, or to the
+// "Saved for later" list when wishlistId is zero.
+func (s *UserService) SaveForLater(ctx context.Context, productId uint, wishlistId uint, u domain.User) (*domain.Wishlist, error) {
+	cart, err := s.Repo.FindCartItem(ctx, u.ID, productId)
+	if err != nil || cart.ID < 1 {
+		return nil, domain.NotFoundError("product is not in the cart")
+	}
+
+	var list *domain.Wishlist
+	err = s.atomically(ctx, func(svc *UserService) error {
+		if wishlistId > 0 {
+			list, err = svc.Wishlists.ownWishlist(ctx, wishlistId, u)
 		} else {
-			cart.Qty = input.Qty
-			err := s.Repo.UpdateCart(cart)
-			if err != nil {
-				return nil, errors.New("failed to update cart item")
+			list, err = svc.Wishlists.defaultWishlist(ctx, u)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err = svc.Wishlists.Repo.FindWishlistItem(ctx, list.ID, productId); err != nil {
+			if err = svc.Wishlists.addItem(ctx, list, productId); err != nil {
+				return err
 			}
 		}
-	} else {
-		product, _ := s.CRepo.FindProductByID(int(input.ProductId))
-		if product.ID < 1 {
-			return nil, errors.New("product does not exist")
-		}
-
-		err := s.Repo.CreateCart(domain.Cart{
-			ProductId: input.ProductId,
-			UserId:    u.ID,
-			Name:      product.Name,
-			ImageUrl:  product.ImageUrl,
-			Qty:       input.Qty,
-			Price:     product.Price,
-			SellerId:  uint(product.UserId),
-		})
+
+		if err = svc.Repo.DeleteCartById(ctx, cart.ID); err != nil {
+			return errors.New("failed to delete cart item")
+		}
+
+		list, err = svc.Wishlists.Repo.FindWishlistById(ctx, list.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// MoveToCart takes a product off a wishlist and adds qty of it to the cart.
+func (s *UserService) MoveToCart(ctx context.Context, wishlistId uint, productId uint, qty int, u domain.User) ([]domain.Cart, error) {
+	if qty < 1 {
+		return nil, domain.ValidationError("quantity must be at least 1")
+	}
+
+	err := s.atomically(ctx, func(svc *UserService) error {
+		list, err := svc.Wishlists.ownWishlist(ctx, wishlistId, u)
+		if err != nil {
+			return err
+		}
+
+		item, err := svc.Wishlists.Repo.FindWishlistItem(ctx, list.ID, productId)
 		if err != nil {
-			return nil, errors.New("failed to add product to cart")
+			return domain.NotFoundError("product is not on this wishlist")
 		}
+
+		cart, _ := svc.Repo.FindCartItem(ctx, u.ID, productId)
+		err = svc.saveCartItem(ctx, cart, dto.CreateCartRequest{ProductId: productId, Qty: cart.Qty + uint(qty)}, domain.Cart{UserId: u.ID})
+		if err != nil {
+			return err
+		}
+
+		return svc.Wishlists.Repo.DeleteWishlistItem(ctx, item.ID)
+	})
+	if err != nil {
+		return nil, err
 	}
+	telemetry.CartItemsAdded.WithLabelValues("user").Inc()
 
-	return s.Repo.FindCartItems(u.ID)
+	return s.Repo.FindCartItems(ctx, u.ID)
 }
 
-func (s *UserService) CreateOrder(u domain.User) (string, error) {
-	// get cart items for the user
-	cartItems, amount, err := s.FindCart(u.ID)
+// ExpireGuestCarts deletes guest carts left untouched for longer than the
+// configured TTL.
+func (s *UserService) ExpireGuestCarts(ctx context.Context) (int64, error) {
+	return s.Repo.DeleteExpiredGuestCarts(ctx, time.Now().Add(-s.Config.Cart.GuestTTL))
+}
+
+// atomically runs fn with a copy of the service whose repositories, and those
+// of the services it calls, share one transaction. Without a unit of work, as
+// in unit tests, fn runs on the service itself.
+func (s *UserService) atomically(ctx context.Context, fn func(svc *UserService) error) error {
+	if s.UnitOfWork == nil {
+		return fn(s)
+	}
+
+	return s.UnitOfWork.Do(ctx, func(tx repository.Tx) error {
+		svc := *s
+		svc.Repo = tx.Users
+		svc.CRepo = tx.Catalog
+		svc.Promotions.Repo, svc.Promotions.CRepo = tx.Promotions, tx.Catalog
+		svc.Tax.CRepo = tx.Catalog
+		svc.Shipping.Repo, svc.Shipping.CRepo = tx.Shipping, tx.Catalog
+		svc.Ledger.Repo = tx.Ledger
+		svc.Payments.Repo = tx.Transactions
+		svc.Inventory.Repo = tx.Inventory
+		svc.Wishlists.Repo, svc.Wishlists.CRepo = tx.Wishlists, tx.Catalog
+
+		return fn(&svc)
+	})
+}
+
+func (s *UserService) mergeGuestCartOnAuth(ctx context.Context, token string, uId uint) {
+	if len(token) < 1 || uId < 1 {
+		return
+	}
+
+	if err := s.MergeGuestCart(ctx, token, uId); err != nil {
+		slog.WarnContext(ctx, "merging guest cart failed", "user_id", uId, "error", err)
+	}
+}
+
+// saveCartItem creates, updates or removes a cart line. owner carries the
+// UserId or CartToken used when a new line is created.
+func (s *UserService) saveCartItem(ctx context.Context, cart domain.Cart, input dto.CreateCartRequest, owner domain.Cart) error {
+	if input.ProductId == 0 {
+		return domain.ValidationError("product id is required")
+	}
+
+	if cart.ID > 0 {
+		if input.Qty < 1 {
+			err := s.Repo.DeleteCartById(ctx, cart.ID)
+			if err != nil {
+				return errors.New("failed to delete cart item")
+			}
+		} else {
+			cart.Qty = input.Qty
+			err := s.Repo.UpdateCart(ctx, cart)
+			if err != nil {
+				return errors.New("failed to update cart item")
+			}
+		}
+
+		return nil
+	}
+
+	if input.Qty < 1 {
+		return domain.ValidationError("quantity must be at least 1")
+	}
+
+	product, err := s.CRepo.FindProductByID(ctx, int(input.ProductId))
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.NotFoundError("product does not exist")
+	}
 	if err != nil {
-		return "", errors.New("cart does not exist")
+		return err
 	}
 
-	if len(cartItems) == 0 {
-		return "", errors.New("cart is empty. cannot create order")
+	err = s.Repo.CreateCart(ctx, domain.Cart{
+		ProductId: input.ProductId,
+		UserId:    owner.UserId,
+		CartToken: owner.CartToken,
+		Name:      product.Name,
+		ImageUrl:  product.ImageUrl,
+		Qty:       input.Qty,
+		Price:     product.Price,
+		SellerId:  uint(product.UserId),
+	})
+	if err != nil {
+		return errors.New("failed to add product to cart")
 	}
 
-	// find success payment reference status
-	paymentId := "pay_12345"
-	txnId := "txn_12345"
-	orderRef, _ := helper.RandomNumbers(8)
+	return nil
+}
+
+func (s *UserService) CreateOrder(ctx context.Context, u domain.User) (string, error) {
+	// the order is placed only against a payment that went through, and
+	// for what it paid for, whatever the cart holds now
+	paid, err := s.Payments.PaidPayment(ctx, u.ID)
+	if err != nil {
+		return "", err
+	}
+	summary, err := paidCheckout(*paid)
+	if err != nil {
+		return "", err
+	}
+	orderRef := paid.OrderId
 
 	// create order with generated order reference
 	var orderItems []domain.OrderItem
 
-	for _, item := range cartItems {
+	taxes := map[uint]dto.TaxLine{}
+	for _, t := range summary.Taxes {
+		taxes[t.CartItemId] = t
+	}
+
+	for _, item := range summary.Items {
+		tax := taxes[item.ID]
 		orderItems = append(orderItems, domain.OrderItem{
-			ProductId: item.ProductId,
-			Qty:       int(item.Qty),
-			Price:     item.Price,
-			Name:      item.Name,
-			ImageUrl:  item.ImageUrl,
-			SellerId:  item.SellerId,
+			ProductId:     item.ProductId,
+			Qty:           int(item.Qty),
+			Price:         item.Price,
+			Name:          item.Name,
+			ImageUrl:      item.ImageUrl,
+			SellerId:      item.SellerId,
+			TaxCountry:    tax.Country,
+			TaxRegion:     tax.Region,
+			TaxRate:       tax.Rate,
+			TaxableAmount: tax.Taxable,
+			TaxAmount:     tax.Amount,
+		})
+	}
+
+	var orderDiscounts []domain.OrderDiscount
+	for _, d := range summary.Discounts {
+		orderDiscounts = append(orderDiscounts, domain.OrderDiscount{
+			CouponId:    d.CouponId,
+			Code:        d.Code,
+			Description: d.Description,
+			Amount:      d.Amount,
+		})
+	}
+
+	var shipments []domain.OrderShipment
+	for _, sh := range summary.Shipping {
+		shipments = append(shipments, domain.OrderShipment{
+			SellerId:          sh.SellerId,
+			ShippingProfileId: sh.Selected.ShippingProfileId,
+			Method:            sh.Selected.Name,
+			Cost:              sh.Selected.Cost,
+			Status:            domain.ShipmentStatusPending,
 		})
 	}
 
 	order := domain.Order{
 		UserId:         u.ID,
-		PaymentId:      paymentId,
-		TransactionId:  txnId,
+		PaymentId:      paid.PaymentId,
+		TransactionId:  strconv.FormatUint(uint64(paid.ID), 10),
 		OrderRefNumber: orderRef,
-		Amount:         amount,
+		SubTotal:       summary.SubTotal,
+		DiscountAmount: summary.DiscountTotal,
+		ShippingAmount: summary.ShippingTotal,
+		TaxAmount:      summary.TaxTotal,
+		Amount:         summary.Total,
 		Items:          orderItems,
+		Discounts:      orderDiscounts,
+		Shipments:      shipments,
+		SubOrders:      s.Ledger.SplitOrder(summary),
+	}
+	var sold []domain.Product
+	// unfillable is set when the order can no longer be placed as paid for,
+	// because stock or a coupon ran out after the buyer paid
+	var unfillable bool
+	err = s.atomically(ctx, func(svc *UserService) error {
+		if err := svc.Payments.Repo.MarkPaymentOrdered(ctx, paid.ID); err != nil {
+			return err
+		}
+
+		if err := svc.Repo.CreateOrder(ctx, &order); err != nil {
+			return err
+		}
+
+		products, err := svc.Inventory.RecordOrder(ctx, &order)
+		if err != nil {
+			unfillable = errors.Is(err, domain.ErrConflict)
+			return err
+		}
+		sold = products
+
+		if err := svc.Promotions.RedeemDiscounts(ctx, u.ID, order.ID, summary.Discounts); err != nil {
+			unfillable = errors.Is(err, domain.ErrConflict)
+			return err
+		}
+
+		// cash on delivery is owed to the sellers once it has been collected
+		if paid.Status == domain.PaymentStatusSuccess {
+			if err := svc.Ledger.RecordOrder(ctx, &order); err != nil {
+				return err
+			}
+		}
+
+		if err := svc.Shipping.ClearSelections(ctx, u.ID); err != nil {
+			return errors.New("failed to clear shipping selections")
+		}
+
+		// remove cart items
+		if err := svc.Repo.DeleteCartItems(ctx, u.ID); err != nil {
+			return errors.New("failed to clear cart items")
+		}
+
+		return nil
+	})
+	if unfillable {
+		// the money goes back rather than staying with a payment no order can
+		// be placed for; the stock taken was rolled back with the order, so
+		// there is none to put back
+		if refundErr := s.Payments.RefundPayment(ctx, paid); refundErr != nil {
+			slog.ErrorContext(ctx, "refunding payment for an unfillable order failed", "payment_id", paid.ID, "error", refundErr)
+			return "", err
+		}
+		return "", domain.ConflictError(err.Error() + ", your payment has been refunded")
 	}
-	err = s.Repo.CreateOrder(order)
 	if err != nil {
 		return "", err
 	}
+	telemetry.OrdersPlaced.Inc()
+	telemetry.OrderValue.Add(order.Amount)
+
+	for i, product := range sold {
+		s.StockAlerts.CheckLowStock(ctx, product, product.Stock+uint(order.Items[i].Qty))
+	}
 
 	// send notification to user
 
-	// remove cart items
-	err = s.Repo.DeleteCartItems(u.ID)
+	return orderRef, nil
+}
+
+// CollectPayment records that the cash of an order paid on delivery was
+// collected, and only then credits the order's sellers.
+func (s *UserService) CollectPayment(ctx context.Context, orderId uint) error {
+	order, err := s.Payments.Repo.FindPlacedOrder(ctx, orderId)
 	if err != nil {
-		return "", errors.New("failed to clear cart items")
+		return err
 	}
 
-	return orderRef, nil
+	paymentId, err := strconv.ParseUint(order.TransactionId, 10, 64)
+	if err != nil {
+		return domain.ConflictError("the order has no payment to collect")
+	}
+
+	return s.atomically(ctx, func(svc *UserService) error {
+		if err := svc.Payments.Repo.MarkPaymentCollected(ctx, uint(paymentId)); err != nil {
+			return err
+		}
+
+		return svc.Ledger.RecordOrder(ctx, order)
+	})
 }
 
-func (s *UserService) GetOrders(u domain.User) ([]domain.Order, error) {
-	orders, err := s.Repo.FindOrders(u.ID)
+// CancelOrder calls off the buyer's order while none of it has shipped. The
+// items go back in stock and the payment is refunded, or called off when it
+// was still to be collected on delivery.
+func (s *UserService) CancelOrder(ctx context.Context, id uint, u domain.User) error {
+	order, err := s.Repo.FindOrderById(ctx, id, u.ID)
 	if err != nil {
-		return nil, err
+		return domain.NotFoundError("order does not exist")
+	}
+
+	paymentId, err := strconv.ParseUint(order.TransactionId, 10, 64)
+	if err != nil {
+		return domain.ConflictError("the order has no payment to refund")
+	}
+	paid, err := s.Payments.Repo.FindPayment(ctx, uint(paymentId))
+	if err != nil {
+		return err
 	}
 
-	return orders, nil
+	// money taken for the order was also credited to its sellers
+	refunded := paid.Status == domain.PaymentStatusSuccess
+	reason := domain.InventoryReasonCancellation
+	if refunded {
+		reason = domain.InventoryReasonRefund
+	}
+
+	return s.atomically(ctx, func(svc *UserService) error {
+		if err := svc.Repo.CancelOrder(ctx, order.ID); err != nil {
+			return err
+		}
+
+		if err := svc.Inventory.RestockOrder(ctx, &order, reason); err != nil {
+			return err
+		}
+
+		if refunded {
+			if err := svc.Ledger.ReverseOrder(ctx, &order); err != nil {
+				return err
+			}
+		}
+
+		// the provider comes last, so a refund it turns down leaves the
+		// order as it was
+		return svc.Payments.RefundPayment(ctx, paid)
+	})
+}
+
+func (s *UserService) GetOrders(ctx context.Context, u domain.User, p repository.Pagination, f repository.OrderFilter) ([]domain.Order, repository.PageInfo, error) {
+	return s.Repo.FindOrders(ctx, u.ID, p, f)
 }
 
-func (s *UserService) GetOrderById(id uint, uId uint) (domain.Order, error) {
-	order, err := s.Repo.FindOrderById(id, uId)
+func (s *UserService) GetOrderById(ctx context.Context, id uint, uId uint) (domain.Order, error) {
+	order, err := s.Repo.FindOrderById(ctx, id, uId)
 	if err != nil {
-		return order, err
+		return order, domain.NotFoundError("order does not exist")
 	}
 
 	return order, nil
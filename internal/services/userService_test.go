@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-ecommerce-app/configs"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"go-ecommerce-app/pkg/notification"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestUserService() (*UserService, *fakeUserRepository, *notification.FakeClient) {
+	repo := newFakeUserRepository()
+	notifier := notification.NewFakeClient()
+	config := configs.Defaults()
+
+	svc := &UserService{
+		Repo: repo,
+		Guard: AuthGuard{
+			Store:        repository.NewMemoryRateLimitStore(),
+			AccountLimit: 10,
+			Window:       time.Minute,
+			Threshold:    3,
+			Lockout:      time.Minute,
+			MaxLockout:   time.Hour,
+		},
+		Notifier: notifier,
+		Auth:     helper.SetupAuth("test-secret"),
+		Config:   config,
+	}
+
+	return svc, repo, notifier
+}
+
+func TestSignUpRejectsDuplicateEmail(t *testing.T) {
+	svc, _, _ := newTestUserService()
+	ctx := context.Background()
+	input := dto.UserSignUp{UserLogin: dto.UserLogin{Email: "buyer@example.com", Password: "secret123"}, Phone: "+15550100"}
+
+	token, err := svc.SignUp(ctx, input)
+	if err != nil || len(token) < 1 {
+		t.Fatalf("SignUp() = %q, %v; want a token", token, err)
+	}
+
+	_, err = svc.SignUp(ctx, input)
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("second SignUp() error = %v; want conflict", err)
+	}
+}
+
+func TestLoginLocksOutAfterRepeatedFailures(t *testing.T) {
+	svc, _, _ := newTestUserService()
+	ctx := context.Background()
+
+	_, err := svc.SignUp(ctx, dto.UserSignUp{UserLogin: dto.UserLogin{Email: "buyer@example.com", Password: "secret123"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := dto.UserLogin{Email: "buyer@example.com", Password: "wrong-password"}
+	for i := 0; i < svc.Guard.Threshold; i++ {
+		if _, err = svc.Login(ctx, wrong); !errors.Is(err, domain.ErrUnauthorized) && !errors.Is(err, domain.ErrRateLimited) {
+			t.Fatalf("Login() attempt %d error = %v; want unauthorized", i+1, err)
+		}
+	}
+
+	_, err = svc.Login(ctx, dto.UserLogin{Email: "buyer@example.com", Password: "secret123"})
+	if !errors.Is(err, domain.ErrRateLimited) {
+		t.Fatalf("Login() while locked out error = %v; want rate limited", err)
+	}
+}
+
+func TestVerificationCodeIsTextedAndVerifies(t *testing.T) {
+	svc, repo, notifier := newTestUserService()
+	ctx := context.Background()
+
+	user, _ := repo.CreateUser(ctx, domain.User{Email: "buyer@example.com", Phone: "+15550100"})
+
+	if err := svc.GetVerificationCode(ctx, user); err != nil {
+		t.Fatalf("GetVerificationCode() error = %v", err)
+	}
+
+	messages := notifier.Messages()
+	if len(messages) != 1 || messages[0].Phone != user.Phone {
+		t.Fatalf("messages = %+v; want one text to %s", messages, user.Phone)
+	}
+	code := messages[0].Body[strings.LastIndex(messages[0].Body, " ")+1:]
+	if len(code) != helper.VerificationCodeLength {
+		t.Fatalf("code = %q; want %d digits", code, helper.VerificationCodeLength)
+	}
+
+	if err := svc.VerifyCode(ctx, user.ID, "000000x"); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("VerifyCode() with a wrong code error = %v; want validation error", err)
+	}
+	if err := svc.VerifyCode(ctx, user.ID, code); err != nil {
+		t.Fatalf("VerifyCode() error = %v", err)
+	}
+
+	verified, _ := repo.FindUserByID(ctx, user.ID)
+	if !verified.Verified {
+		t.Fatal("user is not verified")
+	}
+}
+
+func TestVerificationCodeReportsSmsFailure(t *testing.T) {
+	svc, repo, notifier := newTestUserService()
+	ctx := context.Background()
+	notifier.Err = errors.New("twilio unavailable")
+
+	user, _ := repo.CreateUser(ctx, domain.User{Email: "buyer@example.com", Phone: "+15550100"})
+
+	if err := svc.GetVerificationCode(ctx, user); err == nil {
+		t.Fatal("GetVerificationCode() succeeded; want an error when the text cannot be sent")
+	}
+}
+
+func TestExpireGuestCartsUsesConfiguredTTL(t *testing.T) {
+	svc, repo, _ := newTestUserService()
+	svc.Config.Cart.GuestTTL = 48 * time.Hour
+
+	if _, err := svc.ExpireGuestCarts(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	age := time.Since(repo.expiredBefore)
+	if age < 48*time.Hour || age > 49*time.Hour {
+		t.Fatalf("expired carts untouched for %s; want 48h", age)
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-ecommerce-app/internal/domain"
+	"go-ecommerce-app/internal/dto"
+	"go-ecommerce-app/internal/helper"
+	"go-ecommerce-app/internal/repository"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+const maxWishlistName = 100
+
+type WishlistService struct {
+	Repo   repository.WishlistRepository
+	CRepo  repository.CatalogRepository
+	Alerts NotificationService
+	Auth   helper.Auth
+}
+
+func (s WishlistService) CreateWishlist(ctx context.Context, input dto.WishlistRequest, u domain.User) (*domain.Wishlist, error) {
+	name, err := wishlistName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.Repo.FindWishlistByName(ctx, u.ID, name); err == nil {
+		return nil, domain.ConflictError(fmt.Sprintf("you already have a wishlist named %q", name))
+	}
+
+	list, err := s.newWishlist(ctx, name, u)
+	if err != nil {
+		return nil, err
+	}
+	if input.Public != nil && *input.Public {
+		list.Public = true
+		if err = s.Repo.UpdateWishlist(ctx, list); err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+func (s WishlistService) GetWishlists(ctx context.Context, u domain.User) ([]domain.Wishlist, error) {
+	return s.Repo.FindWishlists(ctx, u.ID)
+}
+
+func (s WishlistService) GetWishlist(ctx context.Context, id uint, u domain.User) (*domain.Wishlist, error) {
+	return s.ownWishlist(ctx, id, u)
+}
+
+// GetSharedWishlist returns the list behind a share link, as long as its
+// owner made it public.
+func (s WishlistService) GetSharedWishlist(ctx context.Context, token string) (*domain.Wishlist, error) {
+	list, err := s.Repo.FindWishlistByToken(ctx, token)
+	if err != nil || !list.Public {
+		return nil, domain.NotFoundError("wishlist not found")
+	}
+
+	return list, nil
+}
+
+// UpdateWishlist renames the list and shares or unshares it.
+func (s WishlistService) UpdateWishlist(ctx context.Context, id uint, input dto.WishlistRequest, u domain.User) (*domain.Wishlist, error) {
+	list, err := s.ownWishlist(ctx, id, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(strings.TrimSpace(input.Name)) > 0 {
+		name, err := wishlistName(input.Name)
+		if err != nil {
+			return nil, err
+		}
+		if other, err := s.Repo.FindWishlistByName(ctx, u.ID, name); err == nil && other.ID != list.ID {
+			return nil, domain.ConflictError(fmt.Sprintf("you already have a wishlist named %q", name))
+		}
+		list.Name = name
+	}
+	if input.Public != nil {
+		list.Public = *input.Public
+	}
+
+	if err = s.Repo.UpdateWishlist(ctx, list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (s WishlistService) DeleteWishlist(ctx context.Context, id uint, u domain.User) error {
+	list, err := s.ownWishlist(ctx, id, u)
+	if err != nil {
+		return err
+	}
+
+	return s.Repo.DeleteWishlist(ctx, list.ID)
+}
+
+func (s WishlistService) AddItem(ctx context.Context, id uint, input dto.WishlistItemRequest, u domain.User) (*domain.Wishlist, error) {
+	list, err := s.ownWishlist(ctx, id, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.addItem(ctx, list, input.ProductId); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.FindWishlistById(ctx, list.ID)
+}
+
+func (s WishlistService) RemoveItem(ctx context.Context, id uint, productId uint, u domain.User) (*domain.Wishlist, error) {
+	list, err := s.ownWishlist(ctx, id, u)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.Repo.FindWishlistItem(ctx, list.ID, productId)
+	if err != nil {
+		return nil, domain.NotFoundError("product is not on this wishlist")
+	}
+	if err = s.Repo.DeleteWishlistItem(ctx, item.ID); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.FindWishlistById(ctx, list.ID)
+}
+
+// NotifyPriceDrop texts the users watching the product when its price went
+// below oldPrice.
+func (s WishlistService) NotifyPriceDrop(ctx context.Context, product domain.Product, oldPrice float64) {
+	if product.Price >= oldPrice {
+		return
+	}
+
+	s.notifyWatchers(ctx, "price_drop", product, fmt.Sprintf("Price drop: %s on your wishlist is now %.2f (was %.2f)", product.Name, product.Price, oldPrice))
+}
+
+// NotifyBackInStock texts the users watching the product that it can be
+// bought again, leaving out the ones already told.
+func (s WishlistService) NotifyBackInStock(ctx context.Context, product domain.Product, told []domain.User) {
+	s.notifyWatchers(ctx, "wishlist_back_in_stock", product, fmt.Sprintf("Back in stock: %s on your wishlist is available again", product.Name), told...)
+}
+
+func (s WishlistService) notifyWatchers(ctx context.Context, kind string, product domain.Product, message string, told ...domain.User) {
+	users, err := s.Repo.FindProductWatchers(ctx, product.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "finding wishlist watchers failed", "product_id", product.ID, "error", err)
+		return
+	}
+
+	users = slices.DeleteFunc(users, func(u domain.User) bool {
+		return slices.ContainsFunc(told, func(t domain.User) bool { return t.ID == u.ID })
+	})
+	if len(users) < 1 {
+		return
+	}
+
+	s.Alerts.NotifyInBackground(ctx, kind, users, message)
+}
+
+// defaultWishlist returns the user's "Saved for later" list, creating it on
+// first use.
+func (s WishlistService) defaultWishlist(ctx context.Context, u domain.User) (*domain.Wishlist, error) {
+	if list, err := s.Repo.FindWishlistByName(ctx, u.ID, domain.DefaultWishlistName); err == nil {
+		return list, nil
+	}
+
+	return s.newWishlist(ctx, domain.DefaultWishlistName, u)
+}
+
+func (s WishlistService) newWishlist(ctx context.Context, name string, u domain.User) (*domain.Wishlist, error) {
+	token, err := helper.RandomToken(16)
+	if err != nil {
+		return nil, errors.New("failed to generate share token")
+	}
+
+	list := &domain.Wishlist{
+		UserId:     u.ID,
+		Name:       name,
+		ShareToken: token,
+		Items:      []domain.WishlistItem{},
+	}
+	if err = s.Repo.CreateWishlist(ctx, list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// addItem puts the product on the list, keeping its current name and price.
+func (s WishlistService) addItem(ctx context.Context, list *domain.Wishlist, productId uint) error {
+	if productId == 0 {
+		return domain.ValidationError("product id is required")
+	}
+
+	product, err := s.CRepo.FindProductByID(ctx, int(productId))
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.NotFoundError("product does not exist")
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.Repo.FindWishlistItem(ctx, list.ID, productId); err == nil {
+		return domain.ConflictError("product is already on this wishlist")
+	}
+
+	return s.Repo.CreateWishlistItem(ctx, &domain.WishlistItem{
+		WishlistId: list.ID,
+		ProductId:  product.ID,
+		Name:       product.Name,
+		ImageUrl:   product.ImageUrl,
+		SellerId:   uint(product.UserId),
+		Price:      product.Price,
+	})
+}
+
+func (s WishlistService) ownWishlist(ctx context.Context, id uint, u domain.User) (*domain.Wishlist, error) {
+	list, err := s.Repo.FindWishlistById(ctx, id)
+	if err != nil || list.UserId != u.ID {
+		return nil, domain.NotFoundError("wishlist not found")
+	}
+
+	return list, nil
+}
+
+func wishlistName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if len(name) < 1 {
+		return "", domain.ValidationError("wishlist name is required")
+	}
+	if len(name) > maxWishlistName {
+		return "", domain.ValidationError(fmt.Sprintf("wishlist name must be at most %d characters", maxWishlistName))
+	}
+
+	return name, nil
+}
@@ -0,0 +1,161 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	gormSpanKey  = "telemetry:span"
+	gormStartKey = "telemetry:start"
+)
+
+// GormTelemetry is a GORM plugin that times every query and wraps it in a
+// span, a child of the span carried by the query's context.
+type GormTelemetry struct{}
+
+func (GormTelemetry) Name() string {
+	return "telemetry"
+}
+
+func (p GormTelemetry) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("telemetry:before_create", p.start("create")),
+		cb.Create().After("gorm:create").Register("telemetry:after_create", p.end("create")),
+		cb.Query().Before("gorm:query").Register("telemetry:before_query", p.start("query")),
+		cb.Query().After("gorm:query").Register("telemetry:after_query", p.end("query")),
+		cb.Update().Before("gorm:update").Register("telemetry:before_update", p.start("update")),
+		cb.Update().After("gorm:update").Register("telemetry:after_update", p.end("update")),
+		cb.Delete().Before("gorm:delete").Register("telemetry:before_delete", p.start("delete")),
+		cb.Delete().After("gorm:delete").Register("telemetry:after_delete", p.end("delete")),
+		cb.Row().Before("gorm:row").Register("telemetry:before_row", p.start("row")),
+		cb.Row().After("gorm:row").Register("telemetry:after_row", p.end("row")),
+		cb.Raw().Before("gorm:raw").Register("telemetry:before_raw", p.start("raw")),
+		cb.Raw().After("gorm:raw").Register("telemetry:after_raw", p.end("raw")),
+	)
+}
+
+func (GormTelemetry) start(operation string) func(*gorm.DB) {
+	tracer := otel.Tracer("go-ecommerce-app/gorm")
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		ctx, span := tracer.Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemPostgreSQL),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+		db.InstanceSet(gormStartKey, time.Now())
+	}
+}
+
+func (GormTelemetry) end(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+
+		if value, ok := db.InstanceGet(gormStartKey); ok {
+			if start, ok := value.(time.Time); ok {
+				queryDuration.WithLabelValues(operation, db.Statement.Table).Observe(time.Since(start).Seconds())
+			}
+		}
+		if failed {
+			queryErrors.WithLabelValues(operation, db.Statement.Table).Inc()
+		}
+
+		value, ok := db.InstanceGet(gormSpanKey)
+		if !ok {
+			return
+		}
+		span, ok := value.(trace.Span)
+		if !ok {
+			return
+		}
+		defer span.End()
+
+		span.SetAttributes(
+			semconv.DBQueryText(db.Statement.SQL.String()),
+			attribute.String("db.sql.table", db.Statement.Table),
+			attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+		)
+
+		if failed {
+			span.RecordError(db.Error)
+			span.SetStatus(codes.Error, db.Error.Error())
+		}
+	}
+}
+
+// GormLogger sends GORM's own messages, slow queries and query errors to
+// slog, tagged with the query's request and trace ids.
+type GormLogger struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration
+	Level         logger.LogLevel
+}
+
+func NewGormLogger(l *slog.Logger) GormLogger {
+	return GormLogger{
+		Logger:        l,
+		SlowThreshold: 200 * time.Millisecond,
+		Level:         logger.Warn,
+	}
+}
+
+func (l GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	l.Level = level
+	return l
+}
+
+func (l GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.Level >= logger.Info {
+		l.Logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.Level >= logger.Warn {
+		l.Logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.Level >= logger.Error {
+		l.Logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.Level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.Level >= logger.Error:
+		sql, rows := fc()
+		l.Logger.ErrorContext(ctx, "query failed", "error", err, "sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.Level >= logger.Warn:
+		sql, rows := fc()
+		l.Logger.WarnContext(ctx, "slow query", "sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	case l.Level >= logger.Info:
+		sql, rows := fc()
+		l.Logger.DebugContext(ctx, "query", "sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	}
+}
@@ -0,0 +1,71 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"go-ecommerce-app/configs"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey int
+
+const requestIdKey contextKey = iota
+
+// WithRequestId returns a context carrying the id of the request it serves.
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey, id)
+}
+
+// RequestId returns the request id carried by ctx, if any.
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey).(string)
+	return id
+}
+
+// NewLogger builds the application logger. Records logged with a context are
+// tagged with its request id and the active trace and span ids, so a log line
+// can be matched to its request and trace.
+func NewLogger(w io.Writer, cfg configs.LogConfig) *slog.Logger {
+	opts := &slog.HandlerOptions{}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err == nil {
+		opts.Level = level
+	}
+
+	var handler slog.Handler = slog.NewJSONHandler(w, opts)
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	}
+
+	return slog.New(contextHandler{handler})
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestId(ctx); len(id) > 0 {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
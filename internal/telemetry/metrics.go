@@ -0,0 +1,100 @@
+package telemetry
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Registry holds every metric served on /metrics.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	queryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Time taken by database queries, by operation and table.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	queryErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "Database queries that failed, by operation and table.",
+	}, []string{"operation", "table"})
+)
+
+// Business counters, incremented by the services.
+var (
+	Signups = factory.NewCounter(prometheus.CounterOpts{
+		Name: "ecommerce_signups_total",
+		Help: "Users who signed up.",
+	})
+
+	CartItemsAdded = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "ecommerce_cart_items_added_total",
+		Help: "Items added to or updated in carts, by cart type (user or guest).",
+	}, []string{"cart"})
+
+	OrdersPlaced = factory.NewCounter(prometheus.CounterOpts{
+		Name: "ecommerce_orders_placed_total",
+		Help: "Orders placed.",
+	})
+
+	OrderValue = factory.NewCounter(prometheus.CounterOpts{
+		Name: "ecommerce_order_value_total",
+		Help: "Total amount of the orders placed.",
+	})
+
+	Payments = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "ecommerce_payments_total",
+		Help: "Payments started or settled, by provider and resulting status.",
+	}, []string{"provider", "status"})
+
+	SmsSent = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "ecommerce_sms_total",
+		Help: "Verification text messages, by result (sent or failed).",
+	}, []string{"result"})
+
+	CatalogChanges = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "ecommerce_catalog_changes_total",
+		Help: "Catalog changes, by entity (category or product) and action.",
+	}, []string{"entity", "action"})
+
+	Notifications = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "ecommerce_notifications_total",
+		Help: "Alerts texted to users, by kind and result (sent or failed).",
+	}, []string{"kind", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveRequest records a handled HTTP request.
+func ObserveRequest(method string, route string, status string, elapsed time.Duration) {
+	httpRequests.WithLabelValues(method, route, status).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// RegisterDBStats exposes the connection pool statistics of db.
+func RegisterDBStats(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
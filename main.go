@@ -1,17 +1,52 @@
 package main
 
 import (
+	"fmt"
 	"go-ecommerce-app/configs"
 	"go-ecommerce-app/internal/api"
-	"log"
+	"go-ecommerce-app/internal/telemetry"
+	"log/slog"
+	"os"
 )
 
 func main() {
-	cfg, err := configs.SetupEnv()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
 
+	args := os.Args[1:]
+	if len(args) >= 2 && args[0] == "config" && args[1] == "check" {
+		os.Exit(checkConfig(args[2:]))
+	}
+
+	cfg, err := configs.LoadAndValidate(args)
+	if err != nil {
+		slog.Error("config is not valid", "error", err)
+		os.Exit(1)
+	}
+
+	slog.SetDefault(telemetry.NewLogger(os.Stdout, cfg.Log))
+
+	if err = api.StartServer(cfg); err != nil {
+		slog.Error("server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// checkConfig prints the resolved configuration with its secrets redacted and
+// reports every validation problem, for use before a deploy.
+func checkConfig(args []string) int {
+	cfg, err := configs.Load(args)
 	if err != nil {
-		log.Fatalf("config file is lot loaded properly: %v\n", err)
+		fmt.Fprintf(os.Stderr, "loading config failed: %v\n", err)
+		return 2
+	}
+
+	fmt.Print(cfg)
+
+	if err = cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "\nconfig is not valid:\n%v\n", err)
+		return 1
 	}
 
-	api.StartServer(cfg)
+	fmt.Fprintln(os.Stderr, "\nconfig is valid")
+	return 0
 }
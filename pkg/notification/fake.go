@@ -0,0 +1,45 @@
+package notification
+
+import (
+	"context"
+	"sync"
+)
+
+// Message is a text message recorded by FakeClient.
+type Message struct {
+	Phone string
+	Body  string
+}
+
+// FakeClient records messages instead of sending them. Set Err to make
+// sending fail.
+type FakeClient struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error
+}
+
+func NewFakeClient() *FakeClient {
+	return &FakeClient{}
+}
+
+// SendSMS implements NotificationClient.
+func (f *FakeClient) SendSMS(ctx context.Context, phone string, message string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.messages = append(f.messages, Message{Phone: phone, Body: message})
+
+	return nil
+}
+
+// Messages returns the messages sent so far.
+func (f *FakeClient) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Message(nil), f.messages...)
+}
@@ -1,24 +1,40 @@
 package notification
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"go-ecommerce-app/configs"
+	"log/slog"
 
 	"github.com/twilio/twilio-go"
 	twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("go-ecommerce-app/notification")
+
 type NotificationClient interface {
-	SendSMS(phone string, message string) error
+	SendSMS(ctx context.Context, phone string, message string) error
 }
 
 type notificationClient struct {
-	config configs.AppConfig
+	config configs.SmsConfig
 }
 
 // Twilio
-func (c notificationClient) SendSMS(phone string, message string) error {
+func (c notificationClient) SendSMS(ctx context.Context, phone string, message string) error {
+	if !c.config.Enabled {
+		// the body can hold a verification code, so neither it nor the
+		// recipient goes to the logs
+		slog.InfoContext(ctx, "sms disabled, message not sent", "length", len(message))
+		return nil
+	}
+
+	ctx, span := tracer.Start(ctx, "twilio.messages.create", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
 	accountSid := c.config.TwilioAccountSid
 	authToken := c.config.TwilioAuthToken
 
@@ -34,17 +50,27 @@ func (c notificationClient) SendSMS(phone string, message string) error {
 
 	resp, err := client.Api.CreateMessage(params)
 	if err != nil {
-		fmt.Println("Error sending SMS message: " + err.Error())
-	} else {
-		response, _ := json.Marshal(*resp)
-		fmt.Println("Response: " + string(response))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "sending sms failed", "error", err)
+		return err
+	}
+
+	var sid, status string
+	if resp.Sid != nil {
+		sid = *resp.Sid
+	}
+	if resp.Status != nil {
+		status = *resp.Status
 	}
+	span.SetAttributes(attribute.String("twilio.message_sid", sid))
+	slog.InfoContext(ctx, "sms sent", "message_sid", sid, "status", status)
 
 	return nil
 }
 
 func NewNotificationClient(config configs.AppConfig) NotificationClient {
 	return &notificationClient{
-		config: config,
+		config: config.Sms,
 	}
 }
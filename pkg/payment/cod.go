@@ -0,0 +1,61 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+const cashOnDeliveryPrefix = "cod_"
+
+type cashOnDelivery struct{}
+
+// NewCashOnDeliveryClient returns a provider for orders paid in cash when
+// they are delivered. There is no payment page; the payment stays pending
+// until the cash is collected.
+func NewCashOnDeliveryClient() PaymentClient {
+	return cashOnDelivery{}
+}
+
+// CreatePayment implements PaymentClient.
+func (c cashOnDelivery) CreatePayment(ctx context.Context, amount float64, userId uint, orderId string, charges []Charge, discounts []Discount) (*Session, error) {
+	if len(orderId) < 1 {
+		return nil, errors.New("order id is required")
+	}
+
+	return &Session{
+		ID:       cashOnDeliveryPrefix + orderId,
+		Provider: ProviderCashOnDelivery,
+		Status:   StatusPending,
+		Detail:   "to be paid on delivery",
+	}, nil
+}
+
+// GetPaymentStatus implements PaymentClient.
+func (c cashOnDelivery) GetPaymentStatus(ctx context.Context, pId string) (*Session, error) {
+	if !strings.HasPrefix(pId, cashOnDeliveryPrefix) {
+		return nil, errors.New("failed to retrieve payment status")
+	}
+
+	return &Session{
+		ID:       pId,
+		Provider: ProviderCashOnDelivery,
+		Status:   StatusPending,
+		Detail:   "to be paid on delivery",
+	}, nil
+}
+
+// RefundPayment implements PaymentClient. Nothing has been collected before
+// delivery, so the payment is only called off.
+func (c cashOnDelivery) RefundPayment(ctx context.Context, pId string) (*Session, error) {
+	if !strings.HasPrefix(pId, cashOnDeliveryPrefix) {
+		return nil, errors.New("failed to refund payment")
+	}
+
+	return &Session{
+		ID:       pId,
+		Provider: ProviderCashOnDelivery,
+		Status:   StatusRefunded,
+		Detail:   "called off before delivery",
+	}, nil
+}
@@ -0,0 +1,114 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeClient keeps payment sessions in memory so the checkout flow can run
+// without a network. Tests move sessions along with Complete, Fail and
+// Expire.
+type FakeClient struct {
+	mu         sync.Mutex
+	successUrl string
+	next       int
+	sessions   map[string]*Session
+	amounts    map[string]float64
+}
+
+func NewFakeClient(successUrl string) *FakeClient {
+	return &FakeClient{
+		successUrl: successUrl,
+		sessions:   map[string]*Session{},
+		amounts:    map[string]float64{},
+	}
+}
+
+// CreatePayment implements PaymentClient.
+func (f *FakeClient) CreatePayment(ctx context.Context, amount float64, userId uint, orderId string, charges []Charge, discounts []Discount) (*Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	total := amount
+	for _, c := range charges {
+		total += c.Amount
+	}
+	for _, d := range discounts {
+		total -= d.Amount
+	}
+
+	f.next++
+	expiresAt := time.Now().Add(30 * time.Minute)
+	s := &Session{
+		ID:        fmt.Sprintf("fake_%d", f.next),
+		Provider:  ProviderFake,
+		Status:    StatusOpen,
+		ExpiresAt: &expiresAt,
+	}
+	s.URL = fmt.Sprintf("%s?session_id=%s", f.successUrl, s.ID)
+	f.sessions[s.ID] = s
+	f.amounts[s.ID] = total
+
+	copied := *s
+	return &copied, nil
+}
+
+// GetPaymentStatus implements PaymentClient.
+func (f *FakeClient) GetPaymentStatus(ctx context.Context, pId string) (*Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.sessions[pId]
+	if !ok {
+		return nil, errors.New("failed to retrieve payment status")
+	}
+	if s.Status == StatusOpen && s.ExpiresAt != nil && time.Now().After(*s.ExpiresAt) {
+		s.Status = StatusExpired
+	}
+
+	copied := *s
+	return &copied, nil
+}
+
+// RefundPayment implements PaymentClient.
+func (f *FakeClient) RefundPayment(ctx context.Context, pId string) (*Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.sessions[pId]
+	if !ok || s.Status != StatusPaid {
+		return nil, errors.New("failed to refund payment")
+	}
+	s.Status = StatusRefunded
+
+	copied := *s
+	return &copied, nil
+}
+
+// Amount returns the total the buyer was asked to pay in the session.
+func (f *FakeClient) Amount(pId string) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.amounts[pId]
+}
+
+func (f *FakeClient) Complete(pId string) error { return f.set(pId, StatusPaid) }
+func (f *FakeClient) Fail(pId string) error     { return f.set(pId, StatusFailed) }
+func (f *FakeClient) Expire(pId string) error   { return f.set(pId, StatusExpired) }
+
+func (f *FakeClient) set(pId string, status Status) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.sessions[pId]
+	if !ok {
+		return fmt.Errorf("unknown payment session %s", pId)
+	}
+	s.Status = status
+
+	return nil
+}
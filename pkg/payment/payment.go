@@ -0,0 +1,99 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	ProviderStripe         = "stripe"
+	ProviderCashOnDelivery = "cod"
+	ProviderFake           = "fake"
+	// ProviderNone settles checkouts with nothing to pay, such as an order a
+	// coupon covers in full, without going through a provider.
+	ProviderNone = "none"
+)
+
+// OfflineProviders collect the money outside the application, so their
+// payments stay pending until the money is recorded as collected.
+var OfflineProviders = []string{ProviderCashOnDelivery}
+
+func IsOffline(provider string) bool {
+	return slices.Contains(OfflineProviders, provider)
+}
+
+// Status is the provider-neutral state of a payment session.
+type Status string
+
+const (
+	// StatusOpen waits for the buyer to complete the payment page.
+	StatusOpen Status = "open"
+	// StatusPending waits for the money to arrive, e.g. an asynchronous bank
+	// transfer or cash collected on delivery.
+	StatusPending Status = "pending"
+	StatusPaid    Status = "paid"
+	StatusFailed  Status = "failed"
+	StatusExpired Status = "expired"
+	// StatusRefunded gave a paid session's money back, or called off an
+	// offline payment before anything was collected.
+	StatusRefunded Status = "refunded"
+)
+
+// Session is a payment started with a provider. URL is empty when the buyer
+// has nothing to complete online.
+type Session struct {
+	ID        string     `json:"id"`
+	Provider  string     `json:"provider"`
+	URL       string     `json:"url"`
+	Status    Status     `json:"status"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Detail    string     `json:"detail"`
+}
+
+// NothingToPay returns the session of a checkout with nothing to pay, which
+// is paid as soon as it is created.
+func NothingToPay(orderId string) *Session {
+	return &Session{
+		ID:       ProviderNone + "_" + orderId,
+		Provider: ProviderNone,
+		Status:   StatusPaid,
+		Detail:   "nothing to pay",
+	}
+}
+
+// Charge is an extra line, such as tax, added on top of the amount passed
+// to CreatePayment.
+type Charge struct {
+	Name   string
+	Amount float64
+}
+
+// Discount is shown as a separate line on the checkout page and is taken off
+// the amount passed to CreatePayment.
+type Discount struct {
+	Name   string
+	Amount float64
+}
+
+type PaymentClient interface {
+	CreatePayment(ctx context.Context, amount float64, userId uint, orderId string, charges []Charge, discounts []Discount) (*Session, error)
+	GetPaymentStatus(ctx context.Context, pId string) (*Session, error)
+	// RefundPayment gives the buyer their money back in full.
+	RefundPayment(ctx context.Context, pId string) (*Session, error)
+}
+
+// NewProvider builds the payment client configured by name.
+func NewProvider(name, stripeSecretKey, successUrl, cancelUrl string) (PaymentClient, error) {
+	switch name {
+	case "", ProviderStripe:
+		return NewPaymentClient(stripeSecretKey, successUrl, cancelUrl), nil
+	case ProviderCashOnDelivery:
+		return NewCashOnDeliveryClient(), nil
+	case ProviderFake:
+		return NewFakeClient(successUrl), nil
+	}
+
+	return nil, fmt.Errorf("unknown payment provider %q", name)
+}
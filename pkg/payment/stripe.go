@@ -1,17 +1,24 @@
 package payment
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/stripe/stripe-go/v78"
 	"github.com/stripe/stripe-go/v78/checkout/session"
+	"github.com/stripe/stripe-go/v78/coupon"
+	"github.com/stripe/stripe-go/v78/refund"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type PaymentClient interface {
-	CreatePayment(amount float64, userId uint, orderId string) (*stripe.CheckoutSession, error)
-	GetPaymentStatus(pId string) (*stripe.CheckoutSession, error)
-}
+var tracer = otel.Tracer("go-ecommerce-app/payment")
 
 type payment struct {
 	stripeSecretKey string
@@ -20,49 +27,219 @@ type payment struct {
 }
 
 // CreatePayment implements PaymentClient.
-func (p *payment) CreatePayment(amount float64, userId uint, orderId string) (*stripe.CheckoutSession, error) {
+func (p *payment) CreatePayment(ctx context.Context, amount float64, userId uint, orderId string, charges []Charge, discounts []Discount) (*Session, error) {
+	ctx, span := tracer.Start(ctx, "stripe.checkout.session.create", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
 	stripe.Key = p.stripeSecretKey
-	amountInCents := amount * 100
 
 	params := &stripe.CheckoutSessionParams{
 		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
-		LineItems: []*stripe.CheckoutSessionLineItemParams{
-			{
-				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-					UnitAmount: stripe.Int64(int64(amountInCents)),
-					Currency:   stripe.String(string(stripe.CurrencyUSD)),
-					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-						Name: stripe.String("electronic gadget"),
-					},
-				},
-				Quantity: stripe.Int64(1),
-			},
-		},
-		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
-		SuccessURL: stripe.String(p.successUrl),
-		CancelURL:  stripe.String(p.cancelUrl),
+		LineItems:          checkoutLineItems(amount, charges),
+		Mode:               stripe.String(string(stripe.CheckoutSessionModePayment)),
+		SuccessURL:         stripe.String(p.successUrl),
+		CancelURL:          stripe.String(p.cancelUrl),
 	}
 
-	params.AddMetadata("order_id", fmt.Sprintf("%s", orderId))
+	params.Context = ctx
+	params.AddMetadata("order_id", orderId)
 	params.AddMetadata("user_id", fmt.Sprintf("%d", userId))
 
+	if len(discounts) > 0 {
+		couponId, err := p.createCoupon(ctx, discounts)
+		if err != nil {
+			failSpan(span, err)
+			return nil, err
+		}
+		if len(couponId) > 0 {
+			params.Discounts = []*stripe.CheckoutSessionDiscountParams{
+				{Coupon: stripe.String(couponId)},
+			}
+		}
+	}
+
 	session, err := session.New(params)
 	if err != nil {
+		failSpan(span, err)
 		return nil, errors.New("failed to create checkout session")
 	}
-	return session, nil
+	span.SetAttributes(attribute.String("stripe.session_id", session.ID))
+
+	return stripeSession(session, time.Now()), nil
+}
+
+// checkoutLineItems puts the amount on the main line and each charge on a
+// line of its own.
+func checkoutLineItems(amount float64, charges []Charge) []*stripe.CheckoutSessionLineItemParams {
+	items := []*stripe.CheckoutSessionLineItemParams{lineItem("electronic gadget", amount)}
+	for _, c := range charges {
+		if c.Amount <= 0 {
+			continue
+		}
+		items = append(items, lineItem(c.Name, c.Amount))
+	}
+
+	return items
+}
+
+func lineItem(name string, amount float64) *stripe.CheckoutSessionLineItemParams {
+	return &stripe.CheckoutSessionLineItemParams{
+		PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+			UnitAmount: stripe.Int64(cents(amount)),
+			Currency:   stripe.String(string(stripe.CurrencyUSD)),
+			ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+				Name: stripe.String(name),
+			},
+		},
+		Quantity: stripe.Int64(1),
+	}
+}
+
+// cents rounds rather than truncates, as 19.99 * 100 is 1998.9999...
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// createCoupon turns the discounts into a single-use Stripe coupon, as a
+// checkout session accepts only one discount.
+func (p *payment) createCoupon(ctx context.Context, discounts []Discount) (string, error) {
+	var total float64
+	var names []string
+	for _, d := range discounts {
+		if d.Amount <= 0 {
+			continue
+		}
+		total += d.Amount
+		names = append(names, d.Name)
+	}
+	if total <= 0 {
+		return "", nil
+	}
+
+	name := strings.Join(names, ", ")
+	if len(name) > 40 {
+		name = name[:40]
+	}
+
+	ctx, span := tracer.Start(ctx, "stripe.coupon.create", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	c, err := coupon.New(&stripe.CouponParams{
+		Params:         stripe.Params{Context: ctx},
+		AmountOff:      stripe.Int64(cents(total)),
+		Currency:       stripe.String(string(stripe.CurrencyUSD)),
+		Duration:       stripe.String(string(stripe.CouponDurationOnce)),
+		MaxRedemptions: stripe.Int64(1),
+		Name:           stripe.String(name),
+	})
+	if err != nil {
+		failSpan(span, err)
+		return "", errors.New("failed to create checkout discount")
+	}
+
+	return c.ID, nil
 }
 
 // GetPaymentStatus implements PaymentClient.
-func (p *payment) GetPaymentStatus(pId string) (*stripe.CheckoutSession, error) {
+func (p *payment) GetPaymentStatus(ctx context.Context, pId string) (*Session, error) {
+	ctx, span := tracer.Start(ctx, "stripe.checkout.session.get",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("stripe.session_id", pId)),
+	)
+	defer span.End()
+
 	stripe.Key = p.stripeSecretKey
-	session, err := session.Get(pId, nil)
+	params := &stripe.CheckoutSessionParams{}
+	params.Context = ctx
+	params.AddExpand("payment_intent.latest_charge")
+	session, err := session.Get(pId, params)
+
+	if err != nil {
+		failSpan(span, err)
+		return nil, errors.New("failed to retrieve payment status")
+	}
 
+	return stripeSession(session, time.Now()), nil
+}
+
+// RefundPayment implements PaymentClient.
+func (p *payment) RefundPayment(ctx context.Context, pId string) (*Session, error) {
+	ctx, span := tracer.Start(ctx, "stripe.refund.create",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("stripe.session_id", pId)),
+	)
+	defer span.End()
+
+	stripe.Key = p.stripeSecretKey
+	params := &stripe.CheckoutSessionParams{}
+	params.Context = ctx
+	cs, err := session.Get(pId, params)
 	if err != nil {
+		failSpan(span, err)
 		return nil, errors.New("failed to retrieve payment status")
 	}
+	if cs.PaymentIntent == nil {
+		return nil, errors.New("the payment has nothing to refund")
+	}
+
+	_, err = refund.New(&stripe.RefundParams{
+		Params:        stripe.Params{Context: ctx},
+		PaymentIntent: stripe.String(cs.PaymentIntent.ID),
+	})
+	if err != nil {
+		failSpan(span, err)
+		return nil, errors.New("failed to refund payment")
+	}
+
+	s := stripeSession(cs, time.Now())
+	s.Status = StatusRefunded
+	return s, nil
+}
+
+func failSpan(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+func stripeSession(cs *stripe.CheckoutSession, now time.Time) *Session {
+	s := &Session{
+		ID:       cs.ID,
+		Provider: ProviderStripe,
+		URL:      cs.URL,
+		Status:   stripeStatus(cs, now),
+		Detail:   fmt.Sprintf("session %s, payment %s", cs.Status, cs.PaymentStatus),
+	}
+	if cs.PaymentIntent != nil {
+		s.Detail = fmt.Sprintf("%s, intent %s", s.Detail, cs.PaymentIntent.Status)
+	}
+	if cs.ExpiresAt > 0 {
+		expiresAt := time.Unix(cs.ExpiresAt, 0)
+		s.ExpiresAt = &expiresAt
+	}
+
+	return s
+}
+
+func stripeStatus(cs *stripe.CheckoutSession, now time.Time) Status {
+	switch {
+	case cs.PaymentIntent != nil && cs.PaymentIntent.LatestCharge != nil && cs.PaymentIntent.LatestCharge.Refunded:
+		return StatusRefunded
+	case cs.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid,
+		cs.PaymentStatus == stripe.CheckoutSessionPaymentStatusNoPaymentRequired:
+		return StatusPaid
+	case cs.PaymentIntent != nil && (cs.PaymentIntent.Status == stripe.PaymentIntentStatusCanceled ||
+		cs.Status == stripe.CheckoutSessionStatusComplete && cs.PaymentIntent.Status == stripe.PaymentIntentStatusRequiresPaymentMethod):
+		return StatusFailed
+	case cs.Status == stripe.CheckoutSessionStatusExpired:
+		return StatusExpired
+	case cs.Status == stripe.CheckoutSessionStatusComplete:
+		// paid with an asynchronous method that has not settled yet
+		return StatusPending
+	case cs.ExpiresAt > 0 && now.Unix() >= cs.ExpiresAt:
+		return StatusExpired
+	}
 
-	return session, nil
+	return StatusOpen
 }
 
 func NewPaymentClient(stripeSecretKey, successUrl, cancenUrl string) PaymentClient {
@@ -0,0 +1,34 @@
+package payment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v78"
+)
+
+func TestCheckoutLineItemsRoundToCents(t *testing.T) {
+	items := checkoutLineItems(19.99, []Charge{{Name: "Tax", Amount: 1.15}, {Name: "Shipping", Amount: 0}})
+
+	want := []int64{1999, 115}
+	if len(items) != len(want) {
+		t.Fatalf("line items = %d; want %d", len(items), len(want))
+	}
+	for i, w := range want {
+		if got := *items[i].PriceData.UnitAmount; got != w {
+			t.Fatalf("line %d = %d cents; want %d", i, got, w)
+		}
+	}
+}
+
+func TestStripeStatusOfRefundedSession(t *testing.T) {
+	cs := &stripe.CheckoutSession{
+		Status:        stripe.CheckoutSessionStatusComplete,
+		PaymentStatus: stripe.CheckoutSessionPaymentStatusPaid,
+		PaymentIntent: &stripe.PaymentIntent{LatestCharge: &stripe.Charge{Refunded: true}},
+	}
+
+	if got := stripeStatus(cs, time.Now()); got != StatusRefunded {
+		t.Fatalf("stripeStatus() = %s; want %s", got, StatusRefunded)
+	}
+}